@@ -0,0 +1,170 @@
+package boltstub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+func start(t *testing.T, script string, configurers ...neogo.Configurer) (*Server, neogo.Driver) {
+	t.Helper()
+	s, err := Start(script)
+	require.NoError(t, err)
+	d, err := neogo.New(s.URI(), neo4j.NoAuth(), configurers...)
+	require.NoError(t, err)
+	return s, d
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs queries", func(t *testing.T) {
+		s, d := start(t, `
+			!: BOLT 5.0
+
+			A: HELLO {"user_agent": "*", "scheme": "none"}
+			C: BEGIN {"mode": "r"}
+			S: SUCCESS {}
+			C: RUN "MATCH (p:Person)\nRETURN p" {"__isWrite": false} {}
+			   PULL {"n": 1000}
+			S: SUCCESS {"fields": ["p"]}
+			   RECORD [{"()": [1, ["Person"], {"id": "jessie", "name": "Jessie", "age": 6}]}]
+			   RECORD [{"()": [2, ["Person"], {"name": "Walter", "age": 6}, "4:db:2"]}]
+			   SUCCESS {"type": "r"}
+			C: COMMIT
+			S: SUCCESS {}
+			?: GOODBYE
+		`)
+		var people []*tests.Person
+		err := d.Exec().
+			Match(db.Node(db.Qual(&people, "p"))).
+			Return(&people).
+			Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, people, 2)
+		assert.Equal(t, "jessie", people[0].ID)
+		assert.Equal(t, "Jessie", people[0].Name)
+		assert.Equal(t, "Walter", people[1].Name)
+
+		require.NoError(t, d.DB().Close(ctx))
+		assert.NoError(t, s.Close())
+	})
+
+	t.Run("propagates bookmarks", func(t *testing.T) {
+		s, d := start(t, `
+			A: HELLO
+			C: BEGIN {}
+			S: SUCCESS {}
+			C: RUN "CREATE (:A)" "*" {}
+			   PULL "*"
+			S: SUCCESS {"fields": []}
+			   SUCCESS {"type": "w"}
+			C: COMMIT
+			S: SUCCESS {"bookmark": "bm:1"}
+			*: RESET
+			C: BEGIN {"mode": "r", "bookmarks": ["bm:1"]}
+			S: SUCCESS {}
+			C: RUN "MATCH (a:A)\nRETURN count(a)" "*" {}
+			   PULL "*"
+			S: SUCCESS {"fields": ["count(a)"]}
+			   RECORD [1]
+			   SUCCESS {"type": "r"}
+			C: COMMIT
+			S: SUCCESS {"bookmark": "bm:2"}
+			?: GOODBYE
+		`, neogo.WithCausalConsistency(func(ctx context.Context) string { return "user" }))
+
+		require.NoError(t, d.Exec().Cypher("CREATE (:A)").Run(ctx))
+		var n int
+		require.NoError(t, d.Exec().Cypher("MATCH (a:A)").Return(db.Qual(&n, "count(a)")).Run(ctx))
+		assert.Equal(t, 1, n)
+
+		require.NoError(t, d.DB().Close(ctx))
+		assert.NoError(t, s.Close())
+	})
+
+	t.Run("fails when the client diverges", func(t *testing.T) {
+		s, d := start(t, `
+			A: HELLO
+			C: BEGIN "*"
+			S: SUCCESS {}
+			C: RUN "RETURN 1" {"__isWrite": false} {}
+			   PULL "*"
+			S: SUCCESS {"fields": ["1"]}
+			   RECORD [1]
+			   SUCCESS {}
+			C: COMMIT
+			S: SUCCESS {}
+		`)
+		err := d.Exec().Cypher("RETURN 2").Run(ctx)
+		var neo4jErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neo4jErr)
+		assert.Contains(t, neo4jErr.Msg, `line 5: expected RUN "RETURN 1" {"__isWrite": false} {}, got RUN "RETURN 2"`)
+
+		require.NoError(t, d.DB().Close(ctx))
+		err = s.Close()
+		assert.ErrorContains(t, err, "line 5")
+	})
+
+	t.Run("sends failures", func(t *testing.T) {
+		s, d := start(t, `
+			A: HELLO
+			C: BEGIN "*"
+			S: SUCCESS {}
+			C: RUN "RETURN" "*" "*"
+			   PULL "*"
+			S: FAILURE {"code": "Neo.ClientError.Statement.SyntaxError", "message": "invalid"}
+			   IGNORED
+			A: RESET
+			?: GOODBYE
+		`)
+		err := d.Exec().Cypher("RETURN").Run(ctx)
+		var neo4jErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neo4jErr)
+		assert.Equal(t, "Neo.ClientError.Statement.SyntaxError", neo4jErr.Code)
+
+		require.NoError(t, d.DB().Close(ctx))
+		assert.NoError(t, s.Close())
+	})
+
+	t.Run("fails when the script isn't completed", func(t *testing.T) {
+		s, d := start(t, `
+			A: HELLO
+			C: BEGIN "*"
+			S: SUCCESS {}
+			C: RUN "RETURN 1" "*" "*"
+			   PULL "*"
+			S: SUCCESS {"fields": []}
+			   SUCCESS {}
+			C: COMMIT
+			S: SUCCESS {}
+			C: BEGIN "*"
+			S: SUCCESS {}
+		`)
+		require.NoError(t, d.Exec().Cypher("RETURN 1").Run(ctx))
+		require.NoError(t, d.DB().Close(ctx))
+		assert.ErrorContains(t, s.Close(), `line 11: connection closed, expected BEGIN "*"`)
+	})
+
+	t.Run("rejects restarts", func(t *testing.T) {
+		s, err := Start(`
+			A: HELLO
+			?: GOODBYE
+		`)
+		require.NoError(t, err)
+		for range 2 {
+			d, err := neo4j.NewDriverWithContext(s.URI(), neo4j.NoAuth())
+			require.NoError(t, err)
+			_ = d.VerifyConnectivity(ctx)
+			require.NoError(t, d.Close(ctx))
+		}
+		assert.ErrorContains(t, s.Close(), "connection 1: unexpected connection")
+	})
+}
@@ -0,0 +1,54 @@
+// Package boltstub provides a scriptable, in-process Bolt server, for testing
+// the path through the Neo4j driver without a Neo4j server.
+//
+//	s, err := boltstub.Start(`
+//		!: BOLT 5.0
+//
+//		A: HELLO
+//		C: BEGIN {"mode": "r"}
+//		S: SUCCESS {}
+//		C: RUN "MATCH (p:Person) RETURN p" {} {}
+//		   PULL {"n": 1000}
+//		S: SUCCESS {"fields": ["p"]}
+//		   RECORD [{"()": [1, ["Person"], {"name": "Jessie"}]}]
+//		   SUCCESS {"type": "r"}
+//		C: COMMIT
+//		S: SUCCESS {"bookmark": "bm:1"}
+//		?: GOODBYE
+//	`)
+//	...
+//	d, err := neogo.New(s.URI(), neo4j.NoAuth())
+//
+// Scripts declare the messages expected from the client, and the messages the
+// server responds with, one per line:
+//
+//   - C: expects a client message.
+//   - S: sends a server message: SUCCESS, RECORD, FAILURE or IGNORED. S: <EXIT>
+//     closes the connection.
+//   - A: expects a client message, and responds with SUCCESS {}.
+//   - ?: is an A: which may be skipped.
+//   - *: is an A: which may be repeated any number of times, including none.
+//   - !: is a directive, preceding all messages: BOLT <major>.<minor> sets the
+//     version negotiated with the client, which defaults to 5.0, ALLOW RESTART
+//     allows more than one connection, and ALLOW CONCURRENT serves connections
+//     concurrently.
+//
+// Indentation shared by all lines is ignored. Further indented lines continue
+// the step above them, and lines starting with # are comments.
+//
+// Messages are written as their name followed by their fields as JSON. Client
+// messages without fields match any fields. In fields of client messages, "*"
+// matches any value, and keys of objects written as "[key]" are optional.
+// JSON numbers are integers unless they contain a fraction or exponent.
+//
+// In server messages, nodes and relationships are written as objects with a
+// single key, "()" or "->":
+//
+//	{"()": [id, labels, properties, elementId]}
+//	{"->": [id, startId, endId, type, properties, elementId, startElementId, endElementId]}
+//
+// Element IDs may be omitted, in which case they're derived from the IDs.
+//
+// A client message which diverges from the script is answered with a FAILURE,
+// and the connection is closed. The divergence is reported by [Server.Close].
+package boltstub
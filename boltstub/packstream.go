@@ -0,0 +1,296 @@
+package boltstub
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// structure is a PackStream structure, such as a message or a node.
+type structure struct {
+	tag    byte
+	fields []any
+}
+
+// pack appends the PackStream encoding of v to b. v may be nil, a bool, an
+// int64, a float64, a string, []byte, []any, map[string]any or a structure.
+func pack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xC0), nil
+	case bool:
+		if v {
+			return append(b, 0xC3), nil
+		}
+		return append(b, 0xC2), nil
+	case int64:
+		return packInt(b, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xC1), math.Float64bits(v)), nil
+	case string:
+		b = packHeader(b, len(v), 0x80, 0xD0)
+		return append(b, v...), nil
+	case []byte:
+		switch n := len(v); {
+		case n <= math.MaxUint8:
+			b = append(b, 0xCC, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xCD), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xCE), uint32(n))
+		}
+		return append(b, v...), nil
+	case []any:
+		b = packHeader(b, len(v), 0x90, 0xD4)
+		for _, e := range v {
+			var err error
+			if b, err = pack(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = packHeader(b, len(v), 0xA0, 0xD8)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			var err error
+			if b, err = pack(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = pack(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case structure:
+		if len(v.fields) > 15 {
+			return nil, fmt.Errorf("structure %#x has too many fields", v.tag)
+		}
+		b = append(b, 0xB0|byte(len(v.fields)), v.tag)
+		for _, f := range v.fields {
+			var err error
+			if b, err = pack(b, f); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("cannot pack %T", v)
+	}
+}
+
+func packInt(b []byte, i int64) []byte {
+	switch {
+	case i >= -16 && i <= math.MaxInt8:
+		return append(b, byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(b, 0xC8, byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xC9), uint16(int16(i)))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xCA), uint32(int32(i)))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xCB), uint64(i))
+	}
+}
+
+// packHeader appends the header of a string, list or map of size n, where tiny
+// is the marker of sizes below 16 and marker8 is the marker of sizes which fit
+// in a byte.
+func packHeader(b []byte, n int, tiny, marker8 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, tiny|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, marker8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, marker8+1), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, marker8+2), uint32(n))
+	}
+}
+
+var errShortMessage = errors.New("message is truncated")
+
+// unpacker decodes PackStream values from a message.
+type unpacker struct {
+	b   []byte
+	err error
+}
+
+func (u *unpacker) read(n int) []byte {
+	if u.err != nil {
+		return nil
+	}
+	if len(u.b) < n {
+		u.err = errShortMessage
+		return nil
+	}
+	b := u.b[:n]
+	u.b = u.b[n:]
+	return b
+}
+
+// size reads a size of n bytes.
+func (u *unpacker) size(n int) int {
+	b := u.read(n)
+	if b == nil {
+		return 0
+	}
+	switch n {
+	case 1:
+		return int(b[0])
+	case 2:
+		return int(binary.BigEndian.Uint16(b))
+	default:
+		return int(binary.BigEndian.Uint32(b))
+	}
+}
+
+// unpack decodes the next value, in the representation accepted by pack.
+func (u *unpacker) unpack() any {
+	m := u.read(1)
+	if m == nil {
+		return nil
+	}
+	marker := m[0]
+	switch {
+	case marker < 0x80 || marker >= 0xF0:
+		return int64(int8(marker))
+	case marker < 0x90:
+		return string(u.read(int(marker & 0x0F)))
+	case marker < 0xA0:
+		return u.list(int(marker & 0x0F))
+	case marker < 0xB0:
+		return u.dict(int(marker & 0x0F))
+	case marker < 0xC0:
+		return u.structure(int(marker & 0x0F))
+	}
+	switch marker {
+	case 0xC0:
+		return nil
+	case 0xC1:
+		if b := u.read(8); b != nil {
+			return math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+		return nil
+	case 0xC2:
+		return false
+	case 0xC3:
+		return true
+	case 0xC8:
+		return int64(int8(u.size(1)))
+	case 0xC9:
+		return int64(int16(u.size(2)))
+	case 0xCA:
+		if b := u.read(4); b != nil {
+			return int64(int32(binary.BigEndian.Uint32(b)))
+		}
+		return nil
+	case 0xCB:
+		if b := u.read(8); b != nil {
+			return int64(binary.BigEndian.Uint64(b))
+		}
+		return nil
+	case 0xCC, 0xCD, 0xCE:
+		return append([]byte(nil), u.read(u.size(1<<(marker-0xCC)))...)
+	case 0xD0, 0xD1, 0xD2:
+		return string(u.read(u.size(1 << (marker - 0xD0))))
+	case 0xD4, 0xD5, 0xD6:
+		return u.list(u.size(1 << (marker - 0xD4)))
+	case 0xD8, 0xD9, 0xDA:
+		return u.dict(u.size(1 << (marker - 0xD8)))
+	}
+	if u.err == nil {
+		u.err = fmt.Errorf("unknown marker %#x", marker)
+	}
+	return nil
+}
+
+func (u *unpacker) list(n int) []any {
+	l := make([]any, 0, min(n, len(u.b)))
+	for i := 0; i < n && u.err == nil; i++ {
+		l = append(l, u.unpack())
+	}
+	return l
+}
+
+func (u *unpacker) dict(n int) map[string]any {
+	m := make(map[string]any, min(n, len(u.b)))
+	for i := 0; i < n && u.err == nil; i++ {
+		k, ok := u.unpack().(string)
+		if !ok && u.err == nil {
+			u.err = errors.New("map keys must be strings")
+		}
+		m[k] = u.unpack()
+	}
+	return m
+}
+
+func (u *unpacker) structure(n int) structure {
+	s := structure{}
+	if tag := u.read(1); tag != nil {
+		s.tag = tag[0]
+	}
+	s.fields = u.list(n)
+	return s
+}
+
+// readMessage reads a chunked message from r, skipping empty NOOP chunks.
+func readMessage(r io.Reader) (structure, error) {
+	var (
+		msg  []byte
+		size [2]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return structure{}, err
+		}
+		n := binary.BigEndian.Uint16(size[:])
+		if n == 0 {
+			if len(msg) == 0 {
+				continue
+			}
+			break
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return structure{}, err
+		}
+		msg = append(msg, chunk...)
+	}
+	u := &unpacker{b: msg}
+	s, ok := u.unpack().(structure)
+	if u.err != nil {
+		return structure{}, fmt.Errorf("cannot decode message: %w", u.err)
+	}
+	if !ok {
+		return structure{}, errors.New("cannot decode message: not a structure")
+	}
+	return s, nil
+}
+
+// writeMessage writes msg to w in chunks.
+func writeMessage(w io.Writer, msg structure) error {
+	b, err := pack(nil, msg)
+	if err != nil {
+		return err
+	}
+	var out []byte
+	for len(b) > 0 {
+		n := min(len(b), math.MaxUint16)
+		out = binary.BigEndian.AppendUint16(out, uint16(n))
+		out = append(out, b[:n]...)
+		b = b[n:]
+	}
+	out = append(out, 0, 0)
+	_, err = w.Write(out)
+	return err
+}
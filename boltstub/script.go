@@ -0,0 +1,438 @@
+package boltstub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Message tags, by name.
+var (
+	clientMessages = map[string]byte{
+		"HELLO":     0x01,
+		"GOODBYE":   0x02,
+		"RESET":     0x0F,
+		"RUN":       0x10,
+		"BEGIN":     0x11,
+		"COMMIT":    0x12,
+		"ROLLBACK":  0x13,
+		"DISCARD":   0x2F,
+		"PULL":      0x3F,
+		"TELEMETRY": 0x54,
+		"ROUTE":     0x66,
+		"LOGON":     0x6A,
+		"LOGOFF":    0x6B,
+	}
+	serverMessages = map[string]byte{
+		"SUCCESS": 0x70,
+		"RECORD":  0x71,
+		"IGNORED": 0x7E,
+		"FAILURE": 0x7F,
+	}
+)
+
+const (
+	tagSuccess = 0x70
+	tagFailure = 0x7F
+	tagGoodbye = 0x02
+	tagNode    = 'N'
+	tagRel     = 'R'
+)
+
+type stepKind byte
+
+const (
+	// stepClient expects a message from the client.
+	stepClient stepKind = 'C'
+	// stepAuto expects a message from the client, and responds with SUCCESS.
+	stepAuto stepKind = 'A'
+	// stepOptional is a stepAuto which may be skipped.
+	stepOptional stepKind = '?'
+	// stepRepeat is a stepAuto which may be repeated any number of times,
+	// including none.
+	stepRepeat stepKind = '*'
+	// stepServer sends a message to the client.
+	stepServer stepKind = 'S'
+	// stepExit closes the connection.
+	stepExit stepKind = 'X'
+)
+
+type (
+	script struct {
+		major, minor int
+		restart      bool
+		concurrent   bool
+		steps        []step
+	}
+
+	step struct {
+		line int
+		kind stepKind
+		msg  message
+	}
+
+	// message is a message in a script. For client messages, fields are
+	// patterns matched by [match], and are ignored if they're nil.
+	message struct {
+		name   string
+		tag    byte
+		fields []any
+	}
+)
+
+// parse parses the script text.
+func parse(text string) (*script, error) {
+	s := &script{major: 5, minor: 0}
+	var (
+		kind       stepKind
+		directives = true
+	)
+	lines := strings.Split(text, "\n")
+	indent := commonIndent(lines)
+	for i, line := range lines {
+		n := i + 1
+		line = strings.TrimPrefix(line, indent)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("boltstub: line %d: %s", n, fmt.Sprintf(format, args...))
+		}
+
+		// Indented lines continue the previous step.
+		if line[0] == ' ' || line[0] == '\t' {
+			if kind == 0 {
+				return nil, fail("indented line doesn't continue a message")
+			}
+		} else {
+			prefix, rest, ok := strings.Cut(trimmed, ":")
+			if !ok || len(prefix) != 1 {
+				return nil, fail("expected a line prefixed with !:, C:, S:, A:, ?: or *:")
+			}
+			trimmed = strings.TrimSpace(rest)
+			switch prefix {
+			case "!":
+				if !directives {
+					return nil, fail("directives must precede messages")
+				}
+				if err := s.directive(trimmed); err != nil {
+					return nil, fail("%v", err)
+				}
+				continue
+			case "C", "S", "A", "?", "*":
+				kind = stepKind(prefix[0])
+				directives = false
+			default:
+				return nil, fail("unknown prefix %q", prefix)
+			}
+		}
+
+		if kind == stepServer && trimmed == "<EXIT>" {
+			s.steps = append(s.steps, step{line: n, kind: stepExit})
+			continue
+		}
+		msg, err := s.message(kind, trimmed)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		s.steps = append(s.steps, step{line: n, kind: kind, msg: msg})
+	}
+	return s, nil
+}
+
+// commonIndent returns the indentation shared by all non-blank lines, so that
+// scripts may be indented to match the code around them.
+func commonIndent(lines []string) string {
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = prefix, false
+			continue
+		}
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	return indent
+}
+
+func (s *script) directive(d string) error {
+	switch fields := strings.Fields(d); {
+	case len(fields) == 2 && fields[0] == "BOLT":
+		major, minor, ok := strings.Cut(fields[1], ".")
+		var err1, err2 error
+		s.major, err1 = strconv.Atoi(major)
+		s.minor, err2 = strconv.Atoi(minor)
+		if !ok || err1 != nil || err2 != nil {
+			return fmt.Errorf("invalid Bolt version %q", fields[1])
+		}
+	case d == "ALLOW RESTART":
+		s.restart = true
+	case d == "ALLOW CONCURRENT":
+		s.concurrent = true
+	default:
+		return fmt.Errorf("unknown directive %q", d)
+	}
+	return nil
+}
+
+// message parses a message: its name followed by its fields as JSON values.
+func (s *script) message(kind stepKind, text string) (message, error) {
+	name, rest, _ := strings.Cut(text, " ")
+	msg := message{name: name}
+	tags := clientMessages
+	if kind == stepServer {
+		tags = serverMessages
+	}
+	tag, ok := tags[name]
+	if !ok {
+		return message{}, fmt.Errorf("unknown message %q", name)
+	}
+	msg.tag = tag
+
+	d := json.NewDecoder(strings.NewReader(rest))
+	d.UseNumber()
+	for {
+		var field any
+		if err := d.Decode(&field); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return message{}, fmt.Errorf("invalid fields of %s: %w", name, err)
+		}
+		if kind == stepServer {
+			v, err := s.value(field)
+			if err != nil {
+				return message{}, fmt.Errorf("invalid fields of %s: %w", name, err)
+			}
+			field = v
+		} else {
+			field = pattern(field)
+		}
+		msg.fields = append(msg.fields, field)
+	}
+	if kind == stepServer && msg.fields == nil {
+		msg.fields = []any{}
+	}
+	if kind == stepServer && msg.tag != serverMessages["IGNORED"] && len(msg.fields) != 1 {
+		return message{}, fmt.Errorf("%s must have exactly one field", name)
+	}
+	return msg, nil
+}
+
+// number converts a JSON number to an int64, or to a float64 if it has a
+// fraction or exponent.
+func number(n json.Number) (any, error) {
+	if strings.ContainsAny(n.String(), ".eE") {
+		return n.Float64()
+	}
+	return n.Int64()
+}
+
+// pattern converts a decoded JSON pattern to the values it matches.
+func pattern(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := number(v); err == nil {
+			return n
+		}
+		return v.String()
+	case []any:
+		for i, e := range v {
+			v[i] = pattern(e)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = pattern(e)
+		}
+	}
+	return v
+}
+
+// value converts a decoded JSON value to the value sent to the client. Nodes
+// and relationships are written as objects with a single key, "()" or "->",
+// whose value lists their fields as in Bolt 5:
+//
+//	{"()": [id, labels, properties, elementId]}
+//	{"->": [id, startId, endId, type, properties, elementId, startElementId, endElementId]}
+//
+// Element IDs may be omitted, in which case they're derived from the IDs.
+func (s *script) value(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return number(v)
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			var err error
+			if l[i], err = s.value(e); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case map[string]any:
+		if len(v) == 1 {
+			if fields, ok := v["()"]; ok {
+				return s.entity(tagNode, fields, 3, []int{0})
+			}
+			if fields, ok := v["->"]; ok {
+				return s.entity(tagRel, fields, 5, []int{0, 1, 2})
+			}
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			var err error
+			if m[k], err = s.value(e); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return v, nil
+}
+
+// entity converts the fields of a node or relationship to a structure with
+// tag, where n is the number of fields before Bolt 5, and ids are the indexes
+// of the IDs from which element IDs are derived.
+func (s *script) entity(tag byte, fields any, n int, ids []int) (any, error) {
+	f, err := s.value(fields)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := f.([]any)
+	if !ok || (len(l) != n && len(l) != n+len(ids)) {
+		return nil, fmt.Errorf("%c must have %d or %d fields", tag, n, n+len(ids))
+	}
+	if s.major < 5 {
+		return structure{tag: tag, fields: l[:n]}, nil
+	}
+	if len(l) == n {
+		for _, i := range ids {
+			l = append(l, fmt.Sprint(l[i]))
+		}
+	}
+	return structure{tag: tag, fields: l}, nil
+}
+
+// match reports whether v matches pattern p. The pattern "*" matches any value,
+// and keys of map patterns written as "[key]" are optional.
+func match(p, v any) bool {
+	switch p := p.(type) {
+	case string:
+		if p == "*" {
+			return true
+		}
+	case []any:
+		l, ok := v.([]any)
+		if !ok || len(l) != len(p) {
+			return false
+		}
+		for i := range p {
+			if !match(p[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return false
+		}
+		matched := 0
+		for k, e := range p {
+			optional := strings.HasPrefix(k, "[") && strings.HasSuffix(k, "]")
+			if optional {
+				k = k[1 : len(k)-1]
+			}
+			actual, ok := m[k]
+			if !ok {
+				if optional {
+					continue
+				}
+				return false
+			}
+			if !match(e, actual) {
+				return false
+			}
+			matched++
+		}
+		return matched == len(m)
+	}
+	return reflect.DeepEqual(p, v)
+}
+
+// matches reports whether the client sent msg, which has the given tag and
+// fields.
+func (m message) matches(msg structure) bool {
+	if m.tag != msg.tag {
+		return false
+	}
+	if m.fields == nil {
+		return true
+	}
+	return match(m.fields, msg.fields)
+}
+
+func (m message) String() string {
+	return format(m.name, m.fields)
+}
+
+// describe formats a message sent by the client.
+func describe(msg structure) string {
+	name := fmt.Sprintf("%#02x", msg.tag)
+	for n, tag := range clientMessages {
+		if tag == msg.tag {
+			name = n
+		}
+	}
+	return format(name, msg.fields)
+}
+
+func format(name string, fields []any) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(formatValue(f))
+	}
+	return b.String()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case []any:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = formatValue(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = strconv.Quote(k) + ": " + formatValue(v[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case structure:
+		return fmt.Sprintf("%c%s", v.tag, formatValue(v.fields))
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,112 @@
+package boltstub
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("parses scripts", func(t *testing.T) {
+		s, err := parse(`
+			!: BOLT 4.4
+			!: ALLOW RESTART
+
+			# Comment
+			A: HELLO
+			C: RUN "RETURN $x" {"x": 1.5} "*"
+			   PULL
+			S: SUCCESS {"fields": ["n"]}
+			   RECORD [{"()": [1, ["A"], {}]}, {"->": [2, 1, 1, "R", {}]}]
+			   <EXIT>
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, 4, s.major)
+		assert.Equal(t, 4, s.minor)
+		assert.True(t, s.restart)
+		assert.False(t, s.concurrent)
+
+		var kinds []stepKind
+		for _, st := range s.steps {
+			kinds = append(kinds, st.kind)
+		}
+		assert.Equal(t, []stepKind{stepAuto, stepClient, stepClient, stepServer, stepServer, stepExit}, kinds)
+		assert.Nil(t, s.steps[0].msg.fields)
+		assert.Equal(t, []any{"RETURN $x", map[string]any{"x": 1.5}, "*"}, s.steps[1].msg.fields)
+		assert.Equal(t, []any{[]any{
+			structure{tag: tagNode, fields: []any{int64(1), []any{"A"}, map[string]any{}}},
+			structure{tag: tagRel, fields: []any{int64(2), int64(1), int64(1), "R", map[string]any{}}},
+		}}, s.steps[4].msg.fields)
+	})
+
+	t.Run("derives element IDs", func(t *testing.T) {
+		s, err := parse(`S: RECORD [{"()": [1, ["A"], {}]}]`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{[]any{
+			structure{tag: tagNode, fields: []any{int64(1), []any{"A"}, map[string]any{}, "1"}},
+		}}, s.steps[0].msg.fields)
+	})
+
+	for script, want := range map[string]string{
+		"C: HELLO\n!: BOLT 5.0":     "line 2: directives must precede messages",
+		"!: BOLT five":              `line 1: invalid Bolt version "five"`,
+		"!: ALLOW EVERYTHING":       `line 1: unknown directive "ALLOW EVERYTHING"`,
+		"C: SUCCESS {}":             `line 1: unknown message "SUCCESS"`,
+		"S: RUN":                    `line 1: unknown message "RUN"`,
+		"S: SUCCESS":                "line 1: SUCCESS must have exactly one field",
+		"C: RUN {":                  "line 1: invalid fields of RUN",
+		"X: HELLO":                  `line 1: unknown prefix "X"`,
+		"HELLO":                     "line 1: expected a line prefixed with",
+		"S: RECORD [{\"()\": [1]}]": "line 1: invalid fields of RECORD: N must have 3 or 4 fields",
+	} {
+		t.Run(want, func(t *testing.T) {
+			_, err := parse(script)
+			assert.ErrorContains(t, err, want)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p := pattern(map[string]any{"mode": "r", "[db]": "neo4j", "bookmarks": "*"})
+	assert.True(t, match(p, map[string]any{"mode": "r", "bookmarks": []any{"bm"}}))
+	assert.True(t, match(p, map[string]any{"mode": "r", "db": "neo4j", "bookmarks": nil}))
+	assert.False(t, match(p, map[string]any{"mode": "r", "db": "system", "bookmarks": nil}))
+	assert.False(t, match(p, map[string]any{"mode": "r"}), "missing key")
+	assert.False(t, match(p, map[string]any{"mode": "r", "bookmarks": nil, "x": 1}), "extra key")
+	assert.False(t, match([]any{int64(1)}, []any{int64(1), int64(2)}))
+	assert.False(t, match(int64(1), 1.0))
+}
+
+func TestPackStream(t *testing.T) {
+	values := []any{
+		nil, true, false,
+		int64(0), int64(-16), int64(-17), int64(127), int64(128), int64(math.MinInt16),
+		int64(math.MaxInt32), int64(math.MinInt64), 1.5,
+		"", "short", strings.Repeat("long", 100), []byte{1, 2, 3},
+		[]any{int64(1), "a", nil}, make([]any, 20),
+		map[string]any{"a": int64(1), "b": []any{}},
+		structure{tag: tagNode, fields: []any{int64(1), []any{"A"}, map[string]any{}}},
+	}
+	for _, v := range values {
+		b, err := pack(nil, v)
+		require.NoError(t, err)
+		u := &unpacker{b: b}
+		got := u.unpack()
+		require.NoError(t, u.err)
+		assert.Empty(t, u.b)
+		assert.Equal(t, v, got)
+	}
+
+	t.Run("chunks messages", func(t *testing.T) {
+		msg := structure{tag: tagSuccess, fields: []any{map[string]any{"s": strings.Repeat("x", 70000)}}}
+		var buf bytes.Buffer
+		require.NoError(t, writeMessage(&buf, msg))
+		got, err := readMessage(bytes.NewReader(append([]byte{0, 0}, buf.Bytes()...)))
+		require.NoError(t, err)
+		assert.Equal(t, msg, got)
+	})
+}
@@ -0,0 +1,280 @@
+package boltstub
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+var errClosed = errors.New("connection closed")
+
+// Server is an in-process Bolt server which responds to connections as
+// scripted.
+//
+// Each connection runs the script from its first step. Unless the script
+// allows restarts, only one connection may be made, and unless it allows
+// concurrency, connections are served one at a time.
+type Server struct {
+	script   *script
+	listener net.Listener
+
+	// serving serializes connections, unless the script allows concurrency.
+	serving sync.Mutex
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	count int
+	errs  []error
+	wg    sync.WaitGroup
+}
+
+// Start parses script and starts a [Server] running it, listening on a random
+// port of 127.0.0.1. Occurrences of #ADDRESS# in script are replaced with the
+// address of the server, for scripting routing tables.
+//
+//	s, err := boltstub.Start(script)
+//	...
+//	d, err := neogo.New(s.URI(), neo4j.NoAuth())
+//	...
+//	if err := s.Close(); err != nil {
+//		t.Error(err)
+//	}
+func Start(script string) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("boltstub: cannot listen: %w", err)
+	}
+	parsed, err := parse(strings.ReplaceAll(script, "#ADDRESS#", l.Addr().String()))
+	if err != nil {
+		return nil, errors.Join(err, l.Close())
+	}
+	s := &Server{
+		script:   parsed,
+		listener: l,
+		conns:    map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Address returns the host and port the server is listening on.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// URI returns the bolt:// URI of the server.
+func (s *Server) URI() string {
+	return "bolt://" + s.Address()
+}
+
+// Close stops the server, closing any open connections, and returns an error
+// describing how the connections diverged from the script.
+//
+// Connections which haven't completed the script fail, so the driver should be
+// closed before the server.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := s.errs
+	if s.count == 0 && len(s.script.steps) > 0 {
+		errs = append(errs, errors.New("boltstub: no connections were made"))
+	}
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return errors.Join(append(errs, err)...)
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		n := s.count
+		s.count++
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			err := s.serve(c, n)
+			_ = c.Close()
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.conns, c)
+			if err != nil {
+				s.errs = append(s.errs, fmt.Errorf("boltstub: connection %d: %w", n, err))
+			}
+		}()
+	}
+}
+
+// serve runs the script on the n-th connection c.
+func (s *Server) serve(c net.Conn, n int) error {
+	if n > 0 && !s.script.restart {
+		return errors.New("unexpected connection, the script doesn't allow restarts")
+	}
+	if !s.script.concurrent {
+		s.serving.Lock()
+		defer s.serving.Unlock()
+	}
+	r := bufio.NewReader(c)
+	if err := s.handshake(r, c); err != nil {
+		return err
+	}
+	i := &interpreter{script: s.script, w: c}
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			return i.finish()
+		}
+		switch err := i.handle(msg); {
+		case errors.Is(err, errClosed):
+			return i.finish()
+		case err != nil:
+			_ = writeMessage(c, structure{tag: tagFailure, fields: []any{map[string]any{
+				"code":    "Neo.ClientError.Request.Invalid",
+				"message": "boltstub: " + err.Error(),
+			}}})
+			return err
+		}
+	}
+}
+
+// handshake negotiates the Bolt version of the script.
+func (s *Server) handshake(r io.Reader, w io.Writer) error {
+	var b [20]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	if binary.BigEndian.Uint32(b[:4]) != 0x6060B017 {
+		return fmt.Errorf("handshake failed: invalid magic %#x", b[:4])
+	}
+	major, minor := byte(s.script.major), byte(s.script.minor)
+	for p := b[4:]; len(p) > 0; p = p[4:] {
+		back, top, pMajor := p[1], p[2], p[3]
+		if pMajor == major && minor <= top && minor+back >= top {
+			_, err := w.Write([]byte{0, 0, minor, major})
+			return err
+		}
+	}
+	_, _ = w.Write([]byte{0, 0, 0, 0})
+	return fmt.Errorf("handshake failed: the client doesn't support Bolt %d.%d", major, minor)
+}
+
+// interpreter runs a script on a connection.
+type interpreter struct {
+	script *script
+	w      io.Writer
+	pc     int
+}
+
+// handle responds to msg, sent by the client. It returns errClosed if the
+// connection should be closed.
+func (i *interpreter) handle(msg structure) error {
+	steps := i.script.steps
+	for i.pc < len(steps) {
+		st := steps[i.pc]
+		matches := st.msg.matches(msg)
+		switch st.kind {
+		case stepClient, stepAuto:
+			if !matches {
+				return fmt.Errorf("line %d: expected %s, got %s", st.line, st.msg, describe(msg))
+			}
+			i.pc++
+			if st.kind == stepAuto {
+				if err := i.succeed(msg); err != nil {
+					return err
+				}
+			}
+			return i.respond()
+		case stepOptional:
+			i.pc++
+			if matches {
+				if err := i.succeed(msg); err != nil {
+					return err
+				}
+				return i.respond()
+			}
+		case stepRepeat:
+			if matches {
+				return i.succeed(msg)
+			}
+			i.pc++
+		default:
+			return fmt.Errorf("line %d: expected the server to respond", st.line)
+		}
+	}
+	if msg.tag == tagGoodbye {
+		return errClosed
+	}
+	return fmt.Errorf("unexpected %s after the end of the script", describe(msg))
+}
+
+// succeed responds to msg with an empty SUCCESS, unless it's GOODBYE, which
+// closes the connection.
+func (i *interpreter) succeed(msg structure) error {
+	if msg.tag == tagGoodbye {
+		return errClosed
+	}
+	return writeMessage(i.w, structure{tag: tagSuccess, fields: []any{map[string]any{}}})
+}
+
+// respond sends the server messages following the current step.
+func (i *interpreter) respond() error {
+	steps := i.script.steps
+	var msgs []structure
+	exit := false
+	for ; i.pc < len(steps) && !exit; i.pc++ {
+		st := steps[i.pc]
+		if st.kind == stepExit {
+			exit = true
+		} else if st.kind == stepServer {
+			msgs = append(msgs, structure{tag: st.msg.tag, fields: st.msg.fields})
+		} else {
+			break
+		}
+	}
+	for _, msg := range msgs {
+		if err := writeMessage(i.w, msg); err != nil {
+			return err
+		}
+	}
+	if exit {
+		return errClosed
+	}
+	return nil
+}
+
+// finish returns an error if the connection closed before the script was
+// completed.
+func (i *interpreter) finish() error {
+	for _, st := range i.script.steps[i.pc:] {
+		switch st.kind {
+		case stepOptional, stepRepeat:
+		case stepServer, stepExit:
+			return fmt.Errorf("line %d: connection closed before the server responded", st.line)
+		default:
+			return fmt.Errorf("line %d: connection closed, expected %s", st.line, st.msg)
+		}
+	}
+	return nil
+}
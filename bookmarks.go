@@ -0,0 +1,179 @@
+package neogo
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// DefaultBookmarkTTL is the duration bookmarks are retained for by the
+	// default [BookmarkStore].
+	DefaultBookmarkTTL = 5 * time.Minute
+	// DefaultBookmarkStoreSize is the maximum number of keys retained by the
+	// default [BookmarkStore].
+	DefaultBookmarkStoreSize = 10_000
+)
+
+// BookmarkStore stores the bookmarks used to provide causal consistency,
+// partitioned by the key returned by the function given to
+// [WithCausalConsistency].
+//
+// Implementations must be safe for concurrent use. They may be backed by an
+// external store, allowing causal consistency to be shared between processes.
+type BookmarkStore interface {
+	// Get returns the bookmarks stored for key, or nil if there are none.
+	Get(ctx context.Context, key string) (neo4j.Bookmarks, error)
+
+	// Update replaces the previous bookmarks stored for key with current.
+	// Bookmarks stored for key which are not in previous are retained.
+	Update(ctx context.Context, key string, previous, current neo4j.Bookmarks) error
+}
+
+// WithBookmarkStore configures the [BookmarkStore] used for causal consistency.
+// Defaults to an in-memory store created with [NewMemoryBookmarkStore].
+func WithBookmarkStore(store BookmarkStore) Configurer {
+	return func(c *Config) {
+		c.BookmarkStore = store
+	}
+}
+
+// NewMemoryBookmarkStore creates an in-memory [BookmarkStore].
+//
+// Keys expire ttl after they were last updated, and once more than maxSize
+// keys are stored, the least recently updated keys are evicted. A ttl or
+// maxSize of zero disables the respective form of eviction.
+func NewMemoryBookmarkStore(ttl time.Duration, maxSize int) BookmarkStore {
+	return &memoryBookmarkStore{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+type (
+	memoryBookmarkStore struct {
+		ttl     time.Duration
+		maxSize int
+		now     func() time.Time
+
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		// order holds the entries from most to least recently updated.
+		order *list.List
+	}
+	memoryBookmarkEntry struct {
+		key       string
+		bookmarks neo4j.Bookmarks
+		expiresAt time.Time
+	}
+)
+
+func (s *memoryBookmarkStore) Get(ctx context.Context, key string) (neo4j.Bookmarks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := elem.Value.(*memoryBookmarkEntry)
+	if s.expired(entry) {
+		s.remove(elem)
+		return nil, nil
+	}
+	return append(neo4j.Bookmarks(nil), entry.bookmarks...), nil
+}
+
+func (s *memoryBookmarkStore) Update(ctx context.Context, key string, previous, current neo4j.Bookmarks) error {
+	if len(current) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bookmarks neo4j.Bookmarks
+	if elem, ok := s.entries[key]; ok {
+		if entry := elem.Value.(*memoryBookmarkEntry); !s.expired(entry) {
+			bookmarks = entry.bookmarks
+		}
+		s.remove(elem)
+	}
+	entry := &memoryBookmarkEntry{
+		key:       key,
+		bookmarks: replaceBookmarks(bookmarks, previous, current),
+	}
+	if s.ttl > 0 {
+		entry.expiresAt = s.now().Add(s.ttl)
+	}
+	s.entries[key] = s.order.PushFront(entry)
+
+	// Entries are ordered by their last update, so expired entries and those
+	// exceeding the size limit are found at the back.
+	for back := s.order.Back(); back != nil; back = s.order.Back() {
+		if !s.expired(back.Value.(*memoryBookmarkEntry)) &&
+			(s.maxSize <= 0 || s.order.Len() <= s.maxSize) {
+			break
+		}
+		s.remove(back)
+	}
+	return nil
+}
+
+func (s *memoryBookmarkStore) expired(entry *memoryBookmarkEntry) bool {
+	return !entry.expiresAt.IsZero() && !s.now().Before(entry.expiresAt)
+}
+
+func (s *memoryBookmarkStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*memoryBookmarkEntry).key)
+}
+
+// replaceBookmarks returns bookmarks with previous removed and current added,
+// without duplicates.
+func replaceBookmarks(bookmarks, previous, current neo4j.Bookmarks) neo4j.Bookmarks {
+	removed := make(map[string]struct{}, len(previous))
+	for _, b := range previous {
+		removed[b] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(bookmarks)+len(current))
+	out := make(neo4j.Bookmarks, 0, len(bookmarks)+len(current))
+	for _, b := range bookmarks {
+		if _, ok := removed[b]; ok {
+			continue
+		}
+		if _, ok := seen[b]; !ok {
+			seen[b] = struct{}{}
+			out = append(out, b)
+		}
+	}
+	for _, b := range current {
+		if _, ok := seen[b]; !ok {
+			seen[b] = struct{}{}
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// bookmarkManager adapts a [BookmarkStore] partition to a
+// [neo4j.BookmarkManager], allowing the driver to read and update bookmarks
+// for every transaction in a session.
+type bookmarkManager struct {
+	store BookmarkStore
+	key   string
+}
+
+var _ neo4j.BookmarkManager = (*bookmarkManager)(nil)
+
+func (m *bookmarkManager) GetBookmarks(ctx context.Context) (neo4j.Bookmarks, error) {
+	return m.store.Get(ctx, m.key)
+}
+
+func (m *bookmarkManager) UpdateBookmarks(ctx context.Context, previous, current neo4j.Bookmarks) error {
+	return m.store.Update(ctx, m.key, previous, current)
+}
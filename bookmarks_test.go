@@ -0,0 +1,279 @@
+package neogo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+)
+
+// fakeRedis is a stand-in for a key-value store which stores strings with an
+// expiry, such as Redis.
+type fakeRedis struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(now func() time.Time) *fakeRedis {
+	return &fakeRedis{
+		now:     now,
+		values:  map[string]string{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (r *fakeRedis) get(key string) (string, bool) {
+	if exp, ok := r.expires[key]; ok && !r.now().Before(exp) {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	v, ok := r.values[key]
+	return v, ok
+}
+
+func (r *fakeRedis) setEx(key, value string, ttl time.Duration) {
+	r.values[key] = value
+	r.expires[key] = r.now().Add(ttl)
+}
+
+// redisBookmarkStore is a [BookmarkStore] backed by [fakeRedis], serializing
+// bookmarks as newline-separated strings.
+type redisBookmarkStore struct {
+	redis *fakeRedis
+	ttl   time.Duration
+}
+
+func (s *redisBookmarkStore) Get(ctx context.Context, key string) (neo4j.Bookmarks, error) {
+	s.redis.mu.Lock()
+	defer s.redis.mu.Unlock()
+	v, ok := s.redis.get("bookmarks:" + key)
+	if !ok || v == "" {
+		return nil, nil
+	}
+	return strings.Split(v, "\n"), nil
+}
+
+func (s *redisBookmarkStore) Update(ctx context.Context, key string, previous, current neo4j.Bookmarks) error {
+	if len(current) == 0 {
+		return nil
+	}
+	s.redis.mu.Lock()
+	defer s.redis.mu.Unlock()
+	var stored neo4j.Bookmarks
+	if v, ok := s.redis.get("bookmarks:" + key); ok && v != "" {
+		stored = strings.Split(v, "\n")
+	}
+	bookmarks := replaceBookmarks(stored, previous, current)
+	s.redis.setEx("bookmarks:"+key, strings.Join(bookmarks, "\n"), s.ttl)
+	return nil
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	cur time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cur
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cur = c.cur.Add(d)
+}
+
+func TestMemoryBookmarkStore(t *testing.T) {
+	ctx := context.Background()
+	newStore := func(ttl time.Duration, maxSize int) (*memoryBookmarkStore, *fakeClock) {
+		clock := &fakeClock{cur: time.Unix(0, 0)}
+		store := NewMemoryBookmarkStore(ttl, maxSize).(*memoryBookmarkStore)
+		store.now = clock.now
+		return store, clock
+	}
+
+	t.Run("replaces previous bookmarks", func(t *testing.T) {
+		store, _ := newStore(0, 0)
+		require.NoError(t, store.Update(ctx, "k", nil, neo4j.Bookmarks{"a", "b"}))
+		require.NoError(t, store.Update(ctx, "k", neo4j.Bookmarks{"a"}, neo4j.Bookmarks{"c", "b"}))
+		bookmarks, err := store.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.Bookmarks{"b", "c"}, bookmarks)
+
+		bookmarks, err = store.Get(ctx, "other")
+		require.NoError(t, err)
+		assert.Nil(t, bookmarks)
+	})
+
+	t.Run("ignores empty updates", func(t *testing.T) {
+		store, _ := newStore(0, 0)
+		require.NoError(t, store.Update(ctx, "k", nil, nil))
+		assert.Empty(t, store.entries)
+	})
+
+	t.Run("expires keys after ttl", func(t *testing.T) {
+		store, clock := newStore(time.Minute, 0)
+		require.NoError(t, store.Update(ctx, "k", nil, neo4j.Bookmarks{"a"}))
+		clock.advance(59 * time.Second)
+		bookmarks, _ := store.Get(ctx, "k")
+		assert.Equal(t, neo4j.Bookmarks{"a"}, bookmarks)
+
+		clock.advance(time.Second)
+		bookmarks, _ = store.Get(ctx, "k")
+		assert.Nil(t, bookmarks)
+		assert.Empty(t, store.entries)
+	})
+
+	t.Run("updates refresh ttl", func(t *testing.T) {
+		store, clock := newStore(time.Minute, 0)
+		require.NoError(t, store.Update(ctx, "k", nil, neo4j.Bookmarks{"a"}))
+		clock.advance(30 * time.Second)
+		require.NoError(t, store.Update(ctx, "k", nil, neo4j.Bookmarks{"b"}))
+		clock.advance(45 * time.Second)
+		bookmarks, _ := store.Get(ctx, "k")
+		assert.Equal(t, neo4j.Bookmarks{"a", "b"}, bookmarks)
+	})
+
+	t.Run("evicts expired keys on update", func(t *testing.T) {
+		store, clock := newStore(time.Minute, 0)
+		require.NoError(t, store.Update(ctx, "old", nil, neo4j.Bookmarks{"a"}))
+		clock.advance(time.Minute)
+		require.NoError(t, store.Update(ctx, "new", nil, neo4j.Bookmarks{"b"}))
+		assert.Len(t, store.entries, 1)
+		assert.Contains(t, store.entries, "new")
+	})
+
+	t.Run("evicts least recently updated keys", func(t *testing.T) {
+		store, _ := newStore(0, 2)
+		require.NoError(t, store.Update(ctx, "a", nil, neo4j.Bookmarks{"1"}))
+		require.NoError(t, store.Update(ctx, "b", nil, neo4j.Bookmarks{"2"}))
+		require.NoError(t, store.Update(ctx, "a", nil, neo4j.Bookmarks{"3"}))
+		require.NoError(t, store.Update(ctx, "c", nil, neo4j.Bookmarks{"4"}))
+
+		bookmarks, _ := store.Get(ctx, "b")
+		assert.Nil(t, bookmarks)
+		bookmarks, _ = store.Get(ctx, "a")
+		assert.Equal(t, neo4j.Bookmarks{"1", "3"}, bookmarks)
+		bookmarks, _ = store.Get(ctx, "c")
+		assert.Equal(t, neo4j.Bookmarks{"4"}, bookmarks)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		store, clock := newStore(time.Minute, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("k%d", i%20)
+				for j := 0; j < 100; j++ {
+					_ = store.Update(ctx, key, nil, neo4j.Bookmarks{fmt.Sprint(j)})
+					_, _ = store.Get(ctx, key)
+					if j%10 == 0 {
+						clock.advance(time.Second)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, len(store.entries), 10)
+		assert.Equal(t, len(store.entries), store.order.Len())
+	})
+}
+
+func TestBookmarkStore(t *testing.T) {
+	ctx := context.Background()
+	type keyCtx struct{}
+	withKey := func(key string) context.Context {
+		return context.WithValue(ctx, keyCtx{}, key)
+	}
+	newDriver := func(store BookmarkStore) (mockDriver, *recordingNeo4jDriver) {
+		m, rec := newRecordingMock()
+		impl := m.(*mockDriverImpl)
+		impl.causalConsistencyKey = func(ctx context.Context) string {
+			key, _ := ctx.Value(keyCtx{}).(string)
+			return key
+		}
+		impl.bookmarkStore = store
+		return m, rec
+	}
+
+	t.Run("shares bookmarks between drivers via an external store", func(t *testing.T) {
+		clock := &fakeClock{cur: time.Unix(0, 0)}
+		store := &redisBookmarkStore{redis: newFakeRedis(clock.now), ttl: time.Minute}
+		writer, writerRec := newDriver(store)
+		reader, readerRec := newDriver(store)
+
+		writer.Bind(nil)
+		var p Person
+		err := writer.Exec().Create(db.Node(&p)).Run(withKey("user-1"))
+		require.NoError(t, err)
+		require.Len(t, writerRec.sessionConfigs, 1)
+		manager := writerRec.sessionConfigs[0].BookmarkManager
+		require.NotNil(t, manager)
+
+		// The neo4j driver reports new bookmarks to the manager once the
+		// transaction commits.
+		require.NoError(t, manager.UpdateBookmarks(ctx, nil, neo4j.Bookmarks{"bm:1"}))
+
+		sess, err := reader.ReadSession(withKey("user-1"))
+		require.NoError(t, err)
+		require.NoError(t, sess.Close(ctx))
+		require.Len(t, readerRec.sessionConfigs, 1)
+		bookmarks, err := readerRec.sessionConfigs[0].BookmarkManager.GetBookmarks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.Bookmarks{"bm:1"}, bookmarks)
+
+		clock.advance(time.Minute)
+		bookmarks, err = store.Get(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Nil(t, bookmarks)
+	})
+
+	t.Run("partitions bookmarks by key", func(t *testing.T) {
+		store := NewMemoryBookmarkStore(time.Minute, 10)
+		m, rec := newDriver(store)
+		m.Bind(nil)
+		m.Bind(nil)
+		require.NoError(t, m.Exec().Return(db.Qual(1, "n")).Run(withKey("a")))
+		require.NoError(t, m.Exec().Return(db.Qual(1, "n")).Run(withKey("b")))
+		require.Len(t, rec.sessionConfigs, 2)
+
+		require.NoError(t, rec.sessionConfigs[0].BookmarkManager.UpdateBookmarks(ctx, nil, neo4j.Bookmarks{"a:1"}))
+		bookmarks, err := rec.sessionConfigs[1].BookmarkManager.GetBookmarks(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, bookmarks)
+	})
+
+	t.Run("skips sessions without a key", func(t *testing.T) {
+		m, rec := newDriver(NewMemoryBookmarkStore(0, 0))
+		m.Bind(nil)
+		require.NoError(t, m.Exec().Return(db.Qual(1, "n")).Run(ctx))
+		require.Len(t, rec.sessionConfigs, 1)
+		assert.Nil(t, rec.sessionConfigs[0].BookmarkManager)
+	})
+
+	t.Run("prefers explicit bookmark managers", func(t *testing.T) {
+		m, rec := newDriver(NewMemoryBookmarkStore(0, 0))
+		m.Bind(nil)
+		explicit := neo4j.NewBookmarkManager(neo4j.BookmarkManagerConfig{})
+		err := m.Exec(WithSessionConfig(func(sc *neo4j.SessionConfig) {
+			sc.BookmarkManager = explicit
+		})).Return(db.Qual(1, "n")).Run(withKey("a"))
+		require.NoError(t, err)
+		require.Len(t, rec.sessionConfigs, 1)
+		assert.Equal(t, explicit, rec.sessionConfigs[0].BookmarkManager)
+	})
+}
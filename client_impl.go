@@ -4,12 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 
-	"github.com/goccy/go-json"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 
+	"github.com/rlch/neogo/cypherfmt"
 	"github.com/rlch/neogo/internal"
 	"github.com/rlch/neogo/query"
 )
@@ -58,6 +59,9 @@ type (
 )
 
 func (s *session) newClient(cy *internal.CypherClient) *clientImpl {
+	if s.driver != nil && s.idGenerator != nil {
+		cy.SetIDGenerator(s.idGenerator)
+	}
 	return &clientImpl{
 		session: s,
 		cy:      cy,
@@ -248,36 +252,79 @@ func (c *runnerImpl) GetRunner() *internal.CypherRunner {
 	return c.cy
 }
 
+func (c *yielderImpl) Compile() (*internal.CompiledCypher, error) {
+	return c.newRunner(c.cy.CypherRunner).Compile()
+}
+
+func (c *querierImpl) Compile() (*internal.CompiledCypher, error) {
+	return c.newRunner(c.cy.CypherRunner).Compile()
+}
+
+// Compile compiles the query without running it, canonicalizing its
+// parameters as they would be sent to Neo4j.
+func (c *runnerImpl) Compile() (*internal.CompiledCypher, error) {
+	cy, err := c.cy.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile cypher: %w", err)
+	}
+	params, err := c.canonicalizeParams(cy.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize parameters: %w", err)
+	}
+	compiled := *cy
+	compiled.Parameters = params
+	return &compiled, nil
+}
+
 func (c *runnerImpl) Print() query.Runner {
 	c.cy.Print()
 	return c
 }
 
+func (c *runnerImpl) Format(w io.Writer, opts cypherfmt.Options) error {
+	cy, err := c.cy.Compile()
+	if err != nil {
+		return fmt.Errorf("cannot compile cypher: %w", err)
+	}
+	return cypherfmt.Format(w, cy.Cypher, opts)
+}
+
 func (c *runnerImpl) run(
 	ctx context.Context,
 	params map[string]any,
+	opts []query.RunOption,
 	mapResult func(r neo4j.ResultWithContext) (any, error),
 ) (out any, err error) {
 	cy, err := c.cy.CompileWithParams(params)
 	if err != nil {
 		return nil, fmt.Errorf("cannot compile cypher: %w", err)
 	}
-	canonicalizedParams, err := canonicalizeParams(cy.Parameters)
+	canonicalizedParams, err := c.canonicalizeParams(cy.Parameters)
 	if err != nil {
 		return nil, fmt.Errorf("cannot serialize parameters: %w", err)
 	}
+	return c.execute(ctx, cy, canonicalizedParams, opts, mapResult)
+}
+
+func (c *runnerImpl) execute(
+	ctx context.Context,
+	cy *internal.CompiledCypher,
+	canonicalizedParams map[string]any,
+	opts []query.RunOption,
+	mapResult func(r neo4j.ResultWithContext) (any, error),
+) (out any, err error) {
 	if canonicalizedParams != nil {
 		canonicalizedParams["__isWrite"] = cy.IsWrite
 	}
 	return c.executeTransaction(
-		ctx, cy,
-		func(tx neo4j.ManagedTransaction) (any, error) {
+		ctx, cy, opts,
+		func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
 			var result neo4j.ResultWithContext
 			result, err = tx.Run(ctx, cy.Cypher, canonicalizedParams)
 			if err != nil {
 				return nil, fmt.Errorf("cannot run cypher: %w", err)
 			}
-			err = c.unmarshalResult(ctx, cy, result)
+			err = c.decoding(opts).unmarshalResult(ctx, cy, result)
 			if err != nil {
 				return nil, err
 			}
@@ -288,22 +335,71 @@ func (c *runnerImpl) run(
 		})
 }
 
-func (c *runnerImpl) RunWithParams(ctx context.Context, params map[string]any) (err error) {
-	_, err = c.run(ctx, params, nil)
+func (c *runnerImpl) RunWithParams(ctx context.Context, params map[string]any, opts ...query.RunOption) (err error) {
+	_, err = c.run(ctx, params, opts, nil)
 	return
 }
 
-func (c *runnerImpl) Run(ctx context.Context) (err error) {
-	_, err = c.run(ctx, nil, nil)
+func (c *runnerImpl) Run(ctx context.Context, opts ...query.RunOption) (err error) {
+	_, err = c.run(ctx, nil, opts, nil)
 	return
 }
 
-func (c *runnerImpl) RunSummary(ctx context.Context) (neo4j.ResultSummary, error) {
-	return c.RunSummaryWithParams(ctx, nil)
+func (c *runnerImpl) RunSummary(ctx context.Context, opts ...query.RunOption) (neo4j.ResultSummary, error) {
+	return c.RunSummaryWithParams(ctx, nil, opts...)
+}
+
+func (c *runnerImpl) RunSummaryWithParams(ctx context.Context, params map[string]any, opts ...query.RunOption) (neo4j.ResultSummary, error) {
+	summary, err := c.run(ctx, params, opts, func(r neo4j.ResultWithContext) (any, error) {
+		return r.Consume(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary.(neo4j.ResultSummary), nil
+}
+
+func (c *runnerImpl) Explain(ctx context.Context, opts ...query.RunOption) (*query.Plan, error) {
+	summary, err := c.runPlan(ctx, "EXPLAIN", opts)
+	if err != nil {
+		return nil, err
+	}
+	plan := query.NewPlan(summary.Plan())
+	if plan == nil {
+		return nil, errors.New("no plan returned by EXPLAIN")
+	}
+	return plan, nil
+}
+
+func (c *runnerImpl) Profile(ctx context.Context, opts ...query.RunOption) (*query.Plan, error) {
+	summary, err := c.runPlan(ctx, "PROFILE", opts)
+	if err != nil {
+		return nil, err
+	}
+	plan := query.NewProfiledPlan(summary.Profile())
+	if plan == nil {
+		return nil, errors.New("no plan returned by PROFILE")
+	}
+	return plan, nil
 }
 
-func (c *runnerImpl) RunSummaryWithParams(ctx context.Context, params map[string]any) (neo4j.ResultSummary, error) {
-	summary, err := c.run(ctx, params, func(r neo4j.ResultWithContext) (any, error) {
+// runPlan executes the query prefixed with EXPLAIN or PROFILE and returns its
+// summary.
+func (c *runnerImpl) runPlan(ctx context.Context, prefix string, opts []query.RunOption) (neo4j.ResultSummary, error) {
+	cy, err := c.cy.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile cypher: %w", err)
+	}
+	canonicalizedParams, err := c.canonicalizeParams(cy.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize parameters: %w", err)
+	}
+	planned := *cy
+	planned.Cypher = prefix + " " + cy.Cypher
+	// Only the plan is returned, so the records of a profiled query are
+	// consumed without being bound.
+	planned.Bindings = nil
+	summary, err := c.execute(ctx, &planned, canonicalizedParams, opts, func(r neo4j.ResultWithContext) (any, error) {
 		return r.Consume(ctx)
 	})
 	if err != nil {
@@ -312,23 +408,23 @@ func (c *runnerImpl) RunSummaryWithParams(ctx context.Context, params map[string
 	return summary.(neo4j.ResultSummary), nil
 }
 
-func (c *runnerImpl) StreamWithParams(ctx context.Context, params map[string]any, sink func(r query.Result) error) (err error) {
+func (c *runnerImpl) StreamWithParams(ctx context.Context, params map[string]any, sink func(r query.Result) error, opts ...query.RunOption) (err error) {
 	cy, err := c.cy.CompileWithParams(params)
 	if err != nil {
 		return fmt.Errorf("cannot compile cypher: %w", err)
 	}
-	canonicalizedParams, err := canonicalizeParams(cy.Parameters)
+	canonicalizedParams, err := c.canonicalizeParams(cy.Parameters)
 	if err != nil {
 		return fmt.Errorf("cannot serialize parameters: %w", err)
 	}
-	_, err = c.executeTransaction(ctx, cy, func(tx neo4j.ManagedTransaction) (any, error) {
+	_, err = c.executeTransaction(ctx, cy, opts, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
 		var result neo4j.ResultWithContext
 		result, err = tx.Run(ctx, cy.Cypher, canonicalizedParams)
 		if err != nil {
 			return nil, fmt.Errorf("cannot run cypher: %w", err)
 		}
 		err := sink(&resultImpl{
-			session:           c.session,
+			session:           c.decoding(opts),
 			ResultWithContext: result,
 			compiled:          cy,
 		})
@@ -340,8 +436,8 @@ func (c *runnerImpl) StreamWithParams(ctx context.Context, params map[string]any
 	return err
 }
 
-func (c *runnerImpl) Stream(ctx context.Context, sink func(r query.Result) error) (err error) {
-	return c.StreamWithParams(ctx, nil, sink)
+func (c *runnerImpl) Stream(ctx context.Context, sink func(r query.Result) error, opts ...query.RunOption) (err error) {
+	return c.StreamWithParams(ctx, nil, sink, opts...)
 }
 
 func (c *resultImpl) Peek(ctx context.Context) bool {
@@ -367,6 +463,17 @@ func (c *resultImpl) Read() error {
 	return nil
 }
 
+// decoding returns the session used to decode results of a query run with
+// opts.
+func (s *session) decoding(opts []query.RunOption) *session {
+	if s.strict || !query.NewRunConfig(opts...).StrictDecoding {
+		return s
+	}
+	strict := *s
+	strict.strict = true
+	return &strict
+}
+
 func (s *session) unmarshalResult(
 	ctx context.Context,
 	cy *internal.CompiledCypher,
@@ -435,7 +542,7 @@ func (s *session) unmarshalRecords(
 			if err := s.bindValue(value, to); err != nil {
 				return fmt.Errorf(
 					"error binding key %s to type %T: %w",
-					key, binding.Interface(), err,
+					key, binding.Interface(), prefixDecodeError(fmt.Sprintf("%s[%d]", key, i), err),
 				)
 			}
 		}
@@ -455,7 +562,7 @@ func (s *session) unmarshalRecord(
 		if err := s.bindValue(value, binding); err != nil {
 			return fmt.Errorf(
 				"error binding key %q to type %T: %w",
-				key, binding.Interface(), err,
+				key, binding.Interface(), prefixDecodeError(key, err),
 			)
 		}
 	}
@@ -465,8 +572,15 @@ func (s *session) unmarshalRecord(
 func (c *runnerImpl) executeTransaction(
 	ctx context.Context,
 	cy *internal.CompiledCypher,
-	exec neo4j.ManagedTransactionWork,
+	opts []query.RunOption,
+	exec func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error),
 ) (out any, err error) {
+	runConfig := query.NewRunConfig(opts...)
+	if c.currentTx != nil || c.Session() != nil {
+		if err := validateRunConfigInSession(runConfig); err != nil {
+			return nil, err
+		}
+	}
 	if c.currentTx == nil {
 		sess := c.Session()
 		sessConfig := neo4j.SessionConfig{
@@ -475,7 +589,6 @@ func (c *runnerImpl) executeTransaction(
 			//  - the query is a write query
 			AccessMode: neo4j.AccessModeRead,
 		}
-		c.ensureCausalConsistency(ctx, &sessConfig)
 		if sess == nil {
 			if conf := c.execConfig.SessionConfig; conf != nil {
 				sessConfig = *conf
@@ -485,47 +598,48 @@ func (c *runnerImpl) executeTransaction(
 			} else {
 				sessConfig.AccessMode = neo4j.AccessModeRead
 			}
-			if err := c.sessionSemaphore.Acquire(ctx, 1); err != nil {
+			applyRunConfigToSession(runConfig, &sessConfig)
+			c.ensureCausalConsistency(ctx, &sessConfig)
+			if err := c.sessionPool.acquire(ctx); err != nil {
 				return nil, err
 			}
 			sess = c.db.NewSession(ctx, sessConfig)
 			defer func() {
-				if sessConfig.AccessMode == neo4j.AccessModeWrite {
-					bookmarks := sess.LastBookmarks()
-					if bookmarks != nil && c.causalConsistencyKey != nil {
-						key := c.causalConsistencyKey(ctx)
-						if cur, ok := causalConsistencyCache[key]; ok {
-							causalConsistencyCache[key] = neo4j.CombineBookmarks(cur, bookmarks)
-						} else {
-							causalConsistencyCache[key] = bookmarks
-							go func(key string) {
-								<-ctx.Done()
-								causalConsistencyCache[key] = nil
-							}(key)
-						}
-					}
-				}
 				if closeErr := sess.Close(ctx); closeErr != nil {
 					err = errors.Join(err, closeErr)
 				}
-				c.sessionSemaphore.Release(1)
+				c.sessionPool.release()
 			}()
 		}
 		config := func(tc *neo4j.TransactionConfig) {
 			if conf := c.execConfig.TransactionConfig; conf != nil {
 				*tc = *conf
 			}
+			applyRunConfigToTransaction(runConfig, tc)
+		}
+		work := func(tx neo4j.ManagedTransaction) (any, error) {
+			return exec(ctx, tx)
 		}
 		if cy.IsWrite || sessConfig.AccessMode == neo4j.AccessModeWrite {
-			out, err = sess.ExecuteWrite(ctx, exec, config)
+			out, err = sess.ExecuteWrite(ctx, work, config)
 		} else {
-			out, err = sess.ExecuteRead(ctx, exec, config)
+			out, err = sess.ExecuteRead(ctx, work, config)
 		}
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		out, err = exec(c.currentTx)
+		// The transaction has already begun, so its timeout is enforced by the
+		// client and its metadata can no longer be set.
+		if len(runConfig.Metadata) > 0 {
+			return nil, errors.New("cannot use run option Metadata within an existing transaction")
+		}
+		if runConfig.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, runConfig.Timeout)
+			defer cancel()
+		}
+		out, err = exec(ctx, c.currentTx)
 		if err != nil {
 			return nil, err
 		}
@@ -533,43 +647,66 @@ func (c *runnerImpl) executeTransaction(
 	return
 }
 
-func canonicalizeParams(params map[string]any) (map[string]any, error) {
-	canon := make(map[string]any, len(params))
-	if len(params) == 0 {
-		return canon, nil
+// validateRunConfigInSession ensures that none of the options which configure
+// a session are used when the session has already been created.
+func validateRunConfigInSession(rc *query.RunConfig) error {
+	var invalid []string
+	if rc.Database != "" {
+		invalid = append(invalid, "Database")
 	}
-	for k, v := range params {
-		if v == nil {
-			canon[k] = nil
+	if rc.FetchSize != 0 {
+		invalid = append(invalid, "FetchSize")
+	}
+	if rc.ImpersonatedUser != "" {
+		invalid = append(invalid, "ImpersonatedUser")
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf(
+			"cannot use run options %s within an existing session or transaction",
+			strings.Join(invalid, ", "),
+		)
+	}
+	return nil
+}
+
+func applyRunConfigToSession(rc *query.RunConfig, sc *neo4j.SessionConfig) {
+	if rc.Database != "" {
+		sc.DatabaseName = rc.Database
+	}
+	if rc.FetchSize != 0 {
+		sc.FetchSize = rc.FetchSize
+	}
+	if rc.ImpersonatedUser != "" {
+		sc.ImpersonatedUser = rc.ImpersonatedUser
+	}
+}
+
+func applyRunConfigToTransaction(rc *query.RunConfig, tc *neo4j.TransactionConfig) {
+	if rc.Timeout > 0 {
+		tc.Timeout = rc.Timeout
+	}
+	if len(rc.Metadata) > 0 {
+		metadata := make(map[string]any, len(tc.Metadata)+len(rc.Metadata))
+		for k, v := range tc.Metadata {
+			metadata[k] = v
 		}
-		vv := reflect.ValueOf(v)
-		for vv.Kind() == reflect.Ptr {
-			vv = vv.Elem()
+		for k, v := range rc.Metadata {
+			metadata[k] = v
 		}
-		switch vv.Kind() {
-		case reflect.Slice:
-			bytes, err := json.Marshal(v)
-			if err != nil {
-				return nil, fmt.Errorf("cannot marshal slice: %w", err)
-			}
-			var js []any
-			if err := json.Unmarshal(bytes, &js); err != nil {
-				return nil, fmt.Errorf("cannot unmarshal slice: %w", err)
-			}
-			canon[k] = js
-		case reflect.Map, reflect.Struct:
-			bytes, err := json.Marshal(v)
-			if err != nil {
-				return nil, fmt.Errorf("cannot marshal map: %w", err)
-			}
-			var js any
-			if err := json.Unmarshal(bytes, &js); err != nil {
-				return nil, fmt.Errorf("cannot unmarshal map: %w", err)
-			}
-			canon[k] = js
-		default:
-			canon[k] = v
+		tc.Metadata = metadata
+	}
+}
+
+// canonicalizeParams encodes params into values that can be sent to the neo4j
+// driver.
+func (r *registry) canonicalizeParams(params map[string]any) (map[string]any, error) {
+	canon := make(map[string]any, len(params))
+	for k, v := range params {
+		encoded, err := r.encode(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode parameter %q: %w", k, err)
 		}
+		canon[k] = encoded
 	}
 	return canon, nil
 }
@@ -3,12 +3,15 @@ package neogo
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/rlch/neogo/cypherfmt"
 	"github.com/rlch/neogo/db"
 	"github.com/rlch/neogo/internal"
 	"github.com/rlch/neogo/internal/tests"
@@ -17,7 +20,7 @@ import (
 
 func TestUnmarshalRecord(t *testing.T) {
 	s := &session{}
-	s.registerTypes(&tests.Human{}, &tests.Dog{})
+	require.NoError(t, s.registerTypes(&tests.Human{}, &tests.Dog{}))
 	t.Run("err on non-existent key", func(t *testing.T) {
 		n := tests.Person{}
 		cy := &internal.CompiledCypher{
@@ -258,7 +261,7 @@ func TestUnmarshalRecord(t *testing.T) {
 
 func TestUnmarshalRecords(t *testing.T) {
 	s := &session{}
-	s.registerTypes(&tests.Human{}, &tests.Dog{})
+	require.NoError(t, s.registerTypes(&tests.Human{}, &tests.Dog{}))
 
 	t.Run("err on non-existent key", func(t *testing.T) {
 		n1 := tests.Person{}
@@ -436,7 +439,7 @@ func TestUnmarshalRecords(t *testing.T) {
 
 	t.Run("binds to abstract nodes", func(t *testing.T) {
 		s := &session{}
-		s.registerTypes(&tests.Dog{}, &tests.Human{})
+		require.NoError(t, s.registerTypes(&tests.Dog{}, &tests.Human{}))
 		var n []tests.Organism
 		cy := &internal.CompiledCypher{
 			Bindings: map[string]reflect.Value{
@@ -504,7 +507,7 @@ func TestUnmarshalRecords(t *testing.T) {
 
 	t.Run("binds to [][]Abstract", func(t *testing.T) {
 		s := &session{}
-		s.registerTypes(&tests.BasePet{}, &tests.Human{})
+		require.NoError(t, s.registerTypes(&tests.BasePet{}, &tests.Human{}))
 		var n [][]tests.Organism
 		cy := &internal.CompiledCypher{
 			Bindings: map[string]reflect.Value{
@@ -569,7 +572,7 @@ func TestUnmarshalRecords(t *testing.T) {
 
 	t.Run("binds to [][]Concrete where Concrete is an implementation of Abstract", func(t *testing.T) {
 		s := &session{}
-		s.registerTypes(&tests.BasePet{})
+		require.NoError(t, s.registerTypes(&tests.BasePet{}))
 		var n [][]tests.BasePet
 		cy := &internal.CompiledCypher{
 			Bindings: map[string]reflect.Value{
@@ -808,6 +811,131 @@ func TestRunSummary(t *testing.T) {
 	})
 }
 
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	plan := &MockPlan{
+		Operator:    "ProduceResults@neo4j",
+		Identifiers: []string{"p"},
+		Rows:        1,
+		DbHits:      1,
+		Children: []MockPlan{{
+			Operator: "NodeIndexSeek@neo4j",
+			Arguments: map[string]any{
+				"Details":       "RANGE INDEX p:Person(name) WHERE name = $name",
+				"EstimatedRows": 1.0,
+			},
+			Identifiers: []string{"p"},
+			Rows:        1,
+			DbHits:      2,
+		}},
+	}
+
+	t.Run("explains with mock", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`^EXPLAIN MATCH \(p:Person`).WillReturnSummary(MockSummary{Plan: plan})
+
+		var p Person
+		got, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"name": "$name"}))).
+			Return(&p).
+			Explain(ctx)
+		require.NoError(t, err)
+		require.NoError(t, m.ExpectationsWereMet())
+		assert.False(t, got.Profiled)
+		assert.Equal(t, "ProduceResults", got.Name())
+		require.Len(t, got.Children, 1)
+		assert.Equal(t, 1.0, got.Children[0].EstimatedRows)
+		assert.True(t, got.UsesIndexOn("Person", "name"))
+		assert.False(t, got.UsesIndexOn("Person", "age"))
+		assert.Zero(t, got.Rows)
+	})
+
+	t.Run("profiles with mock", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`^PROFILE MATCH \(p:Person`).WillReturnSummary(MockSummary{Plan: plan})
+
+		var p Person
+		got, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"name": "$name"}))).
+			Return(&p).
+			Profile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, m.ExpectationsWereMet())
+		assert.True(t, got.Profiled)
+		assert.Equal(t, int64(1), got.Rows)
+		assert.Equal(t, int64(3), got.TotalDbHits())
+		assert.True(t, got.UsesIndexOn("Person", "name"))
+	})
+
+	t.Run("errors without a plan", func(t *testing.T) {
+		m := NewMock()
+		m.Bind(nil)
+		_, err := m.Exec().Cypher("RETURN 1").Explain(ctx)
+		assert.ErrorContains(t, err, "no plan returned by EXPLAIN")
+	})
+
+	if testing.Short() {
+		return
+	}
+	uri, cancel := startNeo4J(ctx)
+	d, err := New(uri, neo4j.BasicAuth("neo4j", "password", ""))
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := cancel(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("explains without executing", func(t *testing.T) {
+		var p Person
+		plan, err := d.Exec().
+			Create(db.Node(db.Qual(&p, "p", db.Props{"name": "'Explained'"}))).
+			Return(&p).
+			Explain(ctx)
+		require.NoError(t, err)
+		assert.False(t, plan.Profiled)
+		assert.Equal(t, "ProduceResults", plan.Name())
+		assert.Zero(t, p)
+	})
+
+	t.Run("profiles with runtime statistics", func(t *testing.T) {
+		var n int
+		plan, err := d.Exec().
+			Unwind("range(1, 3)", "i").
+			Return(db.Qual(&n, "i")).
+			Profile(ctx)
+		require.NoError(t, err)
+		assert.True(t, plan.Profiled)
+		assert.Equal(t, int64(3), plan.Rows)
+		assert.Zero(t, n)
+	})
+}
+
+func TestFormat(t *testing.T) {
+	var p Person
+	var b strings.Builder
+	err := NewMock().Exec().
+		Match(db.Node(db.Qual(&p, "p", db.Props{"name": "'Jessie'"}))).
+		Subquery(func(c Query) query.Runner {
+			return c.With(&p).
+				Match(db.Node(&p).To(db.Var("r", db.Label("KNOWS")), db.Var("friend"))).
+				Return(db.Qual("count(friend)", "friends"))
+		}).
+		Return(&p, "friends").
+		Format(&b, cypherfmt.Options{Indent: "    "})
+	require.NoError(t, err)
+	assert.Equal(t, `MATCH (p:Person {name: 'Jessie'})
+CALL {
+    WITH p
+    MATCH (p)-[r:KNOWS]->(friend)
+    RETURN count(friend) AS friends
+}
+RETURN p, friends
+`, b.String())
+}
+
 func TestResultImpl(t *testing.T) {
 	// TODO: Setup mocks
 	if testing.Short() {
@@ -820,7 +948,10 @@ func TestResultImpl(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create driver: %v", err)
 	}
-	readSession := d.ReadSession(ctx)
+	readSession, err := d.ReadSession(ctx)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
 	session := &session{session: readSession.Session()}
 
 	t.Cleanup(func() {
@@ -880,11 +1011,11 @@ func TestResultImpl(t *testing.T) {
 				Return(n).
 				Compile()
 			assert.NoError(t, err)
-			params, err := canonicalizeParams(cy.Parameters)
+			params, err := session.canonicalizeParams(cy.Parameters)
 			assert.NoError(t, err)
 
 			r := runnerImpl{session: session}
-			_, err = r.executeTransaction(ctx, cy, func(tx neo4j.ManagedTransaction) (any, error) {
+			_, err = r.executeTransaction(ctx, cy, nil, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
 				var result neo4j.ResultWithContext
 				result, err = tx.Run(ctx, cy.Cypher, params)
 				assert.NoError(t, err)
@@ -991,3 +1122,153 @@ func TestClient(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+type (
+	recordingNeo4jDriver struct {
+		*mockNeo4jDriver
+		sessionConfigs []neo4j.SessionConfig
+		txConfigs      []neo4j.TransactionConfig
+		cyphers        []string
+		params         []map[string]any
+		deadlines      []bool
+	}
+	recordingNeo4jSession struct {
+		*mockNeo4jSession
+		d *recordingNeo4jDriver
+	}
+	recordingNeo4jTx struct {
+		*mockNeo4jTx
+		d *recordingNeo4jDriver
+	}
+)
+
+func newRecordingMock(configurers ...Configurer) (mockDriver, *recordingNeo4jDriver) {
+	m := NewMock(configurers...)
+	impl := m.(*mockDriverImpl)
+	rec := &recordingNeo4jDriver{mockNeo4jDriver: impl.driver.db.(*mockNeo4jDriver)}
+	impl.driver.db = rec
+	return m, rec
+}
+
+func (d *recordingNeo4jDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	d.sessionConfigs = append(d.sessionConfigs, config)
+	return &recordingNeo4jSession{
+		mockNeo4jSession: d.mockNeo4jDriver.NewSession(ctx, config).(*mockNeo4jSession),
+		d:                d,
+	}
+}
+
+func (s *recordingNeo4jSession) execute(work neo4j.ManagedTransactionWork, configurers []func(*neo4j.TransactionConfig)) (any, error) {
+	var config neo4j.TransactionConfig
+	for _, c := range configurers {
+		c(&config)
+	}
+	s.d.txConfigs = append(s.d.txConfigs, config)
+	return work(&recordingNeo4jTx{
+		mockNeo4jTx: &mockNeo4jTx{mockBindings: s.mockBindings},
+		d:           s.d,
+	})
+}
+
+func (s *recordingNeo4jSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(work, configurers)
+}
+
+func (s *recordingNeo4jSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(work, configurers)
+}
+
+func (t *recordingNeo4jTx) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	_, hasDeadline := ctx.Deadline()
+	t.d.cyphers = append(t.d.cyphers, cypher)
+	t.d.params = append(t.d.params, params)
+	t.d.deadlines = append(t.d.deadlines, hasDeadline)
+	return t.mockNeo4jTx.Run(ctx, cypher, params)
+}
+
+func TestRunOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("configures managed transactions", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		err := m.Exec(WithTxConfig(func(tc *neo4j.TransactionConfig) {
+			tc.Metadata = map[string]any{"app": "neogo"}
+		})).
+			Return(db.Qual(1, "n")).
+			Run(ctx,
+				Timeout(time.Second),
+				Metadata(map[string]any{"request": "abc"}),
+				FetchSize(10),
+				Database("movies"),
+				ImpersonatedUser("jessie"),
+			)
+		require.NoError(t, err)
+
+		require.Len(t, rec.sessionConfigs, 1)
+		assert.Equal(t, "movies", rec.sessionConfigs[0].DatabaseName)
+		assert.Equal(t, 10, rec.sessionConfigs[0].FetchSize)
+		assert.Equal(t, "jessie", rec.sessionConfigs[0].ImpersonatedUser)
+		assert.Equal(t, neo4j.AccessModeRead, rec.sessionConfigs[0].AccessMode)
+
+		require.Len(t, rec.txConfigs, 1)
+		assert.Equal(t, time.Second, rec.txConfigs[0].Timeout)
+		assert.Equal(t, map[string]any{"app": "neogo", "request": "abc"}, rec.txConfigs[0].Metadata)
+	})
+
+	t.Run("applies options to streams", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		err := m.Exec().
+			Return(db.Qual(1, "n")).
+			Stream(ctx, func(r query.Result) error { return nil }, Database("movies"))
+		require.NoError(t, err)
+		require.Len(t, rec.sessionConfigs, 1)
+		assert.Equal(t, "movies", rec.sessionConfigs[0].DatabaseName)
+	})
+
+	t.Run("configures existing transactions", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		m.Bind(nil)
+		sess, err := m.ReadSession(ctx)
+		require.NoError(t, err)
+		err = sess.ReadTransaction(ctx, func(begin func() Query) error {
+			return begin().
+				Return(db.Qual(1, "n")).
+				Run(ctx, Timeout(time.Second))
+		})
+		require.NoError(t, err)
+		require.NoError(t, sess.Close(ctx))
+
+		assert.Equal(t, []string{"RETURN n"}, rec.cyphers)
+		assert.Equal(t, []bool{true}, rec.deadlines)
+	})
+
+	t.Run("errors on metadata in existing transactions", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		sess, err := m.ReadSession(ctx)
+		require.NoError(t, err)
+		err = sess.ReadTransaction(ctx, func(begin func() Query) error {
+			return begin().
+				Return(db.Qual(1, "n")).
+				Run(ctx, Metadata(map[string]any{"request": "abc"}))
+		})
+		require.NoError(t, sess.Close(ctx))
+		assert.ErrorContains(t, err, "cannot use run option Metadata within an existing transaction")
+		assert.Empty(t, rec.cyphers)
+	})
+
+	t.Run("errors on session options in existing transactions", func(t *testing.T) {
+		m, _ := newRecordingMock()
+		sess, err := m.ReadSession(ctx)
+		require.NoError(t, err)
+		err = sess.ReadTransaction(ctx, func(begin func() Query) error {
+			return begin().
+				Return(db.Qual(1, "n")).
+				Run(ctx, Database("movies"), FetchSize(10))
+		})
+		require.NoError(t, sess.Close(ctx))
+		assert.ErrorContains(t, err, "cannot use run options Database, FetchSize within an existing session or transaction")
+	})
+}
@@ -0,0 +1,1105 @@
+package neogo
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"github.com/rlch/neogo/internal"
+)
+
+// The codec converts between Go values and the values understood by the neo4j
+// driver without round-tripping through JSON. Properties are named by neo4j
+// tags, falling back to the same rules as encoding/json, so any type that could
+// previously be bound via its json tags behaves identically.
+//
+// Plans for each type are computed once and cached. Types the codec doesn't
+// natively understand, such as those implementing json.Marshaler, are still
+// converted using JSON.
+
+type (
+	// structField is a property of a struct, resolved following the rules of
+	// encoding/json.
+	structField struct {
+		name      string
+		index     []int
+		typ       reflect.Type
+		tagged    bool
+		omitEmpty bool
+		// quoted is true if the field uses the ",string" option, in which case
+		// its value is encoded as a JSON string.
+		quoted bool
+		// strategy determines how the field is stored. See
+		// [internal.PropertyStrategy].
+		strategy internal.PropertyStrategy
+		depth    int
+	}
+
+	// structPlan describes how the fields of a struct map to properties.
+	structPlan struct {
+		fields []structField
+		byName map[string]int
+		byFold map[string]int
+		// metadata are the fields receiving element metadata.
+		metadata []metadataField
+		// flattened are the indices of fields stored using
+		// [internal.StoreFlattened].
+		flattened []int
+	}
+
+	// customCodec converts values of a type registered using [WithCodec].
+	customCodec struct {
+		encode encoderFunc
+		decode decoderFunc
+	}
+
+	// metadataField is a field receiving the metadata of a node or
+	// relationship.
+	metadataField struct {
+		metadata internal.ElementMetadata
+		index    []int
+		typ      reflect.Type
+	}
+
+	decoderFunc func(r *registry, from any, v reflect.Value) error
+	encoderFunc func(r *registry, v reflect.Value) (any, error)
+)
+
+var (
+	structPlans sync.Map // map[reflect.Type]*structPlan
+	decoders    sync.Map // map[reflect.Type]decoderFunc
+	encoders    sync.Map // map[reflect.Type]encoderFunc
+
+	rJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	rTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	rJSONMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rTextMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	rError           = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+var (
+	// ErrUnknownProperty is reported when decoding strictly if a property has
+	// no corresponding field.
+	ErrUnknownProperty = errors.New("unknown property")
+	// ErrMissingProperty is reported when decoding strictly if a required
+	// property is missing or null.
+	ErrMissingProperty = errors.New("missing required property")
+	// ErrLossyConversion is reported if a number cannot be represented by the
+	// type it's decoded into, such as a negative number decoded into a uint or
+	// a fractional number decoded into an int. When decoding strictly, it's
+	// also reported if an integer overflows the type it's decoded into.
+	ErrLossyConversion = errors.New("lossy numeric conversion")
+	// ErrTypeMismatch is reported when decoding strictly if a value cannot be
+	// decoded into a field without coercion.
+	ErrTypeMismatch = errors.New("type mismatch")
+)
+
+// DecodeError is returned when a value cannot be decoded, locating the
+// property that failed by its path, such as person.address.city or
+// people[2].name.
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cannot decode %s: %v", e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// prefixDecodeError prefixes the path of err with prefix, which is either a
+// property name or an index.
+func prefixDecodeError(prefix string, err error) error {
+	de, ok := err.(*DecodeError)
+	if !ok {
+		return &DecodeError{Path: prefix, Err: err}
+	}
+	if strings.HasPrefix(de.Path, "[") {
+		return &DecodeError{Path: prefix + de.Path, Err: de.Err}
+	}
+	return &DecodeError{Path: prefix + "." + de.Path, Err: de.Err}
+}
+
+func planFor(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := structPlans.LoadOrStore(t, newStructPlan(t))
+	return p.(*structPlan)
+}
+
+func newStructPlan(t reflect.Type) *structPlan {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var (
+		candidates []structField
+		metadata   []metadataField
+		visited    = map[reflect.Type]bool{}
+		next       = []embedded{{typ: t}}
+	)
+	for depth := 0; len(next) > 0; depth++ {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag, _ := internal.ExtractPropertyTag(sf)
+				if tag.Skip {
+					continue
+				}
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				if tag.Metadata != "" {
+					metadata = append(metadata, metadataField{
+						metadata: tag.Metadata,
+						index:    index,
+						typ:      sf.Type,
+					})
+					continue
+				}
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if tag.Inline || (tag.Name == "" && sf.Anonymous && ft.Kind() == reflect.Struct) {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+				field := structField{
+					name:      tag.Name,
+					index:     index,
+					typ:       sf.Type,
+					tagged:    tag.Name != "",
+					omitEmpty: tag.OmitEmpty,
+					strategy:  tag.Strategy,
+					depth:     depth,
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+				if tag.Quoted {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						field.quoted = true
+					}
+				}
+				candidates = append(candidates, field)
+			}
+		}
+	}
+
+	// As with encoding/json, the shallowest field with a given name wins. Ties
+	// are broken by tagged fields, and otherwise the name is dropped.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.depth != b.depth {
+			return a.depth < b.depth
+		}
+		return a.tagged && !b.tagged
+	})
+	fields := make([]structField, 0, len(candidates))
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+		dominant := candidates[i]
+		if j-i == 1 || candidates[i+1].depth > dominant.depth ||
+			(dominant.tagged && !candidates[i+1].tagged) {
+			fields = append(fields, dominant)
+		}
+		i = j
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	p := &structPlan{
+		fields:   fields,
+		byName:   make(map[string]int, len(fields)),
+		byFold:   make(map[string]int, len(fields)),
+		metadata: metadata,
+	}
+	for i, f := range fields {
+		if f.strategy == internal.StoreFlattened {
+			p.flattened = append(p.flattened, i)
+		}
+		p.byName[f.name] = i
+		if _, ok := p.byFold[strings.ToLower(f.name)]; !ok {
+			p.byFold[strings.ToLower(f.name)] = i
+		}
+	}
+	return p
+}
+
+// required reports whether the property must be present when decoding
+// strictly. Properties are optional if they're omitempty, or their field can
+// hold null.
+func (f *structField) required() bool {
+	if f.omitEmpty || f.strategy == internal.StoreNode {
+		return false
+	}
+	switch f.typ.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return false
+	}
+	return true
+}
+
+// lookup returns the index of the field for the property name, preferring an
+// exact match over a case-insensitive one.
+func (p *structPlan) lookup(name string) (int, bool) {
+	if i, ok := p.byName[name]; ok {
+		return i, true
+	}
+	i, ok := p.byFold[strings.ToLower(name)]
+	return i, ok
+}
+
+// lookupFlattened returns the index of the flattened field the property name
+// belongs to, and the name of the property within it.
+func (p *structPlan) lookupFlattened(name string) (int, string, bool) {
+	for _, i := range p.flattened {
+		if rest, ok := strings.CutPrefix(name, p.fields[i].name+"_"); ok && rest != "" {
+			return i, rest, true
+		}
+	}
+	return 0, "", false
+}
+
+// fieldForDecode returns the field at index, allocating nil embedded pointers.
+func fieldForDecode(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf(
+						"cannot set embedded pointer to unexported struct: %v", v.Type().Elem(),
+					)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// fieldForEncode returns the field at index, or false if it's embedded within a
+// nil pointer.
+func fieldForEncode(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// decode binds from to the value pointed to by to, or to itself if it's
+// settable.
+func (r *registry) decode(from any, to reflect.Value) error {
+	if to.Kind() == reflect.Ptr && !to.IsNil() {
+		to = to.Elem()
+	} else if !to.CanSet() {
+		return fmt.Errorf("cannot decode into unsettable value of type %s", to.Type())
+	}
+	return decoderFor(to.Type())(r, from, to)
+}
+
+func decoderFor(t reflect.Type) decoderFunc {
+	if d, ok := decoders.Load(t); ok {
+		return d.(decoderFunc)
+	}
+	// Recursive types refer to themselves whilst their decoder is being built,
+	// so we store an indirect decoder that waits for it to be completed.
+	var (
+		wg  sync.WaitGroup
+		dec decoderFunc
+	)
+	wg.Add(1)
+	d, loaded := decoders.LoadOrStore(t, decoderFunc(func(r *registry, from any, v reflect.Value) error {
+		wg.Wait()
+		return dec(r, from, v)
+	}))
+	if loaded {
+		return d.(decoderFunc)
+	}
+	dec = newDecoder(t)
+	wg.Done()
+	decoders.Store(t, dec)
+	return dec
+}
+
+func newDecoder(t reflect.Type) decoderFunc {
+	dec := newKindDecoder(t)
+	if temporalDec := newTemporalDecoder(t, dec); temporalDec != nil {
+		dec = temporalDec
+	}
+	if reflect.PointerTo(t).Implements(rPathBinder) {
+		kindDec := dec
+		dec = func(r *registry, from any, v reflect.Value) error {
+			if path, ok := from.(neo4j.Path); ok {
+				return v.Addr().Interface().(pathBinder).bindPath(r, path)
+			}
+			return kindDec(r, from, v)
+		}
+	}
+	if isValuer(t) {
+		kindDec := dec
+		dec = func(r *registry, from any, v reflect.Value) error {
+			if ok, err := bindRecordValuer(from, v.Addr()); ok || err != nil {
+				return err
+			}
+			return kindDec(r, from, v)
+		}
+	}
+	return func(r *registry, from any, v reflect.Value) error {
+		if from == nil {
+			switch v.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+				v.Set(reflect.Zero(t))
+			}
+			return nil
+		}
+		if c, ok := r.codecs[t]; ok {
+			return c.decode(r, from, v)
+		}
+		return dec(r, from, v)
+	}
+}
+
+func newKindDecoder(t reflect.Type) decoderFunc {
+	assignable := func(dec decoderFunc) decoderFunc {
+		return func(r *registry, from any, v reflect.Value) error {
+			if fv := reflect.ValueOf(from); fv.Type().AssignableTo(t) {
+				v.Set(fv)
+				return nil
+			}
+			return dec(r, from, v)
+		}
+	}
+	if t.Kind() != reflect.Interface && t.Kind() != reflect.Ptr {
+		pt := reflect.PointerTo(t)
+		if pt.Implements(rJSONUnmarshaler) || pt.Implements(rTextUnmarshaler) {
+			return assignable(decodeJSON)
+		}
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return assignable(func(r *registry, from any, v reflect.Value) error {
+			switch from.(type) {
+			case neo4j.Node, neo4j.Relationship:
+				return r.bindValue(from, v.Addr())
+			}
+			if !v.IsNil() && v.Elem().Kind() == reflect.Ptr && !v.Elem().IsNil() {
+				return r.decode(from, v.Elem())
+			}
+			return fmt.Errorf("cannot decode %T into %s", from, t)
+		})
+	case reflect.Ptr:
+		elemDec := decoderFor(t.Elem())
+		return func(r *registry, from any, v reflect.Value) error {
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			return elemDec(r, from, v.Elem())
+		}
+	case reflect.Struct:
+		return assignable(newStructDecoder(t))
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return assignable(decodeJSON)
+		}
+		elemDec := decoderFor(t.Elem())
+		return assignable(func(r *registry, from any, v reflect.Value) error {
+			props, ok := asProps(from)
+			if !ok {
+				return decodeFallback(r, from, v)
+			}
+			if v.IsNil() {
+				v.Set(reflect.MakeMapWithSize(t, len(props)))
+			}
+			for k, val := range props {
+				elem := reflect.New(t.Elem()).Elem()
+				if err := elemDec(r, val, elem); err != nil {
+					return prefixDecodeError(k, err)
+				}
+				v.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+			}
+			return nil
+		})
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return assignable(decodeJSON)
+		}
+		elemDec := decoderFor(t.Elem())
+		return assignable(func(r *registry, from any, v reflect.Value) error {
+			fv := reflect.ValueOf(from)
+			if fv.Kind() != reflect.Slice && fv.Kind() != reflect.Array {
+				return decodeFallback(r, from, v)
+			}
+			n := fv.Len()
+			out := v
+			if t.Kind() == reflect.Slice {
+				out = reflect.MakeSlice(t, n, n)
+			} else {
+				out.Set(reflect.Zero(t))
+				n = min(n, t.Len())
+			}
+			for i := 0; i < n; i++ {
+				if err := elemDec(r, fv.Index(i).Interface(), out.Index(i)); err != nil {
+					return prefixDecodeError(fmt.Sprintf("[%d]", i), err)
+				}
+			}
+			if t.Kind() == reflect.Slice {
+				v.Set(out)
+			}
+			return nil
+		})
+	case reflect.Bool:
+		return func(r *registry, from any, v reflect.Value) error {
+			if fv := reflect.ValueOf(from); fv.Kind() == reflect.Bool {
+				v.SetBool(fv.Bool())
+				return nil
+			}
+			return decodeFallback(r, from, v)
+		}
+	case reflect.String:
+		return func(r *registry, from any, v reflect.Value) error {
+			if fv := reflect.ValueOf(from); fv.Kind() == reflect.String {
+				v.SetString(fv.String())
+				return nil
+			}
+			return decodeFallback(r, from, v)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(r *registry, from any, v reflect.Value) error {
+			fv := reflect.ValueOf(from)
+			var (
+				i  int64
+				ok bool
+			)
+			switch fv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				i, ok = fv.Int(), true
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				u := fv.Uint()
+				i, ok = int64(u), u <= math.MaxInt64
+			case reflect.Float32, reflect.Float64:
+				// Floats are only decoded if they're whole numbers in range, as
+				// fractions would otherwise be silently dropped.
+				i, ok = floatToInt(fv.Float())
+				if !ok || v.OverflowInt(i) {
+					return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+				}
+			default:
+				return decodeFallback(r, from, v)
+			}
+			// Unless decoding strictly, integers wrap around as they do in Go.
+			if r.strict && (!ok || v.OverflowInt(i)) {
+				return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+			}
+			v.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(r *registry, from any, v reflect.Value) error {
+			fv := reflect.ValueOf(from)
+			var (
+				u  uint64
+				ok bool
+			)
+			switch fv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				i := fv.Int()
+				if i < 0 {
+					return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+				}
+				u, ok = uint64(i), true
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				u, ok = fv.Uint(), true
+			case reflect.Float32, reflect.Float64:
+				var i int64
+				i, ok = floatToInt(fv.Float())
+				if !ok || i < 0 || v.OverflowUint(uint64(i)) {
+					return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+				}
+				u = uint64(i)
+			default:
+				return decodeFallback(r, from, v)
+			}
+			// Negative numbers and floats are never converted lossily into
+			// unsigned integers, but otherwise numbers are converted as they are
+			// for signed integers.
+			if r.strict && (!ok || v.OverflowUint(u)) {
+				return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+			}
+			v.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(r *registry, from any, v reflect.Value) error {
+			fv := reflect.ValueOf(from)
+			var f float64
+			switch fv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f = float64(fv.Int())
+				if r.strict && (f >= math.MaxInt64 || int64(f) != fv.Int()) {
+					return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+				}
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				f = float64(fv.Uint())
+				if r.strict && (f >= math.MaxUint64 || uint64(f) != fv.Uint()) {
+					return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+				}
+			case reflect.Float32, reflect.Float64:
+				f = fv.Float()
+			default:
+				return decodeFallback(r, from, v)
+			}
+			if v.OverflowFloat(f) {
+				return fmt.Errorf("cannot decode %v into %s: %w", from, t, ErrLossyConversion)
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	}
+	return assignable(decodeJSON)
+}
+
+func newStructDecoder(t reflect.Type) decoderFunc {
+	plan := planFor(t)
+	fieldDecs := make([]decoderFunc, len(plan.fields))
+	for i, f := range plan.fields {
+		if f.quoted || f.strategy == internal.StoreJSON {
+			fieldDecs[i] = decodeQuoted
+		} else {
+			fieldDecs[i] = decoderFor(f.typ)
+		}
+	}
+	for _, m := range plan.metadata {
+		if err := checkMetadataField(m); err != nil {
+			return func(*registry, any, reflect.Value) error { return err }
+		}
+	}
+	return func(r *registry, from any, v reflect.Value) error {
+		props, ok := asProps(from)
+		if !ok {
+			return decodeFallback(r, from, v)
+		}
+		if err := decodeMetadata(plan, from, v); err != nil {
+			return err
+		}
+		var decoded []bool
+		if r.strict {
+			decoded = make([]bool, len(plan.fields))
+		}
+		var flattened map[int]map[string]any
+		for k, val := range props {
+			i, ok := plan.lookup(k)
+			if !ok {
+				if j, name, ok := plan.lookupFlattened(k); ok {
+					if flattened == nil {
+						flattened = map[int]map[string]any{}
+					}
+					if flattened[j] == nil {
+						flattened[j] = map[string]any{}
+					}
+					flattened[j][name] = val
+					continue
+				}
+				if r.strict {
+					return &DecodeError{Path: k, Err: ErrUnknownProperty}
+				}
+				continue
+			}
+			if r.strict {
+				if val == nil && plan.fields[i].required() {
+					return &DecodeError{Path: k, Err: ErrMissingProperty}
+				}
+				decoded[i] = true
+			}
+			fv, err := fieldForDecode(v, plan.fields[i].index)
+			if err != nil {
+				return err
+			}
+			if err := fieldDecs[i](r, val, fv); err != nil {
+				return prefixDecodeError(k, err)
+			}
+		}
+		for _, i := range plan.flattened {
+			f := plan.fields[i]
+			nested, ok := flattened[i]
+			if !ok {
+				// Required properties of the field are missing.
+				if !r.strict || decoded[i] || !f.required() {
+					continue
+				}
+				nested = map[string]any{}
+			}
+			fv, err := fieldForDecode(v, f.index)
+			if err != nil {
+				return err
+			}
+			if err := fieldDecs[i](r, nested, fv); err != nil {
+				return prefixDecodeError(f.name, err)
+			}
+			if r.strict {
+				decoded[i] = true
+			}
+		}
+		for i, f := range plan.fields {
+			if r.strict && !decoded[i] && f.required() {
+				return &DecodeError{Path: f.name, Err: ErrMissingProperty}
+			}
+		}
+		return nil
+	}
+}
+
+func checkMetadataField(m metadataField) error {
+	if m.metadata == internal.MetadataLabels {
+		if m.typ.Kind() == reflect.Slice && m.typ.Elem().Kind() == reflect.String {
+			return nil
+		}
+		return fmt.Errorf("field with %q option must be a []string, not %s", m.metadata, m.typ)
+	}
+	if m.typ.Kind() != reflect.String {
+		return fmt.Errorf("field with %q option must be a string, not %s", m.metadata, m.typ)
+	}
+	return nil
+}
+
+// decodeMetadata sets the metadata fields of v from a node or relationship.
+// Metadata that doesn't apply to from is left untouched.
+func decodeMetadata(plan *structPlan, from any, v reflect.Value) error {
+	for _, m := range plan.metadata {
+		var value any
+		switch from := from.(type) {
+		case neo4j.Node:
+			switch m.metadata {
+			case internal.MetadataElementID:
+				value = from.ElementId
+			case internal.MetadataLabels:
+				value = append([]string(nil), from.Labels...)
+			}
+		case neo4j.Relationship:
+			switch m.metadata {
+			case internal.MetadataElementID:
+				value = from.ElementId
+			case internal.MetadataStartElementID:
+				value = from.StartElementId
+			case internal.MetadataEndElementID:
+				value = from.EndElementId
+			case internal.MetadataType:
+				value = from.Type
+			}
+		}
+		if value == nil {
+			continue
+		}
+		fv, err := fieldForDecode(v, m.index)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(value).Convert(m.typ))
+	}
+	return nil
+}
+
+// bindMetadata sets the metadata fields of the struct to points to from a node
+// or relationship.
+func bindMetadata(from any, to reflect.Value) error {
+	for to.Kind() == reflect.Ptr || to.Kind() == reflect.Interface {
+		if to.IsNil() {
+			return nil
+		}
+		to = to.Elem()
+	}
+	if to.Kind() != reflect.Struct || !to.CanSet() {
+		return nil
+	}
+	plan := planFor(to.Type())
+	for _, m := range plan.metadata {
+		if err := checkMetadataField(m); err != nil {
+			return err
+		}
+	}
+	return decodeMetadata(plan, from, to)
+}
+
+// encodeMetadata returns the element IDs held by the metadata fields of v.
+func encodeMetadata(v any) map[internal.ElementMetadata]string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var out map[internal.ElementMetadata]string
+	for _, m := range planFor(rv.Type()).metadata {
+		fv, ok := fieldForEncode(rv, m.index)
+		if !ok || fv.Kind() != reflect.String {
+			continue
+		}
+		if out == nil {
+			out = map[internal.ElementMetadata]string{}
+		}
+		out[m.metadata] = fv.String()
+	}
+	return out
+}
+
+// decodeFallback binds from to v using JSON, for values the codec doesn't
+// natively convert to the type of v. When decoding strictly, it instead
+// reports a type mismatch.
+func decodeFallback(r *registry, from any, v reflect.Value) error {
+	if r.strict {
+		return fmt.Errorf("cannot decode %T into %s: %w", from, v.Type(), ErrTypeMismatch)
+	}
+	return decodeJSON(r, from, v)
+}
+
+// decodeJSON binds from to v by round-tripping through JSON. It's used for
+// types the codec doesn't natively understand.
+func decodeJSON(_ *registry, from any, v reflect.Value) error {
+	bytes, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v.Addr().Interface())
+}
+
+func asProps(from any) (map[string]any, bool) {
+	switch from := from.(type) {
+	case map[string]any:
+		return from, true
+	case neo4j.Node:
+		return from.Props, true
+	case neo4j.Relationship:
+		return from.Props, true
+	}
+	return nil, false
+}
+
+// floatToInt converts f to an integer, reporting false if it has a fractional
+// part or is out of range.
+func floatToInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// isValuer reports whether values of type t may implement [Valuer].
+func isValuer(t reflect.Type) bool {
+	m, ok := reflect.PointerTo(t).MethodByName("Unmarshal")
+	return ok && m.Type.NumIn() == 2 && m.Type.NumOut() == 1 && m.Type.Out(0) == rError
+}
+
+// encode converts v to a value that can be sent to the neo4j driver. Structs
+// and maps are converted to map[string]any, slices and arrays to []any, and
+// named primitives to their underlying types. Temporal and spatial values are
+// kept as types the driver understands natively.
+func (r *registry) encode(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return encoderFor(reflect.TypeOf(v))(r, reflect.ValueOf(v))
+}
+
+func encoderFor(t reflect.Type) encoderFunc {
+	if e, ok := encoders.Load(t); ok {
+		return e.(encoderFunc)
+	}
+	var (
+		wg  sync.WaitGroup
+		enc encoderFunc
+	)
+	wg.Add(1)
+	e, loaded := encoders.LoadOrStore(t, encoderFunc(func(r *registry, v reflect.Value) (any, error) {
+		wg.Wait()
+		return enc(r, v)
+	}))
+	if loaded {
+		return e.(encoderFunc)
+	}
+	enc = withCustomEncoder(t, newEncoder(t))
+	wg.Done()
+	encoders.Store(t, enc)
+	return enc
+}
+
+// withCustomEncoder wraps enc to use the codec registered for t, if any.
+func withCustomEncoder(t reflect.Type, enc encoderFunc) encoderFunc {
+	return func(r *registry, v reflect.Value) (any, error) {
+		if c, ok := r.codecs[t]; ok {
+			return c.encode(r, v)
+		}
+		return enc(r, v)
+	}
+}
+
+func newEncoder(t reflect.Type) encoderFunc {
+	if err := checkEncodable(t); err != nil {
+		return func(*registry, reflect.Value) (any, error) { return nil, err }
+	}
+	if m, ok := t.MethodByName("Marshal"); ok &&
+		m.Type.NumIn() == 1 && m.Type.NumOut() == 2 &&
+		m.Type.Out(0).Kind() == reflect.Ptr && m.Type.Out(1) == rError {
+		return func(r *registry, v reflect.Value) (any, error) {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				return nil, nil
+			}
+			out := v.Method(m.Index).Call(nil)
+			if err, _ := out[1].Interface().(error); err != nil {
+				return nil, err
+			}
+			if out[0].IsNil() {
+				return nil, nil
+			}
+			return r.encode(out[0].Elem().Interface())
+		}
+	}
+	if enc := newTemporalEncoder(t); enc != nil {
+		return enc
+	}
+	if t.Kind() != reflect.Ptr && (t.Implements(rJSONMarshaler) || t.Implements(rTextMarshaler)) {
+		return encodeJSON
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		elemEnc := encoderFor(t.Elem())
+		return func(r *registry, v reflect.Value) (any, error) {
+			if v.IsNil() {
+				return nil, nil
+			}
+			return elemEnc(r, v.Elem())
+		}
+	case reflect.Interface:
+		return func(r *registry, v reflect.Value) (any, error) {
+			if v.IsNil() {
+				return nil, nil
+			}
+			return encoderFor(v.Elem().Type())(r, v.Elem())
+		}
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return encodeJSON
+		}
+		elemEnc := encoderFor(t.Elem())
+		return func(r *registry, v reflect.Value) (any, error) {
+			if v.IsNil() {
+				return nil, nil
+			}
+			out := make(map[string]any, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				k := iter.Key().String()
+				e, err := elemEnc(r, iter.Value())
+				if err != nil {
+					return nil, fmt.Errorf("cannot encode key %q: %w", k, err)
+				}
+				out[k] = e
+			}
+			return out, nil
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return func(r *registry, v reflect.Value) (any, error) {
+				if v.IsNil() {
+					return nil, nil
+				}
+				return v.Bytes(), nil
+			}
+		}
+		elemEnc := encoderFor(t.Elem())
+		return func(r *registry, v reflect.Value) (any, error) {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return nil, nil
+			}
+			out := make([]any, v.Len())
+			for i := range out {
+				e, err := elemEnc(r, v.Index(i))
+				if err != nil {
+					return nil, fmt.Errorf("cannot encode element %d: %w", i, err)
+				}
+				out[i] = e
+			}
+			return out, nil
+		}
+	case reflect.Bool:
+		return func(r *registry, v reflect.Value) (any, error) { return v.Bool(), nil }
+	case reflect.String:
+		return func(r *registry, v reflect.Value) (any, error) { return v.String(), nil }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(r *registry, v reflect.Value) (any, error) { return v.Int(), nil }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(r *registry, v reflect.Value) (any, error) {
+			u := v.Uint()
+			if u > math.MaxInt64 {
+				return nil, fmt.Errorf("cannot encode %d: value overflows int64", u)
+			}
+			return int64(u), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(r *registry, v reflect.Value) (any, error) { return v.Float(), nil }
+	}
+	return encodeJSON
+}
+
+// checkEncodable returns an error if values of type t, or the elements of
+// containers of type t, can never be encoded.
+func checkEncodable(t reflect.Type) error {
+	for {
+		switch t.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+			return fmt.Errorf("unsupported type: %s", t)
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return nil
+		}
+	}
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	plan := planFor(t)
+	fieldEncs := make([]encoderFunc, len(plan.fields))
+	for i, f := range plan.fields {
+		if f.quoted || f.strategy == internal.StoreJSON {
+			fieldEncs[i] = encodeQuoted
+		} else {
+			fieldEncs[i] = encoderFor(f.typ)
+		}
+	}
+	return func(r *registry, v reflect.Value) (any, error) {
+		out := make(map[string]any, len(plan.fields))
+		for i, f := range plan.fields {
+			if f.strategy == internal.StoreNode {
+				// Related nodes aren't properties of their parent.
+				continue
+			}
+			fv, ok := fieldForEncode(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			e, err := fieldEncs[i](r, fv)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode field %q: %w", f.name, err)
+			}
+			if f.strategy == internal.StoreFlattened {
+				nested, _ := e.(map[string]any)
+				for k, e := range nested {
+					out[f.name+"_"+k] = e
+				}
+				continue
+			}
+			out[f.name] = e
+		}
+		return out, nil
+	}
+}
+
+// encodeJSON converts v by round-tripping through JSON. It's used for types
+// the codec doesn't natively understand.
+func encodeJSON(_ *registry, v reflect.Value) (any, error) {
+	bytes, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(bytes, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeQuoted decodes a field using the ",string" option from the JSON
+// encoding of its value.
+func decodeQuoted(_ *registry, from any, v reflect.Value) error {
+	if from == nil {
+		return nil
+	}
+	s, ok := from.(string)
+	if !ok {
+		return fmt.Errorf("cannot decode %T into quoted %s", from, v.Type())
+	}
+	return json.Unmarshal([]byte(s), v.Addr().Interface())
+}
+
+// encodeQuoted encodes a field using the ",string" option as the JSON encoding
+// of its value.
+func encodeQuoted(_ *registry, v reflect.Value) (any, error) {
+	bytes, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,841 @@
+package neogo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+type (
+	codecAddress struct {
+		City     string `json:"city"`
+		Postcode string `json:"postcode,omitempty"`
+	}
+	// CodecMeta is exported as, like encoding/json, pointers to unexported
+	// embedded structs cannot be allocated when decoding.
+	CodecMeta struct {
+		Source  string `json:"source"`
+		Ignored string `json:"-"`
+	}
+	codecPerson struct {
+		Node `neo4j:"Person"`
+		*CodecMeta
+
+		Name       string                `json:"name"`
+		Nickname   *string               `json:"nickname,omitempty"`
+		Age        uint8                 `json:"age"`
+		Score      float32               `json:"score"`
+		Tags       []string              `json:"tags"`
+		Address    codecAddress          `json:"address"`
+		Previous   []*codecAddress       `json:"previous"`
+		Extra      map[string]any        `json:"extra"`
+		Counts     map[string]int        `json:"counts"`
+		Born       time.Time             `json:"born"`
+		Status     codecStatus           `json:"status"`
+		Valuer     *simpleValuer[string] `json:"valuer"`
+		NoTag      bool
+		unexported string
+	}
+	codecStatus  string
+	codecAccount struct {
+		Node `neo4j:"Account"`
+
+		Email    string        `json:"email" neo4j:"emailAddress"`
+		Password string        `json:"-" neo4j:"password"`
+		Session  string        `json:"session" neo4j:"-"`
+		Address  *codecAddress `json:"address" neo4j:",inline"`
+		Logins   int           `json:"logins,string"`
+	}
+	codecTree struct {
+		Name     string       `json:"name"`
+		Children []*codecTree `json:"children"`
+	}
+)
+
+func TestCodec(t *testing.T) {
+	r := &registry{}
+
+	t.Run("plans follow json field rules", func(t *testing.T) {
+		plan := planFor(reflect.TypeOf(codecPerson{}))
+		var names []string
+		for _, f := range plan.fields {
+			names = append(names, f.name)
+		}
+		assert.Equal(t, []string{
+			"id", "source", "name", "nickname", "age", "score", "tags", "address",
+			"previous", "extra", "counts", "born", "status", "valuer", "NoTag",
+		}, names)
+	})
+
+	t.Run("decodes node props into structs", func(t *testing.T) {
+		var p codecPerson
+		born := time.Date(1990, 1, 2, 3, 4, 5, 0, time.UTC)
+		err := r.bindValue(neo4j.Node{
+			Labels: []string{"Person"},
+			Props: map[string]any{
+				"id":       "p1",
+				"source":   "import",
+				"name":     "Jessie",
+				"nickname": "Jess",
+				"age":      int64(24),
+				"score":    9.5,
+				"tags":     []any{"a", "b"},
+				"address":  map[string]any{"city": "Albuquerque"},
+				"previous": []any{map[string]any{"city": "Phoenix"}, nil},
+				"extra":    map[string]any{"n": int64(1)},
+				"counts":   map[string]any{"x": int64(2)},
+				"born":     born,
+				"status":   "active",
+				"valuer":   "custom",
+				"notag":    true,
+				"unknown":  "ignored",
+			},
+		}, reflect.ValueOf(&p))
+		require.NoError(t, err)
+
+		nickname := "Jess"
+		assert.Equal(t, codecPerson{
+			Node:      Node{ID: "p1"},
+			CodecMeta: &CodecMeta{Source: "import"},
+			Name:      "Jessie",
+			Nickname:  &nickname,
+			Age:       24,
+			Score:     9.5,
+			Tags:      []string{"a", "b"},
+			Address:   codecAddress{City: "Albuquerque"},
+			Previous:  []*codecAddress{{City: "Phoenix"}, nil},
+			Extra:     map[string]any{"n": int64(1)},
+			Counts:    map[string]int{"x": 2},
+			Born:      born,
+			Status:    "active",
+			Valuer:    &simpleValuer[string]{Value: "custom"},
+			NoTag:     true,
+		}, p)
+	})
+
+	t.Run("decodes nulls", func(t *testing.T) {
+		nickname := "Jess"
+		p := codecPerson{Name: "Jessie", Nickname: &nickname, Tags: []string{"a"}}
+		err := r.bindValue(map[string]any{
+			"name":     nil,
+			"nickname": nil,
+			"tags":     nil,
+		}, reflect.ValueOf(&p))
+		require.NoError(t, err)
+		assert.Equal(t, "Jessie", p.Name)
+		assert.Nil(t, p.Nickname)
+		assert.Nil(t, p.Tags)
+	})
+
+	t.Run("decodes recursive types", func(t *testing.T) {
+		var tree codecTree
+		err := r.bindValue(map[string]any{
+			"name": "root",
+			"children": []any{
+				map[string]any{"name": "leaf"},
+			},
+		}, reflect.ValueOf(&tree))
+		require.NoError(t, err)
+		assert.Equal(t, codecTree{
+			Name:     "root",
+			Children: []*codecTree{{Name: "leaf"}},
+		}, tree)
+	})
+
+	t.Run("decodes abstract nodes within structs", func(t *testing.T) {
+		r := &registry{}
+		require.NoError(t, r.registerTypes(&tests.Human{}, &tests.Dog{}))
+		var out struct {
+			Organism tests.Organism `json:"organism"`
+		}
+		err := r.bindValue(map[string]any{
+			"organism": neo4j.Node{
+				Labels: []string{"Human", "Organism"},
+				Props:  map[string]any{"alive": true, "name": "Raqeeb"},
+			},
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		assert.Equal(t, &tests.Human{
+			BaseOrganism: tests.BaseOrganism{Alive: true},
+			Name:         "Raqeeb",
+		}, out.Organism)
+	})
+
+	t.Run("converts numbers", func(t *testing.T) {
+		var out struct {
+			I8  int8    `json:"i8"`
+			U16 uint16  `json:"u16"`
+			F32 float32 `json:"f32"`
+			I   int     `json:"i"`
+		}
+		err := r.bindValue(map[string]any{
+			"i8":  int64(-5),
+			"u16": 2.0,
+			"f32": int64(3),
+			"i":   1e3,
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		assert.Equal(t, int8(-5), out.I8)
+		assert.Equal(t, uint16(2), out.U16)
+		assert.Equal(t, float32(3), out.F32)
+		assert.Equal(t, 1000, out.I)
+	})
+
+	t.Run("converts lossy numbers", func(t *testing.T) {
+		var out struct {
+			I8  int8   `json:"i8"`
+			U8  uint8  `json:"u8"`
+			I   int    `json:"i"`
+			U16 uint16 `json:"u16"`
+		}
+		err := r.bindValue(map[string]any{
+			"i8":  int64(200),
+			"u8":  int64(256),
+			"i":   -2.0,
+			"u16": 2.0,
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		assert.Equal(t, int8(-56), out.I8)
+		assert.Equal(t, uint8(0), out.U8)
+		assert.Equal(t, -2, out.I)
+		assert.Equal(t, uint16(2), out.U16)
+
+		for name, props := range map[string]map[string]any{
+			"negative":         {"age": int64(-1)},
+			"nan":              {"age": math.NaN()},
+			"fractional":       {"age": 1.5},
+			"negative float":   {"age": -1.0},
+			"overflowed float": {"age": 256.0},
+		} {
+			t.Run(name, func(t *testing.T) {
+				var p codecPerson
+				assert.ErrorIs(t, r.bindValue(props, reflect.ValueOf(&p)), ErrLossyConversion)
+			})
+		}
+
+		var i struct {
+			I int `json:"i"`
+		}
+		err = r.bindValue(map[string]any{"i": -1.5}, reflect.ValueOf(&i))
+		assert.ErrorIs(t, err, ErrLossyConversion)
+		assert.ErrorContains(t, err, "cannot decode -1.5 into int")
+	})
+
+	t.Run("errors on lossy numbers when strict", func(t *testing.T) {
+		strict := &registry{strict: true}
+		for name, props := range map[string]map[string]any{
+			"overflow":   {"age": int64(256)},
+			"negative":   {"age": int64(-1)},
+			"fractional": {"age": 1.5},
+			"nan":        {"age": math.NaN()},
+		} {
+			t.Run(name, func(t *testing.T) {
+				var p codecPerson
+				err := strict.bindValue(props, reflect.ValueOf(&p))
+				var decodeErr *DecodeError
+				require.ErrorAs(t, err, &decodeErr)
+				assert.Equal(t, "age", decodeErr.Path)
+				assert.ErrorIs(t, err, ErrLossyConversion)
+			})
+		}
+
+		var whole struct {
+			U uint8 `json:"u"`
+		}
+		require.NoError(t, strict.bindValue(map[string]any{"u": 30.0}, reflect.ValueOf(&whole)))
+		assert.Equal(t, uint8(30), whole.U)
+	})
+
+	t.Run("errors on mismatched types", func(t *testing.T) {
+		var p codecPerson
+		err := r.bindValue(map[string]any{"name": int64(1)}, reflect.ValueOf(&p))
+		assert.Error(t, err)
+		err = r.bindValue(map[string]any{"age": "1"}, reflect.ValueOf(&p))
+		assert.Error(t, err)
+	})
+
+	t.Run("encodes structs as property maps", func(t *testing.T) {
+		p := codecPerson{
+			Node:      Node{ID: "p1"},
+			CodecMeta: &CodecMeta{Source: "import", Ignored: "x"},
+			Name:      "Jessie",
+			Age:       24,
+			Score:     9.5,
+			Address:   codecAddress{City: "Albuquerque"},
+			Previous:  []*codecAddress{{City: "Phoenix", Postcode: "85001"}},
+			Counts:    map[string]int{"x": 2},
+			Status:    "active",
+			Valuer:    &simpleValuer[string]{Value: "custom"},
+		}
+		encoded, err := r.encode(&p)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"id":       "p1",
+			"source":   "import",
+			"name":     "Jessie",
+			"age":      int64(24),
+			"score":    float64(9.5),
+			"tags":     nil,
+			"address":  map[string]any{"city": "Albuquerque"},
+			"previous": []any{map[string]any{"city": "Phoenix", "postcode": "85001"}},
+			"extra":    nil,
+			"counts":   map[string]any{"x": int64(2)},
+			"born":     time.Time{},
+			"status":   "active",
+			"valuer":   "custom",
+			"NoTag":    false,
+		}, encoded)
+	})
+
+	t.Run("encodes parameters", func(t *testing.T) {
+		params, err := r.canonicalizeParams(map[string]any{
+			"nil":    nil,
+			"int":    3,
+			"uint":   uint32(4),
+			"status": codecStatus("active"),
+			"ptr":    &codecAddress{City: "Albuquerque"},
+			"slice":  []codecStatus{"a"},
+			"bytes":  []byte("hi"),
+			"map":    map[codecStatus][]int{"a": {1}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"nil":    nil,
+			"int":    int64(3),
+			"uint":   int64(4),
+			"status": "active",
+			"ptr":    map[string]any{"city": "Albuquerque"},
+			"slice":  []any{"a"},
+			"bytes":  []byte("hi"),
+			"map":    map[string]any{"a": []any{int64(1)}},
+		}, params)
+
+		_, err = r.canonicalizeParams(map[string]any{"big": uint64(math.MaxUint64)})
+		assert.ErrorContains(t, err, "overflows int64")
+		_, err = r.canonicalizeParams(map[string]any{"fn": func() {}})
+		assert.ErrorContains(t, err, "unsupported type")
+	})
+
+	t.Run("names properties with neo4j tags", func(t *testing.T) {
+		a := codecAccount{
+			Node:     Node{ID: "a1"},
+			Email:    "jessie@example.com",
+			Password: "hunter2",
+			Session:  "s",
+			Address:  &codecAddress{City: "Albuquerque"},
+			Logins:   3,
+		}
+		props := map[string]any{
+			"id":           "a1",
+			"emailAddress": "jessie@example.com",
+			"password":     "hunter2",
+			"city":         "Albuquerque",
+			"logins":       "3",
+		}
+		encoded, err := r.encode(a)
+		require.NoError(t, err)
+		assert.Equal(t, props, encoded)
+
+		var decoded codecAccount
+		require.NoError(t, r.bindValue(neo4j.Node{
+			Labels: []string{"Account"},
+			Props:  props,
+		}, reflect.ValueOf(&decoded)))
+		a.Session = ""
+		assert.Equal(t, a, decoded)
+
+		// API responses are still shaped by json tags.
+		bytes, err := json.Marshal(a)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"id": "a1",
+			"email": "jessie@example.com",
+			"session": "",
+			"address": {"city": "Albuquerque"},
+			"logins": "3"
+		}`, string(bytes))
+	})
+
+	t.Run("matches the json round trip", func(t *testing.T) {
+		p := codecPerson{
+			Node:     Node{ID: "p1"},
+			Name:     "Jessie",
+			Age:      24,
+			Tags:     []string{"a"},
+			Previous: []*codecAddress{{City: "Phoenix"}},
+		}
+		encoded, err := r.encode(p)
+		require.NoError(t, err)
+		var fromCodec, fromJSON codecPerson
+		require.NoError(t, r.bindValue(encoded, reflect.ValueOf(&fromCodec)))
+		bytes, err := json.Marshal(p)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(bytes, &fromJSON))
+		assert.Equal(t, fromJSON, fromCodec)
+	})
+}
+
+type benchmarkPerson struct {
+	internal.Node `neo4j:"Person"`
+
+	Name    string   `json:"name"`
+	Surname string   `json:"surname"`
+	Age     int      `json:"age"`
+	Email   string   `json:"email"`
+	Active  bool     `json:"active"`
+	Score   float64  `json:"score"`
+	Tags    []string `json:"tags"`
+}
+
+const benchmarkRows = 100_000
+
+func benchmarkRecords() []*neo4j.Record {
+	records := make([]*neo4j.Record, benchmarkRows)
+	for i := range records {
+		records[i] = &neo4j.Record{
+			Keys: []string{"p"},
+			Values: []any{neo4j.Node{
+				Labels: []string{"Person"},
+				Props: map[string]any{
+					"id":      strconv.Itoa(i),
+					"name":    "Jessie",
+					"surname": "Pinkman",
+					"age":     int64(i % 100),
+					"email":   fmt.Sprintf("jessie%d@example.com", i),
+					"active":  i%2 == 0,
+					"score":   float64(i) / 3,
+					"tags":    []any{"a", "b", "c"},
+				},
+			}},
+		}
+	}
+	return records
+}
+
+func BenchmarkUnmarshalRecords(b *testing.B) {
+	records := benchmarkRecords()
+	s := &session{}
+
+	b.Run("codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var people []benchmarkPerson
+			cy := &internal.CompiledCypher{
+				Bindings: map[string]reflect.Value{"p": reflect.ValueOf(&people)},
+			}
+			if err := s.unmarshalRecords(cy, records); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// json is the baseline: the JSON round trip bindValue previously used.
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			people := make([]benchmarkPerson, len(records))
+			for j, record := range records {
+				bytes, err := json.Marshal(record.Values[0].(neo4j.Node).Props)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := json.Unmarshal(bytes, &people[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkCanonicalizeParams(b *testing.B) {
+	people := make([]benchmarkPerson, benchmarkRows)
+	for i := range people {
+		people[i] = benchmarkPerson{
+			Node:    internal.Node{ID: strconv.Itoa(i)},
+			Name:    "Jessie",
+			Surname: "Pinkman",
+			Age:     i % 100,
+			Email:   fmt.Sprintf("jessie%d@example.com", i),
+			Active:  i%2 == 0,
+			Score:   float64(i) / 3,
+			Tags:    []string{"a", "b", "c"},
+		}
+	}
+	params := map[string]any{"people": people}
+	r := &registry{}
+
+	b.Run("codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.canonicalizeParams(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// json is the baseline: the JSON round trip canonicalizeParams previously
+	// used.
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bytes, err := json.Marshal(people)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var out []any
+			if err := json.Unmarshal(bytes, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type (
+	metadataPerson struct {
+		Node `neo4j:"Person"`
+		NodeMetadata
+
+		Name string `json:"name"`
+	}
+	metadataKnows struct {
+		Relationship `neo4j:"KNOWS"`
+		RelationshipMetadata
+
+		Since int `json:"since"`
+	}
+	metadataTagged struct {
+		Node `neo4j:"Tagged"`
+
+		Element string `json:"element" neo4j:",elementId"`
+	}
+)
+
+func TestElementMetadata(t *testing.T) {
+	r := &registry{}
+	node := neo4j.Node{
+		ElementId: "4:db:1",
+		Labels:    []string{"Person", "Admin"},
+		Props:     map[string]any{"id": "p1", "name": "Jessie"},
+	}
+	rel := neo4j.Relationship{
+		ElementId:      "5:db:1",
+		StartElementId: "4:db:1",
+		EndElementId:   "4:db:2",
+		Type:           "KNOWS",
+		Props:          map[string]any{"since": int64(2008)},
+	}
+	person := metadataPerson{
+		Node:         Node{ID: "p1"},
+		NodeMetadata: NodeMetadata{ElementID: "4:db:1", Labels: []string{"Person", "Admin"}},
+		Name:         "Jessie",
+	}
+	knows := metadataKnows{
+		RelationshipMetadata: RelationshipMetadata{
+			ElementID:      "5:db:1",
+			StartElementID: "4:db:1",
+			EndElementID:   "4:db:2",
+			Type:           "KNOWS",
+		},
+		Since: 2008,
+	}
+
+	t.Run("binds node metadata", func(t *testing.T) {
+		var p metadataPerson
+		require.NoError(t, r.bindValue(node, reflect.ValueOf(&p)))
+		assert.Equal(t, person, p)
+
+		var tagged *metadataTagged
+		require.NoError(t, r.bindValue(node, reflect.ValueOf(&tagged)))
+		assert.Equal(t, &metadataTagged{Node: Node{ID: "p1"}, Element: "4:db:1"}, tagged)
+	})
+
+	t.Run("binds relationship metadata", func(t *testing.T) {
+		var k metadataKnows
+		require.NoError(t, r.bindValue(rel, reflect.ValueOf(&k)))
+		assert.Equal(t, knows, k)
+	})
+
+	t.Run("binds metadata within structs and paths", func(t *testing.T) {
+		var out struct {
+			People []metadataPerson `json:"people"`
+			Knows  *metadataKnows   `json:"knows"`
+		}
+		require.NoError(t, r.bindValue(map[string]any{
+			"people": []any{node},
+			"knows":  rel,
+		}, reflect.ValueOf(&out)))
+		assert.Equal(t, []metadataPerson{person}, out.People)
+		assert.Equal(t, &knows, out.Knows)
+
+		var paths []Path[metadataPerson, metadataKnows]
+		require.NoError(t, r.bindValue([]any{neo4j.Path{
+			Nodes: []neo4j.Node{node, {
+				ElementId: "4:db:2",
+				Labels:    []string{"Person"},
+			}},
+			Relationships: []neo4j.Relationship{rel},
+		}}, reflect.ValueOf(&paths)))
+		require.Len(t, paths, 1)
+		assert.Equal(t, "4:db:2", paths[0].End().ElementID)
+		assert.Equal(t, "4:db:2", paths[0].Relationships[0].EndElementID)
+	})
+
+	t.Run("does not store metadata as properties", func(t *testing.T) {
+		encoded, err := r.encode(person)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"id": "p1", "name": "Jessie"}, encoded)
+
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		err = m.Exec().
+			Merge(db.Node(db.Qual(&person, "p"))).
+			Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"MERGE (p:Person {id: $p_id, name: $p_name})"}, rec.cyphers)
+	})
+
+	t.Run("errors on fields of the wrong type", func(t *testing.T) {
+		var out struct {
+			Node `neo4j:"Person"`
+
+			Labels string `neo4j:",labels"`
+		}
+		err := r.bindValue(node, reflect.ValueOf(&out))
+		assert.ErrorContains(t, err, `field with "labels" option must be a []string`)
+	})
+
+	t.Run("round trips through the mock", func(t *testing.T) {
+		m := NewMock()
+		m.Bind(map[string]any{"p": person, "k": knows})
+		var (
+			p metadataPerson
+			k metadataKnows
+		)
+		err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p")).To(db.Qual(&k, "k"), nil)).
+			Return(&p, &k).
+			Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "4:db:1", p.ElementID)
+		assert.Equal(t, []string{"Person"}, p.Labels)
+		assert.Equal(t, knows, k)
+	})
+}
+
+type (
+	codecUUID   [4]byte
+	codecDevice struct {
+		Node  `neo4j:"Device"`
+		Addr  netip.Addr            `json:"addr"`
+		Peers []netip.Addr          `json:"peers"`
+		Named map[string]netip.Addr `json:"named"`
+		Owner *codecUUID            `json:"owner"`
+	}
+)
+
+func TestWithCodec(t *testing.T) {
+	addrCodec := WithCodec(
+		func(a netip.Addr) (string, error) { return a.String(), nil },
+		netip.ParseAddr,
+	)
+	uuidCodec := WithCodec(
+		func(id codecUUID) ([]byte, error) { return id[:], nil },
+		func(b []byte) (id codecUUID, err error) {
+			if len(b) != len(id) {
+				return id, fmt.Errorf("invalid uuid length %d", len(b))
+			}
+			copy(id[:], b)
+			return id, nil
+		},
+	)
+	cfg := &Config{}
+	addrCodec(cfg)
+	uuidCodec(cfg)
+	r := &registry{}
+	r.configure(cfg)
+
+	addr := netip.MustParseAddr("10.0.0.1")
+	peer := netip.MustParseAddr("::1")
+	owner := codecUUID{1, 2, 3, 4}
+	device := codecDevice{
+		Node:  Node{ID: "d"},
+		Addr:  addr,
+		Peers: []netip.Addr{peer},
+		Named: map[string]netip.Addr{"gateway": addr},
+		Owner: &owner,
+	}
+	props := map[string]any{
+		"id":    "d",
+		"addr":  "10.0.0.1",
+		"peers": []any{"::1"},
+		"named": map[string]any{"gateway": "10.0.0.1"},
+		"owner": []byte{1, 2, 3, 4},
+	}
+
+	t.Run("encodes parameters", func(t *testing.T) {
+		params, err := r.canonicalizeParams(map[string]any{
+			"addr":   addr,
+			"owner":  &owner,
+			"device": device,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1", params["addr"])
+		assert.Equal(t, []byte{1, 2, 3, 4}, params["owner"])
+		assert.Equal(t, props, params["device"])
+	})
+
+	t.Run("decodes results", func(t *testing.T) {
+		var (
+			a   netip.Addr
+			id  *codecUUID
+			out codecDevice
+		)
+		require.NoError(t, r.bindValue("10.0.0.1", reflect.ValueOf(&a)))
+		require.NoError(t, r.bindValue([]byte{1, 2, 3, 4}, reflect.ValueOf(&id)))
+		require.NoError(t, r.bindValue(neo4j.Node{Props: props}, reflect.ValueOf(&out)))
+		assert.Equal(t, addr, a)
+		assert.Equal(t, &owner, id)
+		assert.Equal(t, device, out)
+	})
+
+	t.Run("decodes into the driver type first", func(t *testing.T) {
+		var id codecUUID
+		err := r.bindValue([]any{int64(1), int64(2), int64(3), int64(4)}, reflect.ValueOf(&id))
+		require.NoError(t, err)
+		assert.Equal(t, owner, id)
+	})
+
+	t.Run("propagates errors", func(t *testing.T) {
+		var out codecDevice
+		err := r.bindValue(map[string]any{"peers": []any{"not an ip"}}, reflect.ValueOf(&out))
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, "peers[0]", decodeErr.Path)
+		assert.ErrorContains(t, err, `ParseAddr("not an ip")`)
+	})
+
+	t.Run("is ignored by registries without it", func(t *testing.T) {
+		params, err := (&registry{}).canonicalizeParams(map[string]any{"owner": owner})
+		require.NoError(t, err)
+		assert.Equal(t, []any{int64(1), int64(2), int64(3), int64(4)}, params["owner"])
+	})
+
+	t.Run("used by the driver", func(t *testing.T) {
+		m, rec := newRecordingMock(addrCodec, uuidCodec)
+		m.Bind(map[string]any{"d": device})
+		var out codecDevice
+		err := m.Exec().
+			Unwind(db.NamedParam([]netip.Addr{addr}, "addrs"), "addr").
+			Match(db.Node(db.Qual(&out, "d", db.Props{"addr": "addr"}))).
+			Return(&out).
+			Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rec.params, 1)
+		assert.Equal(t, []any{"10.0.0.1"}, rec.params[0]["addrs"])
+		assert.Equal(t, device, out)
+	})
+}
+
+type (
+	strategyAddress struct {
+		City     string `json:"city"`
+		Postcode string `json:"postcode,omitempty"`
+	}
+	strategyCompany struct {
+		Node `neo4j:"Company"`
+		Name string `json:"name"`
+	}
+	strategyEmployee struct {
+		Node     `neo4j:"Employee"`
+		Address  strategyAddress   `neo4j:"address,flatten"`
+		Previous *strategyAddress  `neo4j:"previous,flatten"`
+		Labels   map[string]string `neo4j:"labels,flatten"`
+		Settings map[string]any    `neo4j:"settings,json"`
+		Employer *strategyCompany  `neo4j:"employer,node=WORKS_AT"`
+	}
+)
+
+func TestPropertyStrategies(t *testing.T) {
+	r := &registry{}
+	employee := strategyEmployee{
+		Node:     Node{ID: "e"},
+		Address:  strategyAddress{City: "Stockholm", Postcode: "111 22"},
+		Labels:   map[string]string{"team": "graph"},
+		Settings: map[string]any{"theme": "dark"},
+		Employer: &strategyCompany{Name: "Neo4j"},
+	}
+	props := map[string]any{
+		"id":               "e",
+		"address_city":     "Stockholm",
+		"address_postcode": "111 22",
+		"labels_team":      "graph",
+		"settings":         `{"theme":"dark"}`,
+	}
+
+	t.Run("encodes nested properties", func(t *testing.T) {
+		encoded, err := r.encode(employee)
+		require.NoError(t, err)
+		assert.Equal(t, props, encoded)
+	})
+
+	t.Run("decodes nested properties", func(t *testing.T) {
+		var out strategyEmployee
+		require.NoError(t, r.bindValue(neo4j.Node{Props: props}, reflect.ValueOf(&out)))
+		expected := employee
+		expected.Employer = nil
+		assert.Equal(t, expected, out)
+
+		err := r.bindValue(map[string]any{
+			"previous_city": "Malmö",
+			"employer":      neo4j.Node{Props: map[string]any{"name": "Neo4j"}},
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		assert.Equal(t, &strategyAddress{City: "Malmö"}, out.Previous)
+		assert.Equal(t, employee.Employer, out.Employer)
+	})
+
+	t.Run("decodes nested properties strictly", func(t *testing.T) {
+		r := &registry{strict: true}
+		var out strategyEmployee
+		require.NoError(t, r.bindValue(neo4j.Node{Props: props}, reflect.ValueOf(&out)))
+
+		err := r.bindValue(map[string]any{"id": "e"}, reflect.ValueOf(&out))
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, "address.city", decodeErr.Path)
+		assert.ErrorIs(t, err, ErrMissingProperty)
+	})
+
+	t.Run("creates related nodes", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		err := m.Exec().
+			Create(db.Node(db.Qual(&employee, "e"))).
+			Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rec.params, 1)
+		assert.Equal(t, map[string]any{
+			"__isWrite":          true,
+			"e_id":               "e",
+			"e_address_city":     "Stockholm",
+			"e_address_postcode": "111 22",
+			"e_labels_team":      "graph",
+			"e_settings":         `{"theme":"dark"}`,
+			"e_employer_name":    "Neo4j",
+		}, rec.params[0])
+	})
+}
@@ -2,11 +2,14 @@ package neogo
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/notifications"
+
+	"github.com/rlch/neogo/query"
 )
 
 // defaultConfig returns default configuration values from the neo4j driver.
@@ -33,7 +36,28 @@ type Config struct {
 	config.Config
 
 	CausalConsistencyKey func(context.Context) string
+	BookmarkStore        BookmarkStore
 	Types                []any
+
+	// SessionAcquisitionTimeout is the maximum duration to wait for a session
+	// when MaxConnectionPoolSize sessions are already open. Zero waits until
+	// the context is done.
+	SessionAcquisitionTimeout time.Duration
+
+	// TimeMapping determines the Cypher type that time.Time parameters are
+	// sent as. Defaults to [TimeAsDateTime].
+	TimeMapping TimeMapping
+
+	// StrictDecoding reports unknown properties, missing required properties,
+	// integer overflows and type mismatches when binding results of all
+	// queries. See [StrictDecoding] to enable it for a single query.
+	StrictDecoding bool
+
+	// IDGenerator generates the IDs of nodes created by queries without them,
+	// if set. See [WithIDGenerator].
+	IDGenerator IDGenerator
+
+	codecs map[reflect.Type]customCodec
 }
 
 // Configurer is a function that configures a neogo Config.
@@ -45,18 +69,116 @@ type execConfig struct {
 	*neo4j.TransactionConfig
 }
 
-// causalConsistencyCache stores bookmarks for causal consistency by key.
-var causalConsistencyCache map[string]neo4j.Bookmarks = map[string]neo4j.Bookmarks{}
-
 // WithCausalConsistency configures causal consistency for the driver.
+//
+// Sessions sharing the key returned by when are causally chained: each
+// transaction observes the writes of those that completed before it. The
+// bookmarks used to chain them are kept in the driver's [BookmarkStore]. An
+// empty key disables causal chaining for the session.
 func WithCausalConsistency(when func(ctx context.Context) string) Configurer {
 	return func(c *Config) {
 		c.CausalConsistencyKey = when
 	}
 }
 
+// WithSessionAcquisitionTimeout sets [Config.SessionAcquisitionTimeout].
+func WithSessionAcquisitionTimeout(timeout time.Duration) Configurer {
+	return func(c *Config) {
+		c.SessionAcquisitionTimeout = timeout
+	}
+}
+
+// WithTimeMapping sets [Config.TimeMapping].
+func WithTimeMapping(mapping TimeMapping) Configurer {
+	return func(c *Config) {
+		c.TimeMapping = mapping
+	}
+}
+
+// WithStrictDecoding enables [Config.StrictDecoding].
+func WithStrictDecoding() Configurer {
+	return func(c *Config) {
+		c.StrictDecoding = true
+	}
+}
+
+// WithIDGenerator sets [Config.IDGenerator]. Nodes embedding [Node] or
+// [KeyedNode] which are created by CREATE clauses without IDs are given IDs
+// generated by g when the query is built, so they're available to the caller
+// before it's run:
+//
+//	d, err := neogo.New(uri, auth, neogo.WithIDGenerator(neogo.NewUUIDv7Generator()))
+//	...
+//	p := Person{Name: "Jessie"}
+//	err = d.Exec().Create(db.Node(&p)).Run(ctx) // p.ID is a UUID
+//
+// Nodes which are merged aren't given IDs, as they would never match.
+func WithIDGenerator(g IDGenerator) Configurer {
+	return func(c *Config) {
+		c.IDGenerator = g
+	}
+}
+
+// WithCodec registers functions converting values of type T, which neogo would
+// otherwise encode by reflection, to and from the driver type V. This allows
+// types that cannot implement [Valuer], such as those from other packages, to
+// be used in parameters and results:
+//
+//	neogo.WithCodec(
+//		func(id uuid.UUID) (string, error) { return id.String(), nil },
+//		uuid.Parse,
+//	)
+//
+// The codec is used wherever T appears, including within slices, maps and
+// struct fields. Values returned by Neo4j which are not of type V are first
+// decoded into V. Registering a codec for T again replaces the previous one.
+func WithCodec[T any, V neo4j.RecordValue](
+	encode func(T) (V, error),
+	decode func(V) (T, error),
+) Configurer {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	c := customCodec{
+		encode: func(r *registry, v reflect.Value) (any, error) {
+			to, err := encode(v.Interface().(T))
+			if err != nil {
+				return nil, err
+			}
+			return r.encode(to)
+		},
+		decode: func(r *registry, from any, v reflect.Value) error {
+			to, ok := from.(V)
+			if !ok {
+				if err := r.decode(from, reflect.ValueOf(&to)); err != nil {
+					return err
+				}
+			}
+			value, err := decode(to)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(&value).Elem())
+			return nil
+		},
+	}
+	return func(cfg *Config) {
+		if cfg.codecs == nil {
+			cfg.codecs = make(map[reflect.Type]customCodec)
+		}
+		cfg.codecs[t] = c
+	}
+}
+
 // WithTypes is an option for [New] that allows you to register instances of
 // [IAbstract], [INode] and [IRelationship] to be used with [neogo].
+//
+// Nodes bound to [INode], or any other interface that isn't [IAbstract], are
+// bound to the registered node implementing it with the most labels, all of
+// which the node has. This allows heterogeneous results, such as those of
+// MATCH (n) RETURN n, to be bound to []neogo.INode.
+//
+// Nodes are indexed by their labels when registered, and [New] returns an
+// error if two registered nodes, including the implementers of abstract
+// nodes, have the same labels.
 func WithTypes(types ...any) Configurer {
 	return func(c *Config) {
 		c.Types = append(c.Types, types...)
@@ -80,3 +202,83 @@ func WithSessionConfig(configurers ...func(*neo4j.SessionConfig)) func(ec *execC
 		}
 	}
 }
+
+// Timeout is a run option that sets the timeout of the transaction used to run
+// a query. Within an existing transaction, the timeout applies to the query
+// alone and is enforced by the client.
+func Timeout(timeout time.Duration) query.RunOption {
+	return func(rc *query.RunConfig) {
+		rc.Timeout = timeout
+	}
+}
+
+// Metadata is a run option that attaches metadata to the transaction used to
+// run a query. Metadata from multiple options are merged. It cannot be used
+// within an existing transaction.
+func Metadata(metadata map[string]any) query.RunOption {
+	return func(rc *query.RunConfig) {
+		if rc.Metadata == nil {
+			rc.Metadata = make(map[string]any, len(metadata))
+		}
+		for k, v := range metadata {
+			rc.Metadata[k] = v
+		}
+	}
+}
+
+// FetchSize is a run option that sets the number of records fetched per batch.
+// It cannot be used within an existing transaction.
+func FetchSize(n int) query.RunOption {
+	return func(rc *query.RunConfig) {
+		rc.FetchSize = n
+	}
+}
+
+// Database is a run option that sets the database a query is run against. It
+// cannot be used within an existing transaction.
+func Database(name string) query.RunOption {
+	return func(rc *query.RunConfig) {
+		rc.Database = name
+	}
+}
+
+// ImpersonatedUser is a run option that runs a query on behalf of user. It
+// cannot be used within an existing transaction.
+func ImpersonatedUser(user string) query.RunOption {
+	return func(rc *query.RunConfig) {
+		rc.ImpersonatedUser = user
+	}
+}
+
+// StrictDecoding is a run option that decodes the results of a query strictly.
+// Binding fails with a [*DecodeError] if:
+//
+//   - a property has no corresponding field,
+//   - a required property is missing or null. Properties are required unless
+//     they're omitempty or their field is a pointer, interface, map or slice,
+//   - a number cannot be represented exactly by its field, or
+//   - a value would otherwise be coerced into its field, such as a string
+//     into an int.
+func StrictDecoding() query.RunOption {
+	return func(rc *query.RunConfig) {
+		rc.StrictDecoding = true
+	}
+}
+
+// Redact is a dump option that replaces the values of the parameters and
+// properties with the given names with a placeholder. A name also matches the
+// parameters generated for properties of that name, such as person_password
+// for password.
+func Redact(names ...string) query.DumpOption {
+	return func(dc *query.DumpConfig) {
+		dc.Redact = append(dc.Redact, names...)
+	}
+}
+
+// ParamsMap is a dump option that writes the parameters of a query as a single
+// :params map, rather than a :param statement for each.
+func ParamsMap() query.DumpOption {
+	return func(dc *query.DumpConfig) {
+		dc.ParamsMap = true
+	}
+}
@@ -0,0 +1,98 @@
+// Package cypherfmt formats Cypher queries.
+//
+// Keywords are uppercased and literals such as true are lowercased, each
+// clause starts on its own line, and the bodies of subqueries and FOREACH
+// are indented. Clauses which are too wide have their comma-separated items
+// written on their own lines, and patterns which are still too wide are
+// broken before each relationship:
+//
+//	MATCH (p:Person {name: $name})-[:ACTED_IN]->(m:Movie)
+//	WHERE m.released > 2000
+//	CALL {
+//	  WITH m
+//	  MATCH (m)<-[:DIRECTED]-(d:Person)
+//	  RETURN collect(d.name) AS directors
+//	}
+//	RETURN m.title, directors
+//
+// Comments are kept: those on their own line stay above the clause they
+// precede, and others are written inline as block comments.
+//
+// Source formats the contents of .cypher files, which may hold several
+// statements separated by semicolons. Queries built with neogo can be
+// formatted with Runner.Format.
+package cypherfmt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMaxWidth is the width of lines used when Options.MaxWidth is 0.
+const DefaultMaxWidth = 80
+
+// Options configures the layout of formatted Cypher.
+type Options struct {
+	// Indent is the indentation of each level of nesting. It defaults to two
+	// spaces.
+	Indent string
+	// MaxWidth is the width of lines beyond which clauses are broken, in
+	// runes. It defaults to DefaultMaxWidth, and lines are never broken if it
+	// is negative.
+	MaxWidth int
+}
+
+// Format writes src formatted to w.
+func Format(w io.Writer, src string, opts Options) error {
+	out, err := format(src, opts)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// Source formats src, such as the contents of a .cypher file. Statements
+// are separated by blank lines.
+func Source(src []byte, opts Options) ([]byte, error) {
+	out, err := format(string(src), opts)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func format(src string, opts Options) (string, error) {
+	stmts, eof, err := parse(src)
+	if err != nil {
+		return "", fmt.Errorf("cannot format Cypher: %w", err)
+	}
+	p := &printer{indent: opts.Indent, width: opts.MaxWidth}
+	if p.indent == "" {
+		p.indent = "  "
+	}
+	if p.width == 0 {
+		p.width = DefaultMaxWidth
+	}
+
+	var blocks []string
+	for _, stmt := range stmts {
+		if lines := p.statement(stmt, 0); len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+	// The comments at the end of src follow the last statement, unless it
+	// ended at the end of src and has written them.
+	var tail []string
+	for _, c := range eof.comments {
+		tail = append(tail, c.text)
+	}
+	if len(tail) > 0 {
+		blocks = append(blocks, strings.Join(tail, "\n"))
+	}
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
@@ -0,0 +1,203 @@
+package cypherfmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		src  string
+		want string
+	}{
+		{
+			name: "normalizes keywords and spacing",
+			src: `match (p:Person{name:$name})  where p.age>=18 and not p.name starts with 'J'
+			return p.name as name,null,TRUE,-1,p.age-1,[1,2][0],{a:1}
+			order by name desc skip 1 limit 10`,
+			want: `MATCH (p:Person {name: $name})
+WHERE p.age >= 18 AND NOT p.name STARTS WITH 'J'
+RETURN p.name AS name, null, true, -1, p.age - 1, [1, 2][0], {a: 1}
+ORDER BY name DESC
+SKIP 1
+LIMIT 10
+`,
+		},
+		{
+			name: "patterns",
+			src: `MATCH (a)-[r:KNOWS|LIKES*1..3]->(b)<--(c), p = (a)--(:A&!B) WHERE (a)-[:R]-(b) AND a:Person
+WITH b{.name, age: b.age} AS b, ` + "`a b`" + `.c AS c
+RETURN count(*), all(x IN nodes(p) WHERE x.ok), [x IN r | x.since]`,
+			want: `MATCH (a)-[r:KNOWS|LIKES*1..3]->(b)<--(c), p = (a)--(:A&!B)
+WHERE (a)-[:R]-(b) AND a:Person
+WITH b {.name, age: b.age} AS b, ` + "`a b`" + `.c AS c
+RETURN count(*), all(x IN nodes(p) WHERE x.ok), [x IN r | x.since]
+`,
+		},
+		{
+			name: "indents subqueries",
+			src: `MATCH (p:Person) WHERE exists { match (p)-->(m:Movie) } CALL { with p match (p)-[:ACTED_IN]->(m) where count { (m)<--() } > 1 return collect(m) as movies } in transactions of 10 rows
+RETURN p, movies`,
+			want: `MATCH (p:Person)
+WHERE EXISTS { MATCH (p)-->(m:Movie) }
+CALL {
+  WITH p
+  MATCH (p)-[:ACTED_IN]->(m)
+  WHERE COUNT { (m)<--() } > 1
+  RETURN collect(m) AS movies
+} IN TRANSACTIONS OF 10 ROWS
+RETURN p, movies
+`,
+		},
+		{
+			name: "indents nested subqueries",
+			opts: Options{Indent: "\t"},
+			src:  `CALL { CALL { RETURN 1 AS x } RETURN x } WITH x WHERE EXISTS { MATCH (n) WHERE n.x = x RETURN n } RETURN x`,
+			want: "CALL {\n\tCALL {\n\t\tRETURN 1 AS x\n\t}\n\tRETURN x\n}\nWITH x\nWHERE EXISTS {\n\tMATCH (n)\n\tWHERE n.x = x\n\tRETURN n\n}\nRETURN x\n",
+		},
+		{
+			name: "keeps variables named after keywords",
+			src: `MATCH (n) WITH n.set AS set, n.` + "`end`" + ` AS e RETURN set, e
+UNION MATCH (match) WITH match, match.create AS create, [delete IN match.deleted | delete] AS delete
+WHERE create IS NULL OR delete = [] AND match.limit > 0 RETURN create + delete AS limit ORDER BY limit LIMIT 1`,
+			want: `MATCH (n)
+WITH n.set AS set, n.` + "`end`" + ` AS e
+RETURN set, e
+UNION
+MATCH (match)
+WITH match, match.create AS create, [delete IN match.deleted | delete] AS delete
+WHERE create IS NULL OR delete = [] AND match.limit > 0
+RETURN create + delete AS limit
+ORDER BY limit
+LIMIT 1
+`,
+		},
+		{
+			name: "unions",
+			src:  `MATCH (n:A) RETURN n.name AS name UNION ALL MATCH (n:B) RETURN n.name AS name union return null as name`,
+			want: `MATCH (n:A)
+RETURN n.name AS name
+UNION ALL
+MATCH (n:B)
+RETURN n.name AS name
+UNION
+RETURN null AS name
+`,
+		},
+		{
+			name: "indents FOREACH",
+			src: `MATCH p = (a)-->(b) FOREACH (n IN nodes(p) | SET n.marked = true
+			FOREACH (m IN n.tags | CREATE (:Tag {name: m})))`,
+			want: `MATCH p = (a)-->(b)
+FOREACH (n IN nodes(p) |
+  SET n.marked = true
+  FOREACH (m IN n.tags |
+    CREATE (:Tag {name: m})
+  )
+)
+`,
+		},
+		{
+			name: "MERGE actions",
+			src:  `MERGE (n:Person {id: $id}) ON CREATE SET n.created = timestamp() ON MATCH SET n += $props RETURN n`,
+			want: `MERGE (n:Person {id: $id})
+  ON CREATE SET n.created = timestamp()
+  ON MATCH SET n += $props
+RETURN n
+`,
+		},
+		{
+			name: "breaks long clauses",
+			opts: Options{MaxWidth: 40},
+			src: `MATCH (person:Person)-[:ACTED_IN]->(movie:Movie)<-[:DIRECTED]-(director:Person)
+			RETURN person.name, movie.title, director.name
+			CREATE (a:Node), (b:Node)-[:LINKS_TO {since: 2020}]->(c:Node {name: 'c'})`,
+			want: `MATCH (person:Person)
+  -[:ACTED_IN]->(movie:Movie)
+  <-[:DIRECTED]-(director:Person)
+RETURN
+  person.name,
+  movie.title,
+  director.name
+CREATE
+  (a:Node),
+  (b:Node)
+    -[:LINKS_TO {since: 2020}]->(c:Node {name: 'c'})
+`,
+		},
+		{
+			name: "keeps comments",
+			src: `// Find people.
+MATCH (n:Person) // by label
+/* only adults */ WHERE n.age > 18 AND /* named */ n.name IS NOT NULL
+RETURN n // done`,
+			want: `// Find people.
+MATCH (n:Person) // by label
+/* only adults */
+WHERE n.age > 18 AND /* named */ n.name IS NOT NULL
+RETURN n // done
+`,
+		},
+		{
+			name: "keeps literals",
+			src:  `RETURN 'it''s' AS a, "say \"match\"" AS b, 1.5e3, 0x1F, .5, $` + "`my param`",
+			want: `RETURN 'it''s' AS a, "say \"match\"" AS b, 1.5e3, 0x1F, .5, $` + "`my param`" + "\n",
+		},
+		{
+			name: "empty",
+			src:  " \n",
+			want: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			require.NoError(t, Format(&b, tc.src, tc.opts))
+			assert.Equal(t, tc.want, b.String())
+
+			b.Reset()
+			require.NoError(t, Format(&b, tc.want, tc.opts))
+			assert.Equal(t, tc.want, b.String(), "formatting should be idempotent")
+		})
+	}
+
+	for name, src := range map[string]string{
+		"unclosed":             "MATCH (n RETURN n",
+		"unexpected":           "MATCH (n)) RETURN n",
+		"mismatched":           "RETURN [1, 2)",
+		"unterminated string":  "RETURN 'abc",
+		"unterminated comment": "RETURN 1 /* abc",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorContains(t, Format(&strings.Builder{}, src, Options{}), "cannot format Cypher: 1:")
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	src := `// Schema.
+create index person_name if not exists for (p:Person) on (p.name);;
+match (n) detach delete n ; // Reset.
+
+UNWIND $rows AS row CREATE (p:Person) SET p = row;
+// End.
+`
+	out, err := Source([]byte(src), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, `// Schema.
+CREATE INDEX person_name IF NOT EXISTS FOR (p:Person) ON (p.name);
+
+MATCH (n)
+DETACH DELETE n; // Reset.
+
+UNWIND $rows AS row
+CREATE (p:Person)
+SET p = row;
+
+// End.
+`, string(out))
+}
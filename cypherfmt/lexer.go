@@ -0,0 +1,258 @@
+package cypherfmt
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// tokenWord is an identifier or keyword.
+	tokenWord
+	// tokenQuoted is an identifier escaped with backticks, which is never a
+	// keyword.
+	tokenQuoted
+	tokenNumber
+	tokenString
+	tokenParam
+	tokenSymbol
+)
+
+type (
+	token struct {
+		kind tokenKind
+		// text is the token as written.
+		text string
+		// line and col are the position of the token, from 1.
+		line, col int
+		// space is true if the token is preceded by whitespace or a comment.
+		space bool
+		// comments are the comments preceding the token, since the previous one.
+		comments []comment
+		// trailing is a comment following the token on the same line.
+		trailing *comment
+		// arrow is true if the token is part of a relationship arrow.
+		arrow bool
+	}
+
+	comment struct {
+		// text is the comment as written, including its delimiters.
+		text string
+		// ownLine is true if the comment starts a line.
+		ownLine bool
+	}
+)
+
+func (t *token) is(s string) bool {
+	return t != nil && (t.kind == tokenWord || t.kind == tokenSymbol) && strings.EqualFold(t.text, s)
+}
+
+// inline returns the comment as a block comment, which can precede a token
+// on the same line.
+func (c comment) inline() string {
+	if text, ok := strings.CutPrefix(c.text, "//"); ok {
+		return "/* " + strings.TrimSpace(text) + " */"
+	}
+	return c.text
+}
+
+// symbols are the multi-character symbols of Cypher, longest first. Arrows
+// aren't included, so that their parts can be told apart from operators.
+var symbols = []string{"..", "<>", "<=", ">=", "!=", "=~", "+="}
+
+// lex splits src into tokens, ending with a tokenEOF holding the comments at
+// the end of src.
+func lex(src string) ([]*token, error) {
+	var (
+		tokens   []*token
+		comments []comment
+		line     = 1
+		lineAt   = 0
+		space    bool
+		newline  = true
+	)
+	for i := 0; i <= len(src); {
+		pos := func() (int, int) { return line, utf8.RuneCountInString(src[lineAt:i]) + 1 }
+		if i == len(src) {
+			l, c := pos()
+			tokens = append(tokens, &token{kind: tokenEOF, line: l, col: c, comments: comments})
+			break
+		}
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+		switch {
+		case r == '\n':
+			i++
+			line, lineAt = line+1, i
+			space, newline = true, true
+			continue
+		case unicode.IsSpace(r):
+			i += size
+			space = true
+			continue
+		case strings.HasPrefix(src[i:], "//") || strings.HasPrefix(src[i:], "/*"):
+			var end int
+			if src[i+1] == '/' {
+				end = strings.IndexByte(src[i:], '\n')
+				if end < 0 {
+					end = len(src) - i
+				}
+				end += i
+			} else {
+				n := strings.Index(src[i+2:], "*/")
+				if n < 0 {
+					l, c := pos()
+					return nil, fmt.Errorf("%d:%d: unterminated comment", l, c)
+				}
+				end = i + 2 + n + 2
+			}
+			c := comment{text: strings.TrimRight(src[i:end], " \t\r"), ownLine: newline}
+			for _, r := range src[i:end] {
+				if r == '\n' {
+					line++
+				}
+			}
+			if n := strings.LastIndexByte(src[i:end], '\n'); n >= 0 {
+				lineAt = i + n + 1
+			}
+			i = end
+			space = true
+			if !c.ownLine && len(tokens) > 0 && tokens[len(tokens)-1].trailing == nil && len(comments) == 0 &&
+				strings.HasPrefix(c.text, "//") {
+				tokens[len(tokens)-1].trailing = &c
+				continue
+			}
+			comments = append(comments, c)
+			continue
+		}
+
+		l, c := pos()
+		t := &token{line: l, col: c, space: space, comments: comments}
+		comments, space, newline = nil, false, false
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			t.kind = tokenWord
+		case r == '`':
+			end, err := closing(src, i, '`')
+			if err != nil {
+				return nil, fmt.Errorf("%d:%d: unterminated identifier", l, c)
+			}
+			i = end
+			t.kind = tokenQuoted
+		case r == '\'' || r == '"':
+			end, err := closing(src, i, byte(r))
+			if err != nil {
+				return nil, fmt.Errorf("%d:%d: unterminated string", l, c)
+			}
+			i = end
+			t.kind = tokenString
+		case r == '$':
+			i++
+			if i < len(src) && src[i] == '`' {
+				end, err := closing(src, i, '`')
+				if err != nil {
+					return nil, fmt.Errorf("%d:%d: unterminated parameter", l, c)
+				}
+				i = end
+			} else {
+				for i < len(src) {
+					r, size := utf8.DecodeRuneInString(src[i:])
+					if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+						break
+					}
+					i += size
+				}
+			}
+			t.kind = tokenParam
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			i = number(src, i)
+			t.kind = tokenNumber
+		default:
+			t.kind = tokenSymbol
+			i += size
+			for _, s := range symbols {
+				if strings.HasPrefix(src[start:], s) {
+					i = start + len(s)
+					break
+				}
+			}
+		}
+		t.text = src[start:i]
+		for _, r := range t.text {
+			if r == '\n' {
+				line++
+			}
+		}
+		if n := strings.LastIndexByte(t.text, '\n'); n >= 0 {
+			lineAt = start + n + 1
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// closing returns the offset following the quote closing the string,
+// identifier or parameter starting at src[i].
+func closing(src string, i int, quote byte) (int, error) {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			// Quotes are escaped by doubling them.
+			if j+1 < len(src) && src[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated")
+}
+
+// number returns the offset following the number starting at src[i].
+func number(src string, i int) int {
+	if strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0o") {
+		i += 2
+		for i < len(src) && (isDigit(src[i]) || strings.IndexByte("abcdefABCDEF_", src[i]) >= 0) {
+			i++
+		}
+		return i
+	}
+	digits := func() {
+		for i < len(src) && (isDigit(src[i]) || src[i] == '_') {
+			i++
+		}
+	}
+	digits()
+	if i+1 < len(src) && src[i] == '.' && isDigit(src[i+1]) {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && isDigit(src[j]) {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
@@ -0,0 +1,446 @@
+package cypherfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// item is either a token or a bracketed group of items.
+	item struct {
+		tok   *token
+		group *group
+	}
+
+	groupKind int
+
+	group struct {
+		kind        groupKind
+		open, close *token
+		items       []item
+		// pipe and body hold the clauses of a FOREACH following the pipe, or
+		// of a subquery.
+		pipe *token
+		body *statement
+		// expr is true if the group is the subquery of an expression, such as
+		// EXISTS, which can be written on one line.
+		expr bool
+	}
+
+	clause struct {
+		// keywords are the keywords beginning the clause, such as OPTIONAL
+		// MATCH. They are empty for statements not beginning with a clause we
+		// recognise.
+		keywords []*token
+		items    []item
+	}
+
+	statement struct {
+		clauses []*clause
+		// end is the token ending the statement, holding the comments
+		// preceding it.
+		end *token
+	}
+)
+
+const (
+	// groupParen is a parenthesized expression or node pattern.
+	groupParen groupKind = iota
+	// groupCall holds the arguments of a function or procedure call.
+	groupCall
+	groupList
+	// groupRel is the detail of a relationship pattern, such as [r:KNOWS*1..2].
+	groupRel
+	groupMap
+	// groupBlock is a subquery, such as the body of CALL or EXISTS.
+	groupBlock
+	groupForeach
+)
+
+var (
+	// clauses are the keywords beginning a clause, and the number of
+	// keywords in the clause's header.
+	clauses = map[string]int{
+		"MATCH": 1, "CREATE": 1, "MERGE": 1, "SET": 1, "DELETE": 1, "REMOVE": 1,
+		"WHERE": 1, "WITH": 1, "RETURN": 1, "UNWIND": 1, "SKIP": 1, "OFFSET": 1,
+		"LIMIT": 1, "YIELD": 1, "CALL": 1, "FOREACH": 1, "USE": 1, "FINISH": 1,
+		"UNION": 1, "EXPLAIN": 1, "PROFILE": 1,
+	}
+
+	// keywords are the words uppercased outside clause headers.
+	keywords = map[string]bool{
+		"AS": true, "AND": true, "OR": true, "XOR": true, "NOT": true, "IN": true,
+		"IS": true, "DISTINCT": true, "CASE": true, "WHEN": true, "THEN": true,
+		"ELSE": true, "END": true, "STARTS": true, "ENDS": true, "WITH": true,
+		"CONTAINS": true, "ASC": true, "DESC": true, "ASCENDING": true,
+		"DESCENDING": true, "BY": true, "ALL": true, "FROM": true, "HEADERS": true,
+		"FIELDTERMINATOR": true, "ON": true, "TRANSACTIONS": true, "ROWS": true,
+		"OF": true, "ERROR": true, "CONTINUE": true, "BREAK": true, "FAIL": true,
+		"CONCURRENT": true, "INDEX": true, "CONSTRAINT": true, "FOR": true,
+		"REQUIRE": true, "UNIQUE": true, "IF": true, "DROP": true, "SHOW": true,
+	}
+
+	// terminals are the keywords which can end a clause.
+	terminals = map[string]bool{
+		"END": true, "ASC": true, "DESC": true, "ASCENDING": true, "DESCENDING": true,
+		"ROWS": true, "CONTINUE": true, "BREAK": true, "FAIL": true,
+	}
+
+	// subqueries are the keywords of expressions taking a subquery.
+	subqueries = map[string]bool{"EXISTS": true, "COUNT": true, "COLLECT": true}
+
+	// literals are the words lowercased outside clause headers.
+	literals = map[string]bool{"NULL": true, "TRUE": true, "FALSE": true}
+
+	closers = map[string]string{"(": ")", "[": "]", "{": "}"}
+)
+
+type parser struct {
+	tokens []*token
+	pos    int
+}
+
+// parse parses the statements of src, returning the token ending src, which
+// holds the comments at the end.
+func parse(src string) ([]*statement, *token, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{tokens: tokens}
+	var stmts []*statement
+	for {
+		if p.peek(0).kind == tokenEOF {
+			return stmts, p.peek(0), nil
+		}
+		stmt, err := p.statement("")
+		if err != nil {
+			return nil, nil, err
+		}
+		stmts = append(stmts, stmt)
+		if !stmt.end.is(";") {
+			return stmts, stmt.end, nil
+		}
+	}
+}
+
+func (p *parser) peek(n int) *token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() *token {
+	t := p.peek(0)
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func unexpected(t *token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%d:%d: unexpected end of input", t.line, t.col)
+	}
+	return fmt.Errorf("%d:%d: unexpected %q", t.line, t.col, t.text)
+}
+
+// statement parses clauses up to closer, or to a semicolon or the end of
+// input if closer is empty. The token ending the statement is consumed.
+func (p *parser) statement(closer string) (*statement, error) {
+	stmt := &statement{}
+	var (
+		c    *clause
+		prev *token
+	)
+	for {
+		t := p.peek(0)
+		switch {
+		case closer == "" && (t.kind == tokenEOF || t.is(";")),
+			closer != "" && t.is(closer):
+			stmt.end = p.next()
+			if c != nil {
+				c.finish()
+			}
+			return stmt, nil
+		case t.kind == tokenEOF, t.is(")"), t.is("]"), t.is("}"), t.is(";"):
+			return nil, unexpected(t)
+		}
+		if n := p.clauseStart(prev, c); n > 0 {
+			if c != nil {
+				c.finish()
+			}
+			c = &clause{}
+			for range n {
+				t := p.next()
+				t.text = strings.ToUpper(t.text)
+				c.keywords = append(c.keywords, t)
+			}
+			stmt.clauses = append(stmt.clauses, c)
+			prev = c.keywords[n-1]
+			continue
+		}
+		if c == nil {
+			c = &clause{}
+			stmt.clauses = append(stmt.clauses, c)
+		}
+		it, err := p.item(c.items, c)
+		if err != nil {
+			return nil, err
+		}
+		c.items = append(c.items, it)
+		prev = p.peek(-1)
+	}
+}
+
+// clauseStart returns the number of keywords in the header of the clause
+// starting at the next token, or 0 if it doesn't start a clause.
+func (p *parser) clauseStart(prev *token, c *clause) int {
+	t := p.peek(0)
+	if t.kind != tokenWord || !ends(prev, c) || p.peek(1).is(":") {
+		return 0
+	}
+	word := strings.ToUpper(t.text)
+	switch word {
+	case "OPTIONAL":
+		if p.peek(1).is("MATCH") || p.peek(1).is("CALL") {
+			return 2
+		}
+	case "ORDER":
+		if p.peek(1).is("BY") {
+			return 2
+		}
+	case "DETACH", "NODETACH":
+		if p.peek(1).is("DELETE") {
+			return 2
+		}
+	case "ON":
+		if p.peek(1).is("CREATE") || p.peek(1).is("MATCH") {
+			if p.peek(2).is("SET") {
+				return 3
+			}
+			return 2
+		}
+	case "LOAD":
+		if p.peek(1).is("CSV") {
+			if p.peek(2).is("WITH") && p.peek(3).is("HEADERS") {
+				return 4
+			}
+			return 2
+		}
+	case "UNION":
+		if p.peek(1).is("ALL") || p.peek(1).is("DISTINCT") {
+			return 2
+		}
+		return 1
+	case "EXPLAIN", "PROFILE":
+		if c != nil {
+			return 0
+		}
+		return 1
+	default:
+		return clauses[word]
+	}
+	return 0
+}
+
+// ends reports whether the clause c can end at prev, the token preceding the
+// next, so that the next token can start a clause. Otherwise a word such as
+// set is an expression, as in WITH n.set AS set or RETURN set.
+func ends(prev *token, c *clause) bool {
+	if prev == nil {
+		return true
+	}
+	if c != nil && len(c.items) == 0 {
+		// Of the clauses without a body, only these can be followed by another.
+		return len(c.keywords) > 0 &&
+			(c.keywords[0].is("UNION") || c.keywords[0].is("EXPLAIN") || c.keywords[0].is("PROFILE"))
+	}
+	switch prev.kind {
+	case tokenSymbol:
+		// As in RETURN *.
+		return prev.is(")") || prev.is("]") || prev.is("}") || prev.is("*") && c != nil && len(c.items) == 1
+	case tokenWord:
+		return !keywords[strings.ToUpper(prev.text)] || terminals[strings.ToUpper(prev.text)]
+	}
+	return true
+}
+
+// item parses the token or group following items in c.
+func (p *parser) item(items []item, c *clause) (item, error) {
+	t := p.next()
+	closer, ok := closers[t.text]
+	if t.kind != tokenSymbol || !ok {
+		return item{tok: t}, nil
+	}
+	g := &group{open: t}
+	var prev *token
+	if len(items) > 0 {
+		prev = items[len(items)-1].tok
+	}
+	switch t.text {
+	case "(":
+		switch {
+		case c != nil && len(items) == 0 && len(c.keywords) == 1 && c.keywords[0].is("FOREACH"):
+			g.kind = groupForeach
+		case prev != nil && prev.kind == tokenWord && !t.space &&
+			(!keywords[strings.ToUpper(prev.text)] || strings.EqualFold(prev.text, "ALL")),
+			prev != nil && prev.kind == tokenQuoted && !t.space:
+			g.kind = groupCall
+		default:
+			g.kind = groupParen
+		}
+	case "[":
+		g.kind = groupList
+	case "{":
+		g.kind = groupMap
+		g.expr = prev != nil && prev.kind == tokenWord && subqueries[strings.ToUpper(prev.text)]
+		if g.expr || p.clauseStart(nil, nil) > 0 {
+			g.kind = groupBlock
+		}
+	}
+
+	var err error
+	switch g.kind {
+	case groupBlock:
+		g.body, err = p.statement(closer)
+		if err != nil {
+			return item{}, err
+		}
+		g.close = g.body.end
+		return item{group: g}, nil
+	case groupForeach:
+		g.items, err = p.items("|")
+		if err != nil {
+			return item{}, err
+		}
+		g.pipe = p.peek(-1)
+		g.finish()
+		g.body, err = p.statement(closer)
+		if err != nil {
+			return item{}, err
+		}
+		g.close = g.body.end
+		return item{group: g}, nil
+	}
+	g.items, err = p.items(closer)
+	if err != nil {
+		return item{}, err
+	}
+	g.close = p.peek(-1)
+	g.finish()
+	return item{group: g}, nil
+}
+
+// items parses items up to and including closer.
+func (p *parser) items(closer string) ([]item, error) {
+	var items []item
+	for {
+		t := p.peek(0)
+		switch {
+		case t.is(closer):
+			p.next()
+			return markArrows(items), nil
+		case t.kind == tokenEOF, t.is(")"), t.is("]"), t.is("}"), t.is(";"):
+			return nil, unexpected(t)
+		}
+		it, err := p.item(items, nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+}
+
+// markArrows marks the tokens between node and relationship patterns as
+// arrows, and the groups between them as relationship patterns.
+func markArrows(items []item) []item {
+	for i := 0; i < len(items); i++ {
+		g := items[i].group
+		if g == nil || g.kind != groupParen && g.kind != groupRel {
+			continue
+		}
+		j, dash := i+1, false
+		for ; j < len(items); j++ {
+			t := items[j].tok
+			if t == nil || !t.is("-") && !t.is("<") && !t.is(">") {
+				break
+			}
+			// The parts of an arrow can't be separated, unlike a subtraction
+			// of a negation.
+			if j > i+1 && t.space {
+				break
+			}
+			dash = dash || t.is("-")
+		}
+		if !dash || j == len(items) || items[j].group == nil {
+			continue
+		}
+		next := items[j].group
+		switch {
+		case next.open.is("[") && next.kind == groupList:
+			next.kind = groupRel
+		case next.open.is("(") && next.kind == groupParen && (g.kind == groupRel || j-i > 2):
+		default:
+			continue
+		}
+		for k := i + 1; k < j; k++ {
+			items[k].tok.arrow = true
+		}
+		i = j - 1
+	}
+	return items
+}
+
+func (c *clause) finish() {
+	c.items = markArrows(c.items)
+	normalizeItems(c.items, groupParen)
+}
+
+func (g *group) finish() {
+	normalizeItems(g.items, g.kind)
+}
+
+// normalizeItems uppercases the keywords and lowercases the literals among
+// items.
+func normalizeItems(items []item, kind groupKind) {
+	for i, it := range items {
+		t := it.tok
+		if t == nil || t.kind != tokenWord {
+			continue
+		}
+		var prev *token
+		if i > 0 {
+			prev = items[i-1].tok
+		}
+		// Variables, property keys and labels keep their case, even if they're
+		// keywords.
+		if prev.is(".") || prev.is(":") && kind != groupMap || prev.is("AS") {
+			continue
+		}
+		var next item
+		if i+1 < len(items) {
+			next = items[i+1]
+		}
+		if kind == groupMap && next.tok.is(":") {
+			continue
+		}
+		word := strings.ToUpper(t.text)
+		switch {
+		case next.group != nil && next.group.kind == groupCall:
+		case subqueries[word]:
+			// As in EXISTS { ... } and IF NOT EXISTS.
+			if next.group != nil && next.group.kind == groupBlock || prev.is("NOT") {
+				t.text = word
+			}
+		case keywords[word]:
+			t.text = word
+		case word == "NULL" && (prev.is("IS") || prev.is("NOT") && i > 1 && items[i-2].tok.is("IS")):
+			// As in IS NULL and IS NOT NULL.
+			t.text = word
+		case literals[word]:
+			t.text = strings.ToLower(word)
+		}
+	}
+}
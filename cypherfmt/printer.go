@@ -0,0 +1,286 @@
+package cypherfmt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// operators are the symbols after which a minus or plus is a sign.
+var operators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"+": true, "-": true, "*": true, "/": true, "%": true, "^": true, "=~": true,
+	"+=": true, "|": true, "..": true, ",": true, ":": true,
+}
+
+type printer struct {
+	indent string
+	width  int
+}
+
+func (p *printer) pad(depth int) string {
+	return strings.Repeat(p.indent, depth)
+}
+
+func (p *printer) fits(s string) bool {
+	if p.width < 0 {
+		return true
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if utf8.RuneCountInString(line) > p.width {
+			return false
+		}
+	}
+	return true
+}
+
+// statement returns the lines of s, indented to depth.
+func (p *printer) statement(s *statement, depth int) []string {
+	var lines []string
+	for _, c := range s.clauses {
+		lines = append(lines, p.clause(c, depth)...)
+	}
+	if s.end.is(";") && len(lines) > 0 {
+		lines[len(lines)-1] += ";"
+		if s.end.trailing != nil {
+			lines[len(lines)-1] += " " + s.end.trailing.text
+			s.end.trailing = nil
+		}
+	}
+	for _, c := range s.end.comments {
+		lines = append(lines, p.pad(depth)+c.text)
+	}
+	s.end.comments = nil
+	return lines
+}
+
+// clause returns the lines of c, preceded by the comments above it.
+func (p *printer) clause(c *clause, depth int) []string {
+	if len(c.keywords) > 0 && c.keywords[0].is("ON") {
+		depth++
+	}
+	var lines []string
+	if first := c.first(); first != nil {
+		for _, cm := range first.comments {
+			lines = append(lines, p.pad(depth)+cm.text)
+		}
+		first.comments = nil
+	}
+	var trailing *comment
+	if last := c.last(); last != nil {
+		trailing, last.trailing = last.trailing, nil
+	}
+
+	headers := make([]string, len(c.keywords))
+	for i, kw := range c.keywords {
+		headers[i] = p.token(kw)
+	}
+	text := p.body(strings.Join(headers, " "), c.items, depth)
+	if trailing != nil {
+		text += " " + trailing.text
+	}
+	return append(lines, text)
+}
+
+// body renders a clause, breaking its comma-separated items onto their own
+// lines if they don't fit, and then any patterns that still don't fit.
+func (p *printer) body(header string, items []item, depth int) string {
+	prefix := p.pad(depth) + header
+	if len(items) == 0 {
+		return prefix
+	}
+	if header != "" {
+		prefix += " "
+	}
+	flat := prefix + p.seq(items, groupParen, depth, false)
+	if p.fits(flat) {
+		return flat
+	}
+	parts, commas := split(items)
+	if len(parts) == 1 || header == "" {
+		return prefix + p.seq(items, groupParen, depth, true)
+	}
+	var b strings.Builder
+	b.WriteString(p.pad(depth) + header)
+	for i, part := range parts {
+		b.WriteString("\n")
+		line := p.pad(depth+1) + p.seq(part, groupParen, depth+1, false)
+		if !p.fits(line) {
+			line = p.pad(depth+1) + p.seq(part, groupParen, depth+1, true)
+		}
+		b.WriteString(line)
+		if i < len(commas) {
+			b.WriteString(p.token(commas[i]))
+		}
+	}
+	return b.String()
+}
+
+// split splits items at their commas.
+func split(items []item) (parts [][]item, commas []*token) {
+	start := 0
+	for i, it := range items {
+		if it.tok.is(",") {
+			parts = append(parts, items[start:i])
+			commas = append(commas, it.tok)
+			start = i + 1
+		}
+	}
+	return append(parts, items[start:]), commas
+}
+
+// seq renders items within a group of kind. If breakArrows is set, patterns
+// are broken before each relationship.
+func (p *printer) seq(items []item, kind groupKind, depth int, breakArrows bool) string {
+	var (
+		b     strings.Builder
+		unary bool
+	)
+	for i, it := range items {
+		if i > 0 && !unary && space(items, i, kind) {
+			b.WriteByte(' ')
+		}
+		if breakArrows && i > 0 && it.tok != nil && it.tok.arrow &&
+			items[i-1].group != nil && items[i-1].group.kind == groupParen {
+			b.WriteString("\n" + p.pad(depth+1))
+		}
+		b.WriteString(p.item(it, depth))
+		unary = sign(items, i)
+	}
+	return b.String()
+}
+
+func (p *printer) item(it item, depth int) string {
+	if it.tok != nil {
+		return p.token(it.tok)
+	}
+	g := it.group
+	open := p.token(g.open)
+	switch g.kind {
+	case groupBlock:
+		if len(g.body.clauses) == 0 && len(g.body.end.comments) == 0 {
+			return open + p.token(g.close)
+		}
+		// A trailing comment would comment out the closing brace.
+		inline := g.expr && len(g.body.clauses) == 1 && len(g.body.end.comments) == 0 &&
+			g.body.clauses[0].last().trailing == nil
+		lines := p.statement(g.body, depth+1)
+		if inner := strings.TrimPrefix(lines[0], p.pad(depth+1)); inline && len(lines) == 1 && !strings.Contains(inner, "\n") {
+			return open + " " + inner + " " + p.token(g.close)
+		}
+		return open + "\n" + strings.Join(lines, "\n") + "\n" + p.pad(depth) + p.token(g.close)
+	case groupForeach:
+		lines := p.statement(g.body, depth+1)
+		return open + p.seq(g.items, g.kind, depth, false) + " " + p.token(g.pipe) + "\n" +
+			strings.Join(lines, "\n") + "\n" + p.pad(depth) + p.token(g.close)
+	}
+	return open + p.seq(g.items, g.kind, depth, false) + p.token(g.close)
+}
+
+// token renders t with the comments around it, which are made inline.
+func (p *printer) token(t *token) string {
+	var b strings.Builder
+	for _, c := range t.comments {
+		b.WriteString(c.inline() + " ")
+	}
+	b.WriteString(t.text)
+	if t.trailing != nil {
+		b.WriteString(" " + t.trailing.inline())
+	}
+	return b.String()
+}
+
+// space reports whether items[i] is separated from the item preceding it
+// within a group of kind.
+func space(items []item, i int, kind groupKind) bool {
+	prev, cur := items[i-1], items[i]
+	pt, ct := prev.tok, cur.tok
+	switch {
+	case ct.is(","), ct.is("."), ct.is(".."), ct.is(":"):
+		return false
+	case pt.is(","):
+		return true
+	case pt.is("."), pt.is(".."), pt.is("!"):
+		return false
+	case ct != nil && ct.arrow, pt != nil && pt.arrow:
+		return false
+	case pt.is(":"):
+		return kind == groupMap
+	case kind == groupRel && (ct.is("*") || pt.is("*")):
+		return false
+	// Label expressions, such as (n:A|B) and [:A|B].
+	case (kind == groupParen || kind == groupRel) && (ct.is("|") || pt.is("|") || ct.is("&") || pt.is("&")):
+		return false
+	}
+	if cur.group != nil {
+		switch cur.group.kind {
+		case groupCall:
+			return false
+		case groupList:
+			return !indexable(prev)
+		}
+	}
+	return true
+}
+
+// indexable reports whether a list following it is a subscript.
+func indexable(it item) bool {
+	if it.group != nil {
+		return it.group.kind != groupBlock && it.group.kind != groupForeach
+	}
+	switch it.tok.kind {
+	case tokenQuoted, tokenParam:
+		return true
+	case tokenWord:
+		return !keywords[it.tok.text]
+	}
+	return false
+}
+
+// sign reports whether items[i] is the sign of the item following it.
+func sign(items []item, i int) bool {
+	t := items[i].tok
+	if !t.is("-") && !t.is("+") || t.arrow {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	prev := items[i-1].tok
+	switch {
+	case prev == nil:
+		return false
+	case prev.kind == tokenSymbol:
+		return operators[prev.text] && !prev.arrow
+	case prev.kind == tokenWord:
+		return keywords[prev.text] && prev.text != "END"
+	}
+	return false
+}
+
+// first returns the first token of c.
+func (c *clause) first() *token {
+	if len(c.keywords) > 0 {
+		return c.keywords[0]
+	}
+	if len(c.items) == 0 {
+		return nil
+	}
+	if it := c.items[0]; it.tok != nil {
+		return it.tok
+	}
+	return c.items[0].group.open
+}
+
+// last returns the last token of c.
+func (c *clause) last() *token {
+	if len(c.items) == 0 {
+		if len(c.keywords) == 0 {
+			return nil
+		}
+		return c.keywords[len(c.keywords)-1]
+	}
+	if it := c.items[len(c.items)-1]; it.tok != nil {
+		return it.tok
+	}
+	return c.items[len(c.items)-1].group.close
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/auth"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
-	"golang.org/x/sync/semaphore"
 
 	"github.com/rlch/neogo/internal"
 	"github.com/rlch/neogo/query"
@@ -21,14 +20,13 @@ func New(
 	auth auth.TokenManager,
 	configurers ...Configurer,
 ) (Driver, error) {
-	cfg := &Config{
-		Config: *defaultConfig(),
-	}
-
-	for _, c := range configurers {
-		c(cfg)
+	cfg := newConfig(configurers)
+	// Types are registered before the driver is created, so that it isn't
+	// leaked if they can't be.
+	var r registry
+	if err := r.configure(cfg); err != nil {
+		return nil, err
 	}
-
 	neo4j, err := neo4j.NewDriverWithContext(
 		target,
 		auth,
@@ -37,19 +35,54 @@ func New(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Neo4J driver: %w", err)
 	}
+	return newDriver(neo4j, cfg, r), nil
+}
 
-	d := driver{
-		db:                   neo4j,
-		causalConsistencyKey: cfg.CausalConsistencyKey,
-		sessionSemaphore:     semaphore.NewWeighted(int64(cfg.Config.MaxConnectionPoolSize)),
+// NewWithDriver creates a new neogo [Driver] which runs queries with db, such
+// as an in-memory [github.com/rlch/neogo/memdb.DB].
+//
+// Configurers which modify the underlying [config.Config] only affect the
+// session pool, since db has already been created.
+func NewWithDriver(
+	db neo4j.DriverWithContext,
+	configurers ...Configurer,
+) (Driver, error) {
+	cfg := newConfig(configurers)
+	var r registry
+	if err := r.configure(cfg); err != nil {
+		return nil, err
 	}
+	return newDriver(db, cfg, r), nil
+}
+
+func newConfig(configurers []Configurer) *Config {
+	cfg := &Config{
+		Config: *defaultConfig(),
+	}
+	for _, c := range configurers {
+		c(cfg)
+	}
+	return cfg
+}
 
-	// Register types from config
-	if len(cfg.Types) > 0 {
-		d.registerTypes(cfg.Types...)
+// newDriver creates a driver running queries with db, whose types have been
+// registered with r.
+func newDriver(db neo4j.DriverWithContext, cfg *Config, r registry) Driver {
+	bookmarkStore := cfg.BookmarkStore
+	if bookmarkStore == nil {
+		bookmarkStore = NewMemoryBookmarkStore(DefaultBookmarkTTL, DefaultBookmarkStoreSize)
 	}
 
-	return &d, nil
+	d := driver{
+		db:                   db,
+		causalConsistencyKey: cfg.CausalConsistencyKey,
+		bookmarkStore:        bookmarkStore,
+		sessionPool:          newSessionPool(cfg.Config.MaxConnectionPoolSize, cfg.SessionAcquisitionTimeout),
+		idGenerator:          cfg.IDGenerator,
+		registry:             r,
+	}
+
+	return &d
 }
 
 type (
@@ -63,10 +96,24 @@ type (
 		DB() neo4j.DriverWithContext
 
 		// ReadSession creates a new read-access session based on the specified session configuration.
-		ReadSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) readSession
+		//
+		// If all sessions are in use, ReadSession waits until one is released,
+		// returning an error if ctx is done or the acquisition times out.
+		ReadSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) (readSession, error)
 
 		// WriteSession creates a new write-access session based on the specified session configuration.
-		WriteSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) writeSession
+		//
+		// If all sessions are in use, WriteSession waits until one is released,
+		// returning an error if ctx is done or the acquisition times out.
+		WriteSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) (writeSession, error)
+
+		// PoolStats returns a snapshot of the sessions acquired from the driver.
+		PoolStats() PoolStats
+
+		// NewID returns an ID generated by the [IDGenerator] the driver is
+		// configured with, or a ULID if there is none. The driver is thus an
+		// IDGenerator itself, which can be passed to [NewNode].
+		NewID() any
 
 		// Exec creates a new transaction + session and executes the given Cypher
 		// query.
@@ -129,7 +176,9 @@ type (
 		registry
 		db                   neo4j.DriverWithContext
 		causalConsistencyKey func(ctx context.Context) string
-		sessionSemaphore     *semaphore.Weighted
+		bookmarkStore        BookmarkStore
+		sessionPool          *sessionPool
+		idGenerator          IDGenerator
 	}
 	session struct {
 		*driver
@@ -147,6 +196,15 @@ type (
 
 func (d *driver) DB() neo4j.DriverWithContext { return d.db }
 
+func (d *driver) PoolStats() PoolStats { return d.sessionPool.stats() }
+
+func (d *driver) NewID() any {
+	if d.idGenerator != nil {
+		return d.idGenerator.NewID()
+	}
+	return internal.DefaultIDGenerator.NewID()
+}
+
 func (d *driver) Exec(configurers ...func(*execConfig)) Query {
 	sessionConfig := neo4j.SessionConfig{}
 	txConfig := neo4j.TransactionConfig{}
@@ -172,49 +230,44 @@ func (d *driver) Exec(configurers ...func(*execConfig)) Query {
 	return session.newClient(internal.NewCypherClient())
 }
 
+// ensureCausalConsistency configures the session to read and update the
+// bookmarks stored for the causal consistency key of ctx. A bookmark manager
+// configured explicitly takes precedence.
 func (d *driver) ensureCausalConsistency(ctx context.Context, sc *neo4j.SessionConfig) {
-	if d == nil || d.causalConsistencyKey == nil {
+	if d == nil || d.causalConsistencyKey == nil || d.bookmarkStore == nil {
 		return
 	}
-	var key string
-	if key = d.causalConsistencyKey(ctx); key == "" {
+	if sc.BookmarkManager != nil {
 		return
 	}
-	bookmarks := causalConsistencyCache[key]
-	if bookmarks == nil {
+	var key string
+	if key = d.causalConsistencyKey(ctx); key == "" {
 		return
 	}
-	sc.Bookmarks = bookmarks
+	sc.BookmarkManager = &bookmarkManager{store: d.bookmarkStore, key: key}
 }
 
-func (d *driver) ReadSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) readSession {
-	config := neo4j.SessionConfig{}
-	for _, c := range configurers {
-		c(&config)
-	}
-	config.AccessMode = neo4j.AccessModeRead
-	d.ensureCausalConsistency(ctx, &config)
-	if err := d.sessionSemaphore.Acquire(ctx, 1); err != nil {
-		panic(fmt.Errorf("failed to acquire session semaphore: %w", err))
-	}
-	sess := d.db.NewSession(ctx, config)
-	return &session{
-		driver:   d,
-		registry: d.registry,
-		db:       d.db,
-		session:  sess,
-	}
+func (d *driver) ReadSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) (readSession, error) {
+	return d.newSession(ctx, neo4j.AccessModeRead, configurers)
 }
 
-func (d *driver) WriteSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) writeSession {
+func (d *driver) WriteSession(ctx context.Context, configurers ...func(*neo4j.SessionConfig)) (writeSession, error) {
+	return d.newSession(ctx, neo4j.AccessModeWrite, configurers)
+}
+
+func (d *driver) newSession(
+	ctx context.Context,
+	accessMode neo4j.AccessMode,
+	configurers []func(*neo4j.SessionConfig),
+) (*session, error) {
 	config := neo4j.SessionConfig{}
 	for _, c := range configurers {
 		c(&config)
 	}
-	config.AccessMode = neo4j.AccessModeWrite
+	config.AccessMode = accessMode
 	d.ensureCausalConsistency(ctx, &config)
-	if err := d.sessionSemaphore.Acquire(ctx, 1); err != nil {
-		panic(fmt.Errorf("failed to acquire session semaphore: %w", err))
+	if err := d.sessionPool.acquire(ctx); err != nil {
+		return nil, err
 	}
 	sess := d.db.NewSession(ctx, config)
 	return &session{
@@ -222,7 +275,7 @@ func (d *driver) WriteSession(ctx context.Context, configurers ...func(*neo4j.Se
 		registry: d.registry,
 		db:       d.db,
 		session:  sess,
-	}
+	}, nil
 }
 
 func (s *session) Session() neo4j.SessionWithContext {
@@ -231,7 +284,7 @@ func (s *session) Session() neo4j.SessionWithContext {
 
 func (s *session) Close(ctx context.Context, errs ...error) error {
 	sessErr := s.session.Close(ctx)
-	s.driver.sessionSemaphore.Release(1)
+	s.driver.sessionPool.release()
 	if sessErr != nil {
 		errs = append(errs, sessErr)
 		return errors.Join(errs...)
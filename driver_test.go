@@ -150,13 +150,16 @@ func ExampleDriver_readSession() {
 	}
 
 	var ns, nsTimes2 []int
-	session := d.ReadSession(ctx)
+	session, err := d.ReadSession(ctx)
+	if err != nil {
+		panic(err)
+	}
 	defer func() {
 		if err := session.Close(ctx); err != nil {
 			panic(err)
 		}
 	}()
-	err := session.ReadTransaction(ctx, func(begin func() Query) error {
+	err = session.ReadTransaction(ctx, func(begin func() Query) error {
 		if err := begin().
 			Unwind("range(0, 10)", "i").
 			Return(db.Qual(&ns, "i")).Run(ctx); err != nil {
@@ -209,13 +212,16 @@ func ExampleDriver_writeSession() {
 	}
 
 	var people []*Person
-	session := d.WriteSession(ctx)
+	session, err := d.WriteSession(ctx)
+	if err != nil {
+		panic(err)
+	}
 	defer func() {
 		if err := session.Close(ctx); err != nil {
 			panic(err)
 		}
 	}()
-	err := session.WriteTransaction(ctx, func(begin func() Query) error {
+	err = session.WriteTransaction(ctx, func(begin func() Query) error {
 		if err := begin().
 			Unwind("range(1, 10)", "i").
 			Merge(db.Node(
@@ -315,13 +321,16 @@ func ExampleDriver_streamWithParams() {
 	}
 
 	ns := []int{}
-	session := d.ReadSession(ctx)
+	session, err := d.ReadSession(ctx)
+	if err != nil {
+		panic(err)
+	}
 	defer func() {
 		if err := session.Close(ctx); err != nil {
 			panic(err)
 		}
 	}()
-	err := session.ReadTransaction(ctx, func(begin func() Query) error {
+	err = session.ReadTransaction(ctx, func(begin func() Query) error {
 		var num int
 		params := map[string]interface{}{
 			"total": n,
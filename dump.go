@@ -0,0 +1,218 @@
+package neogo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"github.com/rlch/neogo/query"
+)
+
+// redacted replaces the values of redacted parameters and properties.
+const redacted = "<redacted>"
+
+func (c *runnerImpl) Dump(w io.Writer, opts ...query.DumpOption) error {
+	cy, err := c.Compile()
+	if err != nil {
+		return err
+	}
+	config := query.NewDumpConfig(opts...)
+
+	names := make([]string, 0, len(cy.Parameters))
+	for name := range cy.Parameters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	if config.ParamsMap {
+		b.WriteString(":params {")
+	}
+	for i, name := range names {
+		v := redact(config.Redact, name, cy.Parameters[name])
+		literal, err := cypherLiteral(v)
+		if err != nil {
+			return fmt.Errorf("cannot dump parameter %q: %w", name, err)
+		}
+		if config.ParamsMap {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s: %s", cypherName(name), literal)
+		} else {
+			fmt.Fprintf(&b, ":param %s => %s;\n", cypherName(name), literal)
+		}
+	}
+	if config.ParamsMap {
+		b.WriteString("};\n")
+	}
+	if len(names) > 0 || config.ParamsMap {
+		b.WriteString("\n")
+	}
+	b.WriteString(cy.Cypher)
+	b.WriteString("\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// redact replaces v with a placeholder if name is one of names, or else the
+// properties within v whose names are.
+func redact(names []string, name string, v any) any {
+	for _, n := range names {
+		if name == n || strings.HasSuffix(name, "_"+n) {
+			return redacted
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = redact(names, k, e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = redact(names, "", e)
+		}
+		return out
+	}
+	return v
+}
+
+// cypherLiteral writes v, a canonicalized parameter, as a Cypher literal.
+// Byte arrays have no literal, and are written as lists of integers.
+func cypherLiteral(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return cypherFloat(v), nil
+	case string:
+		return cypherString(v), nil
+	case []byte:
+		ints := make([]string, len(v))
+		for i, b := range v {
+			ints[i] = strconv.Itoa(int(b))
+		}
+		return "[" + strings.Join(ints, ", ") + "]", nil
+	case []any:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elem, err := cypherLiteral(e)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		entries := make([]string, len(keys))
+		for i, k := range keys {
+			elem, err := cypherLiteral(v[k])
+			if err != nil {
+				return "", err
+			}
+			entries[i] = cypherName(k) + ": " + elem
+		}
+		return "{" + strings.Join(entries, ", ") + "}", nil
+	case time.Time:
+		s := v.Format(time.RFC3339Nano)
+		if name := v.Location().String(); name != "UTC" && name != "Local" {
+			s += "[" + name + "]"
+		}
+		return "datetime(" + cypherString(s) + ")", nil
+	case neo4j.Date:
+		return "date(" + cypherString(v.Time().Format(time.DateOnly)) + ")", nil
+	case neo4j.LocalDateTime:
+		return "localdatetime(" + cypherString(v.Time().Format("2006-01-02T15:04:05.999999999")) + ")", nil
+	case neo4j.LocalTime:
+		return "localtime(" + cypherString(v.Time().Format("15:04:05.999999999")) + ")", nil
+	case neo4j.Time:
+		return "time(" + cypherString(v.Time().Format("15:04:05.999999999Z07:00")) + ")", nil
+	case neo4j.Duration:
+		return fmt.Sprintf("duration({months: %d, days: %d, seconds: %d, nanoseconds: %d})",
+			v.Months, v.Days, v.Seconds, v.Nanos), nil
+	case neo4j.Point2D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s})",
+			v.SpatialRefId, cypherFloat(v.X), cypherFloat(v.Y)), nil
+	case neo4j.Point3D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s, z: %s})",
+			v.SpatialRefId, cypherFloat(v.X), cypherFloat(v.Y), cypherFloat(v.Z)), nil
+	}
+	return "", fmt.Errorf("unsupported type: %T", v)
+}
+
+func cypherFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "0.0 / 0.0"
+	case math.IsInf(f, 1):
+		return "1.0 / 0.0"
+	case math.IsInf(f, -1):
+		return "-1.0 / 0.0"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	// Cypher doesn't allow a sign in exponents, and reads numbers without a
+	// decimal point or exponent as integers.
+	s = strings.Replace(s, "e+", "e", 1)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+func cypherString(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// cypherName escapes name with backticks if it isn't a valid identifier.
+func cypherName(name string) string {
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	if name == "" {
+		return "``"
+	}
+	return name
+}
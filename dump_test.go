@@ -0,0 +1,116 @@
+package neogo
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+	"github.com/rlch/neogo/query"
+)
+
+func TestDump(t *testing.T) {
+	dump := func(t *testing.T, opts ...query.DumpOption) string {
+		t.Helper()
+		p := tests.Person{Node: Node{ID: "p1"}, Name: "O'Brien", Email: "jessie@example.com"}
+		meta := map[string]any{"password": "hunter2", "tags": []string{"a", "b"}}
+		var b strings.Builder
+		err := NewMock().Exec().
+			Create(db.Node(&p)).
+			Set(db.SetPropValue("person.meta", db.NamedParam(meta, "meta"))).
+			Return(&p).
+			Dump(&b, opts...)
+		require.NoError(t, err)
+		return b.String()
+	}
+
+	t.Run("writes a :param statement for each parameter", func(t *testing.T) {
+		assert.Equal(t, `:param meta => {password: 'hunter2', tags: ['a', 'b']};
+:param person_email => 'jessie@example.com';
+:param person_id => 'p1';
+:param person_name => 'O\'Brien';
+
+CREATE (person:Person {email: $person_email, id: $person_id, name: $person_name})
+SET person.meta = $meta
+RETURN person
+`, dump(t))
+	})
+
+	t.Run("redacts parameters and properties", func(t *testing.T) {
+		assert.Equal(t, `:param meta => {password: '<redacted>', tags: ['a', 'b']};
+:param person_email => '<redacted>';
+:param person_id => 'p1';
+:param person_name => 'O\'Brien';
+
+CREATE (person:Person {email: $person_email, id: $person_id, name: $person_name})
+SET person.meta = $meta
+RETURN person
+`, dump(t, Redact("email", "password")))
+	})
+
+	t.Run("writes a :params map", func(t *testing.T) {
+		assert.Equal(t, `:params {meta: '<redacted>', person_email: 'jessie@example.com', person_id: 'p1', person_name: 'O\'Brien'};
+
+CREATE (person:Person {email: $person_email, id: $person_id, name: $person_name})
+SET person.meta = $meta
+RETURN person
+`, dump(t, ParamsMap(), Redact("meta")))
+	})
+
+	t.Run("writes queries without parameters", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, NewMock().Exec().Return("1").Dump(&b))
+		assert.Equal(t, "RETURN 1\n", b.String())
+	})
+
+	t.Run("fails on unsupported parameters", func(t *testing.T) {
+		err := NewMock().Exec().
+			Return(db.Qual(db.NamedParam(complex(1, 2), "c"), "c")).
+			Dump(&strings.Builder{})
+		assert.Error(t, err)
+	})
+}
+
+func TestCypherLiteral(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	day := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	for _, tc := range []struct {
+		value any
+		want  string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{int64(-42), "-42"},
+		{1.0, "1.0"},
+		{1.5e300, "1.5e300"},
+		{2.5e-7, "2.5e-07"},
+		{math.Inf(-1), "-1.0 / 0.0"},
+		{"line\n'quoted' \\ \x00", `'line\n\'quoted\' \\ \u0000'`},
+		{[]byte{1, 255}, "[1, 255]"},
+		{[]any{int64(1), "a", nil}, "[1, 'a', null]"},
+		{map[string]any{"b": int64(1), "a b": false}, "{`a b`: false, b: 1}"},
+		{day, "datetime('2024-01-02T03:04:05.0000006Z')"},
+		{day.In(berlin), "datetime('2024-01-02T04:04:05.0000006+01:00[Europe/Berlin]')"},
+		{neo4j.Date(day), "date('2024-01-02')"},
+		{neo4j.LocalDateTime(day), "localdatetime('2024-01-02T03:04:05.0000006')"},
+		{neo4j.LocalTime(day), "localtime('03:04:05.0000006')"},
+		{neo4j.Time(day), "time('03:04:05.0000006Z')"},
+		{neo4j.Duration{Months: 1, Days: 2, Seconds: 3, Nanos: 4}, "duration({months: 1, days: 2, seconds: 3, nanoseconds: 4})"},
+		{neo4j.Point2D{X: 1, Y: 2.5, SpatialRefId: 7203}, "point({srid: 7203, x: 1.0, y: 2.5})"},
+		{neo4j.Point3D{X: 1, Y: 2, Z: 3, SpatialRefId: 9157}, "point({srid: 9157, x: 1.0, y: 2.0, z: 3.0})"},
+	} {
+		got, err := cypherLiteral(tc.value)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err = cypherLiteral(struct{}{})
+	assert.ErrorContains(t, err, "unsupported type: struct {}")
+}
@@ -6,23 +6,32 @@ import (
 	"github.com/rlch/neogo/internal"
 )
 
-// NewNode creates a new node with a random ID.
+// NewNode creates a new node with an ID generated by the first of
+// generators, or by the default [IDGenerator], which generates ULIDs, if
+// there are none. A [Driver] is an IDGenerator, so nodes can be given IDs by
+// the generator it's configured with:
+//
+//	p := neogo.NewNode[Person](d)
+//
+// See [KeyedNode.GenerateID] for nodes whose IDs can't hold ULIDs.
 func NewNode[N any, PN interface {
 	INode
 	internal.IDSetter
 	*N
-}]() PN {
+}](generators ...IDGenerator) PN {
 	n := PN(new(N))
-	n.GenerateID()
+	n.GenerateID(generators...)
 	return n
 }
 
-// NodeWithID creates a new node with the given ID.
+// NodeWithID creates a new node with the given ID, which is converted to the
+// node's key type if necessary. It panics if id can't be converted, such as
+// when an int ID doesn't fit in the key type of a [KeyedNode].
 func NodeWithID[N any, PN interface {
 	INode
 	internal.IDSetter
 	*N
-}](id string,
+}](id any,
 ) PN {
 	n := PN(new(N))
 	n.SetID(id)
@@ -31,6 +40,60 @@ func NodeWithID[N any, PN interface {
 
 var rAbstract = reflect.TypeOf((*IAbstract)(nil)).Elem()
 
+// KeyedNode is a base type for nodes whose IDs have type K, such as int64 or
+// a UUID type, rather than string like [Node]:
+//
+//	type Account struct {
+//		neogo.KeyedNode[int64] `neo4j:"Account"`
+//
+//		Email string `json:"email"`
+//	}
+//
+// IDs set with SetID are converted to K without losing their values: numbers
+// are converted if they fit in K, numbers and strings are converted to and
+// from one another, and strings are unmarshalled into keys implementing
+// [encoding.TextUnmarshaler]. Keys which aren't stdlib types need a [Valuer]
+// or a codec registered using [WithCodec] to be stored.
+type KeyedNode[K comparable] struct {
+	ID K `json:"id"`
+}
+
+var _ interface {
+	INode
+	internal.IDHolder
+} = (*KeyedNode[int64])(nil)
+
+func (KeyedNode[K]) IsNode() {}
+
+// GetID returns the ID formatted as a string.
+func (n KeyedNode[K]) GetID() string { return internal.FormatID(n.ID) }
+
+// HasID reports whether the ID isn't the zero value of K.
+func (n KeyedNode[K]) HasID() bool {
+	var zero K
+	return n.ID != zero
+}
+
+// SetID sets the ID to id converted to K. It panics if id can't be converted.
+func (n *KeyedNode[K]) SetID(id any) {
+	n.ID = internal.MustConvertID[K](id)
+}
+
+// GenerateID sets the ID to one generated by the first of generators, as by
+// SetID. If there are none, the default [IDGenerator], which generates ULIDs,
+// is used. If K can't hold them, the ID is left unset, so that it's generated
+// by the IDGenerator configured with [WithIDGenerator] when the node is
+// created.
+func (n *KeyedNode[K]) GenerateID(generators ...IDGenerator) {
+	if len(generators) > 0 && generators[0] != nil {
+		n.SetID(generators[0].NewID())
+		return
+	}
+	if id, err := internal.ConvertID[K](internal.DefaultIDGenerator.NewID()); err == nil {
+		n.ID = id
+	}
+}
+
 func ExtractNodeLabels(i any) []string {
 	return internal.ExtractNodeLabels(i)
 }
@@ -52,7 +115,8 @@ type (
 	// See [Relationship] for the default implementation.
 	IRelationship = internal.IRelationship
 
-	// Node is a base type for all nodes.
+	// Node is a base type for all nodes, whose IDs are strings. See
+	// [KeyedNode] for nodes with IDs of other types.
 	//
 	// The neo4j tag is used to specify the label for the node. Multiple labels
 	// may be specified idiomatically by nested [Node] types. See [internal/tests]
@@ -64,6 +128,36 @@ type (
 	//   Name string `json:"name"`
 	//   Age  int    `json:"age"`
 	//  }
+	//
+	// Properties are named by json tags. On fields other than embedded nodes, a
+	// neo4j tag takes precedence, supporting a name, "-", "omitempty" and
+	// "inline", which promotes the properties of a struct field to the node:
+	//
+	//  type User struct {
+	//   neogo.Node `neo4j:"User"`
+	//
+	//   Email    string  `json:"email" neo4j:"emailAddress"`
+	//   Password string  `json:"-" neo4j:"password"`
+	//   Session  string  `json:"session" neo4j:"-"`
+	//   Address  Address `json:"address" neo4j:",inline"`
+	//  }
+	//
+	// As properties cannot hold maps, nested structs and maps can be stored
+	// using "flatten", which stores each of their properties prefixed with the
+	// field's name, "json", which stores them as a JSON string, or, for nodes,
+	// "node=TYPE", which stores them as a node related by a relationship of
+	// type TYPE. Related nodes are created or merged alongside their parent,
+	// but aren't matched with it, and can be bound using a map projection or
+	// by returning them directly:
+	//
+	//  type Employee struct {
+	//   neogo.Node `neo4j:"Employee"`
+	//
+	//   Home     Address           `neo4j:"home,flatten"` // home_city, ...
+	//   Labels   map[string]string `neo4j:"labels,flatten"`
+	//   Settings Settings          `neo4j:"settings,json"`
+	//   Employer *Company          `neo4j:"employer,node=WORKS_AT"`
+	//  }
 	Node = internal.Node
 
 	// Abstract is a base type for all abstract nodes. An abstract node can have
@@ -122,3 +216,51 @@ type (
 	//  }
 	Label = internal.Label
 )
+
+type (
+	// NodeMetadata can be embedded in a node to receive its metadata when it's
+	// bound. It isn't stored as properties.
+	//
+	//  type Person struct {
+	//   neogo.Node `neo4j:"Person"`
+	//   neogo.NodeMetadata
+	//
+	//   Name string `json:"name"`
+	//  }
+	//
+	// Fields can also receive metadata individually using the neo4j tag options
+	// "elementId" and "labels":
+	//
+	//  type Person struct {
+	//   neogo.Node `neo4j:"Person"`
+	//
+	//   ElementID string `neo4j:",elementId"`
+	//  }
+	//
+	// Used in a query, these fields are accessed using the functions which
+	// return them, such as elementId(person).
+	NodeMetadata struct {
+		// ElementID is the element ID of the node, which uniquely identifies it
+		// within a transaction.
+		ElementID string `json:"elementId,omitempty" neo4j:",elementId"`
+		// Labels are the labels of the node in the database.
+		Labels []string `json:"labels,omitempty" neo4j:",labels"`
+	}
+
+	// RelationshipMetadata can be embedded in a relationship to receive its
+	// metadata when it's bound. It isn't stored as properties.
+	//
+	// Fields can also receive metadata individually using the neo4j tag options
+	// "elementId", "startElementId", "endElementId" and "type".
+	RelationshipMetadata struct {
+		// ElementID is the element ID of the relationship.
+		ElementID string `json:"elementId,omitempty" neo4j:",elementId"`
+		// StartElementID is the element ID of the node the relationship starts
+		// at.
+		StartElementID string `json:"startElementId,omitempty" neo4j:",startElementId"`
+		// EndElementID is the element ID of the node the relationship ends at.
+		EndElementID string `json:"endElementId,omitempty" neo4j:",endElementId"`
+		// Type is the type of the relationship in the database.
+		Type string `json:"type,omitempty" neo4j:",type"`
+	}
+)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
 )
 
 func ExampleNewNode() {
@@ -17,3 +18,33 @@ func ExampleNodeWithID() {
 	fmt.Printf("id: %v", n.ID)
 	// Output: id: test
 }
+
+func ExampleKeyedNode() {
+	type Account struct {
+		neogo.KeyedNode[int64] `neo4j:"Account"`
+	}
+	a := neogo.NodeWithID[Account]("42")
+	fmt.Printf("id: %d", a.ID)
+	// Output: id: 42
+}
+
+func ExampleNodeMetadata() {
+	type Person struct {
+		neogo.Node `neo4j:"Person"`
+		neogo.NodeMetadata
+
+		Name string `json:"name"`
+	}
+	var p Person
+	neogo.NewMock().Exec().
+		Match(db.Node(db.Qual(&p, "p"))).
+		Where(db.Cond(&p.ElementID, "=", "'4:abc:0'")).
+		Set(db.SetPropValue(&p.Name, db.String("Jessie"))).
+		Return(&p.Labels).
+		Print()
+	// Output:
+	// MATCH (p:Person)
+	// WHERE elementId(p) = '4:abc:0'
+	// SET p.name = "Jessie"
+	// RETURN labels(p)
+}
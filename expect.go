@@ -0,0 +1,273 @@
+package neogo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// QueryMatcher matches the Cypher of queries run against a mock [Driver]. See
+// ExpectQuery.
+type QueryMatcher interface {
+	MatchQuery(cypher string) bool
+}
+
+// QueryMatcherFunc is a function implementing [QueryMatcher].
+type QueryMatcherFunc func(cypher string) bool
+
+func (f QueryMatcherFunc) MatchQuery(cypher string) bool { return f(cypher) }
+
+type regexpMatcher struct{ *regexp.Regexp }
+
+func (m regexpMatcher) MatchQuery(cypher string) bool { return m.MatchString(cypher) }
+
+// Expectation is a query expected by a mock [Driver], created by ExpectQuery.
+//
+//	m := neogo.NewMock()
+//	m.ExpectQuery(`MATCH \(p:Person`).
+//		WithParams(map[string]any{"p_name": "Jessie"}).
+//		WillReturn(map[string]any{"p": &Person{Name: "Jessie"}})
+//
+//	// ... run code under test ...
+//
+//	if err := m.ExpectationsWereMet(); err != nil {
+//		t.Error(err)
+//	}
+type Expectation struct {
+	// mu is the mutex of the mock's bindings, which guards the fields of the
+	// expectation as queries are matched against it.
+	mu *sync.Mutex
+
+	query   QueryMatcher
+	desc    string
+	params  map[string]any
+	records []map[string]any
+	summary *MockSummary
+	err     error
+	times   int
+	calls   int
+}
+
+// MockSummary configures the [neo4j.ResultSummary] of a query run against a
+// mock [Driver]. See WillReturnSummary.
+type MockSummary struct {
+	// StatementType is the type of the query. If unset, it's inferred from
+	// whether neogo considers the query to be a write.
+	StatementType neo4j.StatementType
+	Counters      MockCounters
+	Notifications []MockNotification
+	// Plan is the plan of the query, as returned by Explain. It's also
+	// returned by Profile if the query is prefixed with PROFILE.
+	Plan *MockPlan
+}
+
+// MockPlan is an operator in the plan of a query run against a mock [Driver].
+type MockPlan struct {
+	Operator    string
+	Arguments   map[string]any
+	Identifiers []string
+	// Rows, DbHits, PageCacheHits, PageCacheMisses, PageCacheHitRatio and Time
+	// are the runtime statistics of the operator, returned by Profile.
+	Rows              int64
+	DbHits            int64
+	PageCacheHits     int64
+	PageCacheMisses   int64
+	PageCacheHitRatio float64
+	Time              int64
+	Children          []MockPlan
+}
+
+// MockCounters are the updates made by a query run against a mock [Driver].
+type MockCounters struct {
+	NodesCreated         int
+	NodesDeleted         int
+	RelationshipsCreated int
+	RelationshipsDeleted int
+	PropertiesSet        int
+	LabelsAdded          int
+	LabelsRemoved        int
+	IndexesAdded         int
+	IndexesRemoved       int
+	ConstraintsAdded     int
+	ConstraintsRemoved   int
+	SystemUpdates        int
+}
+
+// MockNotification is a notification raised by a query run against a mock
+// [Driver].
+type MockNotification struct {
+	Code        string
+	Title       string
+	Description string
+	Severity    neo4j.NotificationSeverity
+	Category    neo4j.NotificationCategory
+	// Line, Column and Offset are the position in the query the notification
+	// refers to. The position is nil if Line is 0.
+	Line, Column, Offset int
+}
+
+// MockTransientError returns a retriable error, as Neo4j returns when a
+// transaction deadlocks. Queries failing with it are retried by ExecuteRead
+// and ExecuteWrite.
+func MockTransientError(msg string) error {
+	return &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected", Msg: msg}
+}
+
+// MockConstraintError returns the error Neo4j returns when a query violates a
+// constraint, such as a uniqueness constraint.
+func MockConstraintError(msg string) error {
+	return &neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed", Msg: msg}
+}
+
+// MockNetworkError returns a retriable error, as the driver returns when the
+// connection to Neo4j is lost.
+func MockNetworkError(msg string) error {
+	return &neo4j.ConnectivityError{Inner: errors.New(msg)}
+}
+
+// WithParams expects the query to be run with params. Other parameters the
+// query is run with are ignored. Values are compared once encoded as they
+// would be sent to Neo4j.
+func (e *Expectation) WithParams(params map[string]any) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.params = params
+	return e
+}
+
+// WillReturn responds to the query with records, which are bound as though
+// by BindRecords.
+func (e *Expectation) WillReturn(records ...map[string]any) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = records
+	return e
+}
+
+// WillReturnSummary responds to the query with summary, as returned by
+// RunSummary.
+func (e *Expectation) WillReturnSummary(summary MockSummary) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.summary = &summary
+	return e
+}
+
+// WillReturnError responds to the query with err. Errors such as
+// [MockTransientError] and [MockNetworkError] are retriable, so work run with
+// ExecuteRead and ExecuteWrite is retried until the query is run without
+// matching the expectation.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+	return e
+}
+
+// Times expects the query to be run n times, rather than once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.times = n
+	return e
+}
+
+func (e *Expectation) String() string {
+	s := fmt.Sprintf("query matching %s", e.desc)
+	if len(e.params) > 0 {
+		s += fmt.Sprintf(" with params %v", e.params)
+	}
+	return s
+}
+
+// expectations are the expectations of a mock, guarded by the mutex of its
+// bindings.
+type expectations []*Expectation
+
+// match consumes the first unmet expectation matching the query, returning nil
+// if there is none.
+func (es expectations) match(r *registry, cypher string, params map[string]any) (*Expectation, error) {
+	for _, e := range es {
+		if e.calls >= e.times || !e.query.MatchQuery(cypher) {
+			continue
+		}
+		ok, err := matchParams(r, e.params, params)
+		if err != nil {
+			return nil, fmt.Errorf("cannot match params of %s: %w", e, err)
+		}
+		if ok {
+			e.calls++
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// unexpectedQuery is the error returned for a query which no expectation
+// matches.
+func unexpectedQuery(cypher string, params map[string]any) error {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unexpected query:\n%s\nwith params: %s", cypher, strings.Join(names, ", "))
+}
+
+func matchParams(r *registry, expected, actual map[string]any) (bool, error) {
+	if len(expected) == 0 {
+		return true, nil
+	}
+	if r == nil {
+		r = &registry{}
+	}
+	expected, err := r.canonicalizeParams(expected)
+	if err != nil {
+		return false, err
+	}
+	for name, value := range expected {
+		a, ok := actual[name]
+		if !ok || !reflect.DeepEqual(value, a) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *mockBindings) ExpectQuery(query any) *Expectation {
+	e := &Expectation{mu: &d.mu, times: 1}
+	switch q := query.(type) {
+	case string:
+		e.query, e.desc = regexpMatcher{regexp.MustCompile(q)}, fmt.Sprintf("%q", q)
+	case *regexp.Regexp:
+		e.query, e.desc = regexpMatcher{q}, fmt.Sprintf("%q", q.String())
+	case QueryMatcher:
+		e.query, e.desc = q, fmt.Sprintf("%T", q)
+	case func(string) bool:
+		e.query, e.desc = QueryMatcherFunc(q), "func"
+	default:
+		panic(fmt.Errorf("cannot expect query using %T", query))
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expectations = append(d.expectations, e)
+	return e
+}
+
+func (d *mockBindings) ExpectationsWereMet() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var errs []error
+	for _, e := range d.expectations {
+		if e.calls < e.times {
+			errs = append(errs, fmt.Errorf("expected %s to be run %d times, but it was run %d times", e, e.times, e.calls))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,144 @@
+package neogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+func TestExpectations(t *testing.T) {
+	ctx := context.Background()
+	findPerson := func(d Driver, name string) (*tests.Person, error) {
+		var p tests.Person
+		err := d.Exec().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"name": db.NamedParam(name, "name")}))).
+			Return(&p).
+			Run(ctx)
+		return &p, err
+	}
+
+	t.Run("matches queries in any order", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`MATCH \(p:Person`).
+			WithParams(map[string]any{"name": "Bob"}).
+			WillReturn(map[string]any{"p": &tests.Person{Name: "Bob", Age: 30}})
+		m.ExpectQuery(regexp.MustCompile(`MATCH \(p:Person`)).
+			WithParams(map[string]any{"name": "Alice"}).
+			WillReturn(map[string]any{"p": &tests.Person{Name: "Alice", Age: 20}})
+
+		alice, err := findPerson(m, "Alice")
+		require.NoError(t, err)
+		assert.Equal(t, 20, alice.Age)
+		bob, err := findPerson(m, "Bob")
+		require.NoError(t, err)
+		assert.Equal(t, 30, bob.Age)
+		assert.NoError(t, m.ExpectationsWereMet())
+	})
+
+	t.Run("reports unmet expectations", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`MATCH`).WithParams(map[string]any{"name": "Alice"}).Times(2)
+		m.ExpectQuery(QueryMatcherFunc(func(cypher string) bool {
+			return strings.HasPrefix(cypher, "CREATE")
+		}))
+
+		_, err := findPerson(m, "Alice")
+		require.NoError(t, err)
+		err = m.ExpectationsWereMet()
+		assert.ErrorContains(t, err, `expected query matching "MATCH" with params map[name:Alice] to be run 2 times, but it was run 1 times`)
+		assert.ErrorContains(t, err, "expected query matching neogo.QueryMatcherFunc to be run 1 times, but it was run 0 times")
+	})
+
+	t.Run("errors on unexpected queries", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`MATCH`).WithParams(map[string]any{"name": "Alice"})
+
+		_, err := findPerson(m, "Bob")
+		assert.ErrorContains(t, err, "unexpected query:\nMATCH (p:Person {name: $name})\nRETURN p\nwith params: __isWrite, name")
+	})
+
+	t.Run("falls back to bindings", func(t *testing.T) {
+		m := NewMock()
+		m.ExpectQuery(`CREATE`)
+		m.Bind(map[string]any{"p": &tests.Person{Name: "Alice", Age: 20}})
+
+		p, err := findPerson(m, "Alice")
+		require.NoError(t, err)
+		assert.Equal(t, 20, p.Age)
+	})
+
+	t.Run("returns errors", func(t *testing.T) {
+		m := NewMock()
+		errUnavailable := errors.New("unavailable")
+		m.ExpectQuery(func(cypher string) bool { return true }).WillReturnError(errUnavailable)
+
+		_, err := findPerson(m, "Alice")
+		assert.ErrorIs(t, err, errUnavailable)
+		assert.NoError(t, m.ExpectationsWereMet())
+	})
+
+	t.Run("matches concurrent queries", func(t *testing.T) {
+		const n = 20
+		m := NewMock()
+		for i := range n {
+			name := fmt.Sprintf("person%d", i)
+			m.ExpectQuery(`MATCH`).
+				WithParams(map[string]any{"name": name}).
+				WillReturn(map[string]any{"p": &tests.Person{Name: name, Age: i}})
+		}
+
+		var wg sync.WaitGroup
+		ages := make([]int, n)
+		errs := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p, err := findPerson(m, fmt.Sprintf("person%d", i))
+				ages[i], errs[i] = p.Age, err
+			}()
+		}
+		wg.Wait()
+		for i := range n {
+			require.NoError(t, errs[i])
+			assert.Equal(t, i, ages[i])
+		}
+		assert.NoError(t, m.ExpectationsWereMet())
+	})
+
+	t.Run("configures expectations concurrently with queries", func(t *testing.T) {
+		const n = 20
+		m := NewMock()
+		e := m.ExpectQuery(`MATCH`).WithParams(map[string]any{"name": "Alice"})
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				e.WillReturn(map[string]any{"p": &tests.Person{Name: "Alice"}}).
+					WillReturnError(nil).
+					Times(i + 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range n {
+				_, err := findPerson(m, "Bob")
+				assert.Error(t, err)
+			}
+		}()
+		wg.Wait()
+		assert.ErrorContains(t, m.ExpectationsWereMet(), fmt.Sprintf("to be run %d times, but it was run 0 times", n))
+	})
+}
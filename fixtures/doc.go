@@ -0,0 +1,30 @@
+// Package fixtures seeds graphs of typed nodes and relationships from YAML or
+// JSON, for tests.
+//
+//	nodes:
+//	  keanu:
+//	    label: Person
+//	    props: {id: keanu, name: Keanu Reeves, age: 60}
+//	  matrix:
+//	    label: Movie
+//	    props: {id: matrix, title: The Matrix, released: 1999}
+//	relationships:
+//	  - key: neo
+//	    type: ACTED_IN
+//	    from: keanu
+//	    to: matrix
+//	    props: {role: Neo}
+//
+// Nodes are keyed by name, and labelled by a label of a node registered with
+// the [Loader]: the type with exactly the given labels, which may be separated
+// by colons, or otherwise the only type with all of them. Relationships
+// reference the keys of the nodes they connect, and may also be keyed.
+// Properties are written as they're stored in Neo4j, and are decoded into the
+// registered types by the driver, like the results of any other query.
+//
+//	l := fixtures.New(d, &Person{}, &Movie{}, ActedIn{})
+//	set, err := l.LoadFile(ctx, "testdata/movies.yaml")
+//	...
+//	defer set.Cleanup(ctx)
+//	keanu := set.Entities["keanu"].(*Person)
+package fixtures
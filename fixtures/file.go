@@ -0,0 +1,66 @@
+package fixtures
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// file is a fixture file. JSON is decoded as YAML, which it's a subset of.
+	file struct {
+		Nodes         map[string]nodeFixture `yaml:"nodes"`
+		Relationships []relFixture           `yaml:"relationships"`
+	}
+
+	nodeFixture struct {
+		Label string         `yaml:"label"`
+		Props map[string]any `yaml:"props"`
+	}
+
+	relFixture struct {
+		Key   string         `yaml:"key"`
+		Type  string         `yaml:"type"`
+		From  string         `yaml:"from"`
+		To    string         `yaml:"to"`
+		Props map[string]any `yaml:"props"`
+	}
+)
+
+// parse decodes and validates a fixture file.
+func parse(data []byte) (*file, error) {
+	var f file
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+	if err := d.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot decode fixtures: %w", err)
+	}
+	for key, n := range f.Nodes {
+		if n.Label == "" {
+			return nil, fmt.Errorf("node %q has no label", key)
+		}
+	}
+	keys := map[string]bool{}
+	for i, r := range f.Relationships {
+		name := fmt.Sprintf("relationship %d", i)
+		if r.Key != "" {
+			name = fmt.Sprintf("relationship %q", r.Key)
+			if _, ok := f.Nodes[r.Key]; ok || keys[r.Key] {
+				return nil, fmt.Errorf("key of %s is already used", name)
+			}
+			keys[r.Key] = true
+		}
+		if r.Type == "" {
+			return nil, fmt.Errorf("%s has no type", name)
+		}
+		for _, end := range []string{r.From, r.To} {
+			if _, ok := f.Nodes[end]; !ok {
+				return nil, fmt.Errorf("%s references unknown node %q", name, end)
+			}
+		}
+	}
+	return &f, nil
+}
@@ -0,0 +1,376 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal"
+	"github.com/rlch/neogo/query"
+)
+
+// DefaultBatchSize is the default number of nodes or relationships created by
+// each UNWIND query.
+const DefaultBatchSize = 1000
+
+type (
+	// Loader creates fixtures with a driver, hydrating them into the node and
+	// relationship types it was created with.
+	Loader struct {
+		// BatchSize is the number of nodes or relationships created by each
+		// UNWIND query. If it's zero, DefaultBatchSize is used.
+		BatchSize int
+
+		driver        neogo.Driver
+		nodes         []*nodeType
+		relationships map[string]reflect.Type
+	}
+
+	// nodeType is a registered node.
+	nodeType struct {
+		// typ is a pointer to the struct of the node.
+		typ    reflect.Type
+		labels []string
+	}
+
+	// Set is a set of fixtures created by a [Loader].
+	Set struct {
+		// Entities maps the keys of nodes, and keyed relationships, to pointers
+		// to their hydrated structs. Relationships whose types weren't
+		// registered with the [Loader] are absent.
+		Entities map[string]any
+
+		driver        neogo.Driver
+		elementIDs    map[string]string
+		nodes         []string
+		relationships []string
+	}
+)
+
+// New creates a [Loader] which creates fixtures with d. types are the nodes,
+// abstract nodes and relationships fixtures may have, as passed to
+// [neogo.WithTypes].
+func New(d neogo.Driver, types ...any) *Loader {
+	l := &Loader{driver: d, relationships: map[string]reflect.Type{}}
+	for _, t := range types {
+		switch v := t.(type) {
+		case neogo.IAbstract:
+			l.addNode(v)
+			for _, impl := range v.Implementers() {
+				l.addNode(impl)
+			}
+		case neogo.INode:
+			l.addNode(v)
+		case neogo.IRelationship:
+			typ := reflect.TypeOf(v)
+			if typ.Kind() != reflect.Ptr {
+				typ = reflect.PointerTo(typ)
+			}
+			l.relationships[internal.ExtractRelationshipType(v)] = typ
+		}
+	}
+	return l
+}
+
+func (l *Loader) addNode(n any) {
+	typ := reflect.TypeOf(n)
+	labels := internal.ExtractConcreteNodeLabels(n)
+	if typ.Kind() != reflect.Ptr || len(labels) == 0 {
+		return
+	}
+	for _, existing := range l.nodes {
+		if existing.typ == typ {
+			return
+		}
+	}
+	l.nodes = append(l.nodes, &nodeType{typ: typ, labels: labels})
+}
+
+// resolve returns the node type with label, which may be several labels
+// separated by colons: the type with exactly those labels, or otherwise the
+// only type with all of them.
+func (l *Loader) resolve(label string) (*nodeType, error) {
+	want := strings.Split(label, ":")
+	var candidates []*nodeType
+Types:
+	for _, n := range l.nodes {
+		for _, w := range want {
+			if !slices.Contains(n.labels, w) {
+				continue Types
+			}
+		}
+		if len(n.labels) == len(want) {
+			return n, nil
+		}
+		candidates = append(candidates, n)
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no node with label %s was registered", label)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.typ.String()
+	}
+	return nil, fmt.Errorf("label %s is ambiguous between %s", label, strings.Join(names, ", "))
+}
+
+// LoadFile creates the fixtures in the YAML or JSON file at path. See [Loader.Load].
+func (l *Loader) LoadFile(ctx context.Context, path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fixtures: %w", err)
+	}
+	return l.Load(ctx, data)
+}
+
+// Load creates the fixtures described by data, as YAML or JSON, in a single
+// write transaction, and returns them hydrated.
+//
+// Nodes with the same type, and relationships with the same type, are created
+// together by UNWIND queries of up to BatchSize rows.
+func (l *Loader) Load(ctx context.Context, data []byte) (*Set, error) {
+	f, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group nodes by type, and relationships by type, in a deterministic order.
+	byType := map[*nodeType][]map[string]any{}
+	var types []*nodeType
+	for _, key := range sortedKeys(f.Nodes) {
+		n := f.Nodes[key]
+		typ, err := l.resolve(n.Label)
+		if err != nil {
+			return nil, fmt.Errorf("node %q: %w", key, err)
+		}
+		if _, ok := byType[typ]; !ok {
+			types = append(types, typ)
+		}
+		byType[typ] = append(byType[typ], map[string]any{"key": key, "props": props(n.Props)})
+	}
+	byRelType := map[string][]relFixture{}
+	for _, r := range f.Relationships {
+		if _, ok := l.relationships[r.Type]; !ok && r.Key != "" {
+			return nil, fmt.Errorf("relationship %q: no relationship with type %s was registered", r.Key, r.Type)
+		}
+		byRelType[r.Type] = append(byRelType[r.Type], r)
+	}
+
+	sess, err := l.driver.WriteSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set *Set
+	err = sess.WriteTransaction(ctx, func(start func() neogo.Query) error {
+		// The transaction may be retried, so we start afresh.
+		set = &Set{
+			Entities:   map[string]any{},
+			driver:     l.driver,
+			elementIDs: map[string]string{},
+		}
+		for _, typ := range types {
+			if err := l.createNodes(ctx, start, set, typ, byType[typ]); err != nil {
+				return err
+			}
+		}
+		for _, relType := range sortedKeys(byRelType) {
+			var rows []map[string]any
+			for _, r := range byRelType[relType] {
+				rows = append(rows, map[string]any{
+					"key":   r.Key,
+					"from":  set.elementIDs[r.From],
+					"to":    set.elementIDs[r.To],
+					"props": props(r.Props),
+				})
+			}
+			if err := l.createRelationships(ctx, start, set, relType, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err = sess.Close(ctx, err); err != nil {
+		return nil, fmt.Errorf("cannot create fixtures: %w", err)
+	}
+	return set, nil
+}
+
+func (l *Loader) createNodes(
+	ctx context.Context,
+	start func() neogo.Query,
+	set *Set,
+	typ *nodeType,
+	rows []map[string]any,
+) error {
+	cypher := fmt.Sprintf(`UNWIND $rows AS row
+CREATE (n%s)
+SET n = row.props
+WITH row.key AS key, elementId(n) AS id, n`, labelExpr(typ.labels))
+	return l.batch(rows, func(batch []map[string]any) error {
+		var keys, ids []string
+		nodes := reflect.New(reflect.SliceOf(typ.typ))
+		err := start().
+			Cypher(cypher).
+			Return(
+				db.Qual(&keys, "key"),
+				db.Qual(&ids, "id"),
+				db.Qual(nodes.Interface(), "n"),
+			).
+			RunWithParams(ctx, map[string]any{"rows": batch})
+		if err != nil {
+			return fmt.Errorf("cannot create %s nodes: %w", typ.typ.Elem().Name(), err)
+		}
+		if len(keys) != len(batch) {
+			return fmt.Errorf("cannot create %s nodes: %d of %d were created", typ.typ.Elem().Name(), len(keys), len(batch))
+		}
+		for i, key := range keys {
+			set.Entities[key] = nodes.Elem().Index(i).Interface()
+			set.elementIDs[key] = ids[i]
+			set.nodes = append(set.nodes, ids[i])
+		}
+		return nil
+	})
+}
+
+func (l *Loader) createRelationships(
+	ctx context.Context,
+	start func() neogo.Query,
+	set *Set,
+	relType string,
+	rows []map[string]any,
+) error {
+	cypher := fmt.Sprintf(`UNWIND $rows AS row
+MATCH (a)
+WHERE elementId(a) = row.from
+MATCH (b)
+WHERE elementId(b) = row.to
+CREATE (a)-[r:%s]->(b)
+SET r = row.props
+WITH row.key AS key, elementId(r) AS id, r`, quote(relType))
+	typ, hydrate := l.relationships[relType]
+	return l.batch(rows, func(batch []map[string]any) error {
+		var keys, ids []string
+		returns := []query.Identifier{db.Qual(&keys, "key"), db.Qual(&ids, "id")}
+		var rels reflect.Value
+		if hydrate {
+			rels = reflect.New(reflect.SliceOf(typ))
+			returns = append(returns, db.Qual(rels.Interface(), "r"))
+		}
+		err := start().
+			Cypher(cypher).
+			Return(returns...).
+			RunWithParams(ctx, map[string]any{"rows": batch})
+		if err != nil {
+			return fmt.Errorf("cannot create %s relationships: %w", relType, err)
+		}
+		if len(keys) != len(batch) {
+			return fmt.Errorf("cannot create %s relationships: %d of %d were created", relType, len(keys), len(batch))
+		}
+		for i, key := range keys {
+			set.relationships = append(set.relationships, ids[i])
+			if key == "" {
+				continue
+			}
+			set.elementIDs[key] = ids[i]
+			if hydrate {
+				set.Entities[key] = rels.Elem().Index(i).Interface()
+			}
+		}
+		return nil
+	})
+}
+
+// batch calls create with consecutive batches of rows.
+func (l *Loader) batch(rows []map[string]any, create func([]map[string]any) error) error {
+	size := l.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	for len(rows) > 0 {
+		n := min(size, len(rows))
+		if err := create(rows[:n]); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// ElementID returns the element ID of the node or keyed relationship with key.
+func (s *Set) ElementID(key string) (string, bool) {
+	id, ok := s.elementIDs[key]
+	return id, ok
+}
+
+// Cleanup deletes exactly the nodes and relationships created by the
+// [Loader], in a single write transaction. If relationships created since are
+// connected to the nodes, the nodes can't be deleted, so nothing is deleted
+// and the error is returned.
+func (s *Set) Cleanup(ctx context.Context) error {
+	if len(s.nodes) == 0 && len(s.relationships) == 0 {
+		return nil
+	}
+	sess, err := s.driver.WriteSession(ctx)
+	if err != nil {
+		return err
+	}
+	err = sess.WriteTransaction(ctx, func(start func() neogo.Query) error {
+		err := start().
+			Cypher(`MATCH ()-[r]->()
+WHERE elementId(r) IN $ids
+DELETE r`).
+			RunWithParams(ctx, map[string]any{"ids": s.relationships})
+		if err != nil {
+			return err
+		}
+		return start().
+			Cypher(`MATCH (n)
+WHERE elementId(n) IN $ids
+DELETE n`).
+			RunWithParams(ctx, map[string]any{"ids": s.nodes})
+	})
+	if err = sess.Close(ctx, err); err != nil {
+		return fmt.Errorf("cannot delete fixtures: %w", err)
+	}
+	s.nodes, s.relationships = nil, nil
+	return nil
+}
+
+// props returns the properties of a fixture, which must not be nil when set.
+func props(p map[string]any) map[string]any {
+	if p == nil {
+		return map[string]any{}
+	}
+	return p
+}
+
+func labelExpr(labels []string) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(":" + quote(l))
+	}
+	return b.String()
+}
+
+// quote quotes a label or relationship type as an identifier.
+func quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package fixtures
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+	"github.com/rlch/neogo/memdb"
+)
+
+const movies = `
+nodes:
+  keanu:
+    label: Person
+    props: {id: keanu, name: Keanu Reeves, age: 60}
+  carrie:
+    label: Person
+    props: {id: carrie, name: Carrie-Anne Moss, age: 57}
+  matrix:
+    label: Movie
+    props: {id: matrix, title: The Matrix, released: 1999}
+relationships:
+  - key: neo
+    type: ACTED_IN
+    from: keanu
+    to: matrix
+    props: {role: Neo}
+  - type: ACTED_IN
+    from: carrie
+    to: matrix
+    props: {role: Trinity}
+  - type: KNOWS
+    from: keanu
+    to: carrie
+`
+
+func newLoader(t *testing.T) (neogo.Driver, *Loader) {
+	t.Helper()
+	d, err := neogo.NewWithDriver(memdb.New())
+	require.NoError(t, err)
+	return d, New(d, &tests.Person{}, &tests.Movie{}, tests.ActedIn{})
+}
+
+func count(t *testing.T, d neogo.Driver, cypher string) int {
+	t.Helper()
+	var n int
+	err := d.Exec().Cypher(cypher).Return(db.Qual(&n, "n")).Run(context.Background())
+	require.NoError(t, err)
+	return n
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates and hydrates fixtures", func(t *testing.T) {
+		d, l := newLoader(t)
+		set, err := l.Load(ctx, []byte(movies))
+		require.NoError(t, err)
+
+		require.Len(t, set.Entities, 4)
+		keanu, ok := set.Entities["keanu"].(*tests.Person)
+		require.True(t, ok)
+		assert.Equal(t, "keanu", keanu.ID)
+		assert.Equal(t, "Keanu Reeves", keanu.Name)
+		assert.Equal(t, 60, keanu.Age)
+		matrix, ok := set.Entities["matrix"].(*tests.Movie)
+		require.True(t, ok)
+		assert.Equal(t, 1999, matrix.Released)
+		neo, ok := set.Entities["neo"].(*tests.ActedIn)
+		require.True(t, ok)
+		assert.Equal(t, "Neo", neo.Role)
+
+		id, ok := set.ElementID("keanu")
+		require.True(t, ok)
+		var name string
+		err = d.Exec().
+			Cypher("MATCH (p:Person)-[:KNOWS]->(:Person {name: 'Carrie-Anne Moss'}) WHERE elementId(p) = $id").
+			Return(db.Qual(&name, "p.name")).
+			RunWithParams(ctx, map[string]any{"id": id})
+		require.NoError(t, err)
+		assert.Equal(t, "Keanu Reeves", name)
+		assert.Equal(t, 3, count(t, d, "MATCH ()-[r]->() WITH count(r) AS n"))
+	})
+
+	t.Run("loads JSON in batches", func(t *testing.T) {
+		d, l := newLoader(t)
+		l.BatchSize = 1
+		set, err := l.Load(ctx, []byte(`{
+			"nodes": {
+				"a": {"label": "Person", "props": {"name": "A"}},
+				"b": {"label": "Person", "props": {"name": "B"}},
+				"c": {"label": "Person", "props": {"name": "C"}}
+			}
+		}`))
+		require.NoError(t, err)
+		for _, key := range []string{"a", "b", "c"} {
+			p, ok := set.Entities[key].(*tests.Person)
+			require.True(t, ok, key)
+			assert.Equal(t, key, map[string]string{"A": "a", "B": "b", "C": "c"}[p.Name])
+		}
+		assert.Equal(t, 3, count(t, d, "MATCH (p:Person) WITH count(p) AS n"))
+	})
+
+	t.Run("cleans up what it created", func(t *testing.T) {
+		d, l := newLoader(t)
+		err := d.Exec().Cypher("CREATE (:Person {name: 'Lana'})-[:DIRECTED]->(:Movie {title: 'Speed Racer'})").Run(ctx)
+		require.NoError(t, err)
+		set, err := l.Load(ctx, []byte(movies))
+		require.NoError(t, err)
+
+		require.NoError(t, set.Cleanup(ctx))
+		require.NoError(t, set.Cleanup(ctx))
+		assert.Equal(t, 2, count(t, d, "MATCH (n) WITH count(n) AS n"))
+		assert.Equal(t, 1, count(t, d, "MATCH ()-[r]->() WITH count(r) AS n"))
+	})
+
+	t.Run("keeps relationships it didn't create", func(t *testing.T) {
+		d, l := newLoader(t)
+		set, err := l.Load(ctx, []byte(movies))
+		require.NoError(t, err)
+		nodes := count(t, d, "MATCH (n) WITH count(n) AS n")
+		err = d.Exec().Cypher(`MATCH (n), (m)
+WHERE n <> m
+WITH n, m LIMIT 1
+CREATE (n)-[:FOREIGN]->(m)`).Run(ctx)
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, set.Cleanup(ctx), "cannot delete fixtures")
+		assert.Equal(t, nodes, count(t, d, "MATCH (n) WITH count(n) AS n"))
+		assert.Equal(t, 1, count(t, d, "MATCH ()-[r:FOREIGN]->() WITH count(r) AS n"))
+
+		require.NoError(t, d.Exec().Cypher("MATCH ()-[r:FOREIGN]->() DELETE r").Run(ctx))
+		require.NoError(t, set.Cleanup(ctx))
+		assert.Equal(t, 0, count(t, d, "MATCH (n) WITH count(n) AS n"))
+	})
+
+	for data, want := range map[string]string{
+		"nodes: {a: {props: {}}}":                        `node "a" has no label`,
+		"nodes: {a: {label: Robot}}":                     `node "a": no node with label Robot was registered`,
+		"nodes: {a: {label: Person, prop: {}}}":          "cannot decode fixtures",
+		"relationships: [{type: KNOWS, from: a, to: b}]": `relationship 0 references unknown node "a"`,
+		"nodes: {a: {label: Person}}\nrelationships: [{key: a, type: KNOWS, from: a, to: a}]": `key of relationship "a" is already used`,
+		"nodes: {a: {label: Person}}\nrelationships: [{key: r, type: KNOWS, from: a, to: a}]": `relationship "r": no relationship with type KNOWS was registered`,
+	} {
+		t.Run(want, func(t *testing.T) {
+			_, l := newLoader(t)
+			_, err := l.Load(ctx, []byte(data))
+			assert.ErrorContains(t, err, want)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	l := New(nil, &tests.Person{}, &tests.Human{}, &tests.Dog{})
+	for label, want := range map[string]any{
+		"Person":         &tests.Person{},
+		"Organism:Human": &tests.Human{},
+		"Human":          &tests.Human{},
+	} {
+		typ, err := l.resolve(label)
+		if assert.NoError(t, err, label) {
+			assert.Equal(t, reflect.TypeOf(want), typ.typ, label)
+		}
+	}
+	_, err := l.resolve("Organism")
+	assert.ErrorContains(t, err, "label Organism is ambiguous between *tests.Human, *tests.Dog")
+	_, err = l.resolve("Robot")
+	assert.ErrorContains(t, err, "no node with label Robot was registered")
+}
@@ -0,0 +1,160 @@
+package neogo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	randv2 "math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rlch/neogo/internal"
+)
+
+type (
+	// IDGenerator generates the IDs of nodes. Drivers configured with
+	// [WithIDGenerator] use it to generate the IDs of nodes created without
+	// them. IDs are converted to the key type of nodes as by [KeyedNode.SetID].
+	//
+	// Implementations must be safe for concurrent use.
+	IDGenerator = internal.IDGenerator
+
+	// IDGeneratorFunc is an [IDGenerator] implemented by a function.
+	IDGeneratorFunc func() any
+)
+
+func (f IDGeneratorFunc) NewID() any { return f() }
+
+// NewULIDGenerator returns an [IDGenerator] of monotonic ULIDs, as strings.
+// It's the default generator of [Node.GenerateID] and [NewNode], and of
+// drivers which aren't configured with [WithIDGenerator].
+func NewULIDGenerator() IDGenerator {
+	return internal.ULIDGenerator{}
+}
+
+// NewUUIDv4Generator returns an [IDGenerator] of random UUIDs, as strings.
+func NewUUIDv4Generator() IDGenerator {
+	return IDGeneratorFunc(func() any {
+		var u [16]byte
+		if _, err := rand.Read(u[:]); err != nil {
+			panic(fmt.Errorf("cannot generate UUID: %w", err))
+		}
+		return formatUUID(u, 4)
+	})
+}
+
+// NewUUIDv7Generator returns an [IDGenerator] of time-ordered UUIDs, as
+// strings. UUIDs generated by the same generator within a millisecond are
+// ordered by a counter.
+func NewUUIDv7Generator() IDGenerator {
+	var (
+		mu       sync.Mutex
+		lastMs   int64
+		sequence uint16
+	)
+	return IDGeneratorFunc(func() any {
+		var u [16]byte
+		if _, err := rand.Read(u[6:]); err != nil {
+			panic(fmt.Errorf("cannot generate UUID: %w", err))
+		}
+		mu.Lock()
+		ms := time.Now().UnixMilli()
+		if ms <= lastMs {
+			// The 12 bit counter overflowing moves on to the next millisecond.
+			sequence = (sequence + 1) & 0x0FFF
+			if sequence == 0 {
+				lastMs++
+			}
+			ms = lastMs
+		} else {
+			sequence = uint16(u[6])<<8 | uint16(u[7])
+			// Leave room to count within the millisecond.
+			sequence &= 0x07FF
+		}
+		lastMs = ms
+		seq := sequence
+		mu.Unlock()
+
+		for i := 0; i < 6; i++ {
+			u[i] = byte(ms >> (40 - 8*i))
+		}
+		u[6], u[7] = byte(seq>>8), byte(seq)
+		return formatUUID(u, 7)
+	})
+}
+
+const (
+	// snowflakeEpoch is the epoch of Snowflake IDs, in Unix milliseconds.
+	snowflakeEpoch = 1288834974657
+	// snowflakeMaxMachine is the largest machine ID of Snowflake IDs.
+	snowflakeMaxMachine = 1<<10 - 1
+)
+
+// NewSnowflakeGenerator returns an [IDGenerator] of Snowflake IDs, as int64s,
+// which consist of a millisecond timestamp, the machine ID and a sequence
+// number. The machine ID must be between 0 and 1023, and unique to the
+// process among those generating IDs concurrently.
+func NewSnowflakeGenerator(machine int64) (IDGenerator, error) {
+	if machine < 0 || machine > snowflakeMaxMachine {
+		return nil, fmt.Errorf("snowflake machine ID must be between 0 and %d, got %d", snowflakeMaxMachine, machine)
+	}
+	var (
+		mu       sync.Mutex
+		lastMs   int64
+		sequence int64
+	)
+	return IDGeneratorFunc(func() any {
+		mu.Lock()
+		defer mu.Unlock()
+		ms := time.Now().UnixMilli() - snowflakeEpoch
+		if ms <= lastMs {
+			sequence = (sequence + 1) & 0x0FFF
+			if sequence == 0 {
+				lastMs++
+			}
+			ms = lastMs
+		} else {
+			sequence = 0
+		}
+		lastMs = ms
+		return ms<<22 | machine<<12 | sequence
+	}), nil
+}
+
+// NewSeededIDGenerator returns an [IDGenerator] of UUIDs, as strings, which
+// are generated deterministically from seed. Generators with the same seed
+// generate the same IDs in the same order, which keeps IDs stable across test
+// runs, such as in snapshots.
+func NewSeededIDGenerator(seed uint64) IDGenerator {
+	var mu sync.Mutex
+	r := randv2.New(randv2.NewPCG(seed, seed))
+	return IDGeneratorFunc(func() any {
+		mu.Lock()
+		defer mu.Unlock()
+		var u [16]byte
+		for i := 0; i < 16; i += 8 {
+			n := r.Uint64()
+			for j := 0; j < 8; j++ {
+				u[i+j] = byte(n >> (8 * j))
+			}
+		}
+		return formatUUID(u, 4)
+	})
+}
+
+// formatUUID sets the version and variant of u, and formats it.
+func formatUUID(u [16]byte, version byte) string {
+	u[6] = u[6]&0x0F | version<<4
+	u[8] = u[8]&0x3F | 0x80
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
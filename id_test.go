@@ -0,0 +1,145 @@
+package neogo
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestIDGenerators(t *testing.T) {
+	t.Run("UUIDv4", func(t *testing.T) {
+		g := NewUUIDv4Generator()
+		a, b := g.NewID().(string), g.NewID().(string)
+		assert.Equal(t, "4", uuidPattern.FindStringSubmatch(a)[1])
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("UUIDv7 are ordered", func(t *testing.T) {
+		g := NewUUIDv7Generator()
+		prev := ""
+		for i := 0; i < 5000; i++ {
+			id := g.NewID().(string)
+			require.Equal(t, "7", uuidPattern.FindStringSubmatch(id)[1])
+			require.Greater(t, id, prev)
+			prev = id
+		}
+	})
+
+	t.Run("ULID", func(t *testing.T) {
+		g := NewULIDGenerator()
+		a, b := g.NewID().(string), g.NewID().(string)
+		assert.Len(t, a, 26)
+		assert.Greater(t, b, a)
+	})
+
+	t.Run("Snowflake", func(t *testing.T) {
+		g, err := NewSnowflakeGenerator(5)
+		require.NoError(t, err)
+		prev := int64(0)
+		for i := 0; i < 5000; i++ {
+			id := g.NewID().(int64)
+			require.Greater(t, id, prev)
+			require.Equal(t, int64(5), id>>12&snowflakeMaxMachine)
+			prev = id
+		}
+		_, err = NewSnowflakeGenerator(1024)
+		assert.ErrorContains(t, err, "snowflake machine ID must be between 0 and 1023, got 1024")
+	})
+
+	t.Run("seeded generators are deterministic", func(t *testing.T) {
+		a, b := NewSeededIDGenerator(1), NewSeededIDGenerator(1)
+		first := a.NewID()
+		assert.Regexp(t, uuidPattern, first)
+		assert.Equal(t, first, b.NewID())
+		assert.Equal(t, a.NewID(), b.NewID())
+		assert.NotEqual(t, first, NewSeededIDGenerator(2).NewID())
+	})
+}
+
+func TestKeyedNode(t *testing.T) {
+	type Account struct {
+		KeyedNode[int64] `neo4j:"Account"`
+
+		Email string `json:"email"`
+	}
+
+	a := NodeWithID[Account](42)
+	assert.Equal(t, int64(42), a.ID)
+	assert.Equal(t, "42", a.GetID())
+	assert.Equal(t, []string{"Account"}, ExtractNodeLabels(a))
+	assert.Panics(t, func() { a.SetID("forty-two") })
+
+	t.Run("generates IDs with the configured generator", func(t *testing.T) {
+		a := NewNode[Account]()
+		assert.False(t, a.HasID())
+
+		m := NewMock(WithIDGenerator(IDGeneratorFunc(func() any { return int64(7) })))
+		assert.Equal(t, int64(7), NewNode[Account](m).ID)
+		assert.Panics(t, func() { a.GenerateID(NewULIDGenerator()) })
+
+		_, err := m.Exec().
+			Create(db.Node(a)).
+			Return(a).(*runnerImpl).
+			Compile()
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), a.ID)
+	})
+
+	t.Run("binds IDs", func(t *testing.T) {
+		ctx := context.Background()
+		m := NewMock()
+		m.Bind(map[string]any{"a": map[string]any{"id": int64(7), "email": "jessie@example.com"}})
+		var got Account
+		err := m.Exec().
+			Match(db.Node(db.Qual(&got, "a"))).
+			Return(&got).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), got.ID)
+		assert.True(t, got.HasID())
+	})
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	m := NewMock(WithIDGenerator(IDGeneratorFunc(func() any { return "generated" })))
+
+	t.Run("generates IDs of new nodes", func(t *testing.T) {
+		assert.Equal(t, "generated", m.NewID())
+		assert.Equal(t, "generated", NewNode[tests.Person](m).ID)
+		assert.Len(t, NewMock().NewID(), 26)
+	})
+
+	t.Run("generates IDs of created nodes", func(t *testing.T) {
+		p := tests.Person{Name: "Jessie"}
+		named := tests.Person{Node: Node{ID: "named"}}
+		q, err := m.Exec().
+			Create(db.Patterns(db.Node(&p), db.Node(&named))).
+			Return(&p).(*runnerImpl).
+			Compile()
+		require.NoError(t, err)
+		assert.Equal(t, "generated", p.ID)
+		assert.Equal(t, "named", named.ID)
+		assert.Equal(t, "generated", q.Parameters["person_id"])
+	})
+
+	t.Run("doesn't generate IDs of matched or merged nodes", func(t *testing.T) {
+		var p, q tests.Person
+		_, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p"))).
+			Merge(db.Node(db.Qual(&q, "q"))).
+			Create(db.Node(&p).To(tests.Knows{}, &q)).
+			Return(&p).(*runnerImpl).
+			Compile()
+		require.NoError(t, err)
+		assert.Empty(t, p.ID)
+		assert.Empty(t, q.ID)
+	})
+}
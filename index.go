@@ -0,0 +1,149 @@
+package neogo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rlch/neogo/internal"
+)
+
+// nodeIndex resolves nodes returned by Neo4j to registered types using their
+// labels. It's built once when types are registered, so resolving a node
+// doesn't need to reflect on the labels of every registered type.
+type nodeIndex struct {
+	// byLabels maps the sorted concrete labels of each node to its type.
+	byLabels map[string]*indexedNode
+	// byLabel maps each concrete label to the nodes with it, in the order they
+	// were registered.
+	byLabel map[string][]*indexedNode
+	// resolved caches the nodes found by lookup for label sets which aren't
+	// those of a registered node, including those for which none was found. It's
+	// shared by copies of the index, such as those of sessions' registries.
+	resolved *sync.Map // map[resolvedKey]*indexedNode
+}
+
+// resolvedKey identifies a lookup of the nodes implementing iface by the set
+// of labels with the key labels.
+type resolvedKey struct {
+	labels string
+	iface  reflect.Type
+}
+
+// indexedNode is a registered node type.
+type indexedNode struct {
+	typ reflect.Type
+	// labels are the sorted concrete labels of the node.
+	labels []string
+	// order is the order in which the node was registered.
+	order int
+	// base is true if the node was registered directly, rather than as an
+	// implementer of an abstract node.
+	base bool
+}
+
+// labelSetKey returns a key identifying the set of labels, and the labels
+// sorted.
+func labelSetKey(labels []string) (string, []string) {
+	if len(labels) == 1 {
+		return labels[0], labels
+	}
+	sorted := slices.Clone(labels)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ":"), sorted
+}
+
+// add indexes the node n, which is registered directly if base is true. It
+// errors if a different type with the same labels has already been indexed.
+func (x *nodeIndex) add(n any, base bool) error {
+	typ := reflect.TypeOf(n)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot register node of type %s: nodes must be pointers to structs", typ)
+	}
+	labels := internal.ExtractConcreteNodeLabels(n)
+	if len(labels) == 0 {
+		return nil
+	}
+	key, sorted := labelSetKey(labels)
+	if existing, ok := x.byLabels[key]; ok {
+		if existing.typ != typ {
+			return fmt.Errorf(
+				"cannot register %s: its labels %s are ambiguous with %s",
+				typ, strings.Join(labels, ", "), existing.typ,
+			)
+		}
+		existing.base = existing.base || base
+		return nil
+	}
+	if x.byLabels == nil {
+		x.byLabels = map[string]*indexedNode{}
+		x.byLabel = map[string][]*indexedNode{}
+	}
+	node := &indexedNode{typ: typ, labels: sorted, order: len(x.byLabels), base: base}
+	x.byLabels[key] = node
+	for _, label := range node.labels {
+		x.byLabel[label] = append(x.byLabel[label], node)
+	}
+	// Lookups may now resolve to the new node.
+	x.resolved = &sync.Map{}
+	return nil
+}
+
+// addAbstract indexes the abstract node abs and its implementers.
+func (x *nodeIndex) addAbstract(abs IAbstract) error {
+	if err := x.add(abs, true); err != nil {
+		return err
+	}
+	for _, impl := range abs.Implementers() {
+		if err := x.add(impl, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the indexed node implementing iface with the most labels, all
+// of which are in labels. Nodes registered first are preferred when several
+// are equally specific. Nodes found for label sets which aren't those of a
+// registered node are cached, so each set is only resolved once.
+func (x *nodeIndex) lookup(labels []string, iface reflect.Type) *indexedNode {
+	if x == nil || len(labels) == 0 {
+		return nil
+	}
+	key, _ := labelSetKey(labels)
+	if node, ok := x.byLabels[key]; ok && node.typ.Implements(iface) {
+		return node
+	}
+	if x.resolved == nil {
+		return nil
+	}
+	// The node has labels that aren't those of a registered type, such as
+	// those of a subtype which isn't registered, so we find the most specific
+	// supertype.
+	rk := resolvedKey{labels: key, iface: iface}
+	if node, ok := x.resolved.Load(rk); ok {
+		return node.(*indexedNode)
+	}
+	var best *indexedNode
+	for _, label := range labels {
+	Candidates:
+		for _, node := range x.byLabel[label] {
+			if best != nil && (len(node.labels) < len(best.labels) ||
+				len(node.labels) == len(best.labels) && node.order >= best.order) {
+				continue
+			}
+			for _, l := range node.labels {
+				if !slices.Contains(labels, l) {
+					continue Candidates
+				}
+			}
+			if node.typ.Implements(iface) {
+				best = node
+			}
+		}
+	}
+	x.resolved.Store(rk, best)
+	return best
+}
@@ -0,0 +1,127 @@
+package neogo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+type (
+	indexPerson struct {
+		Node `neo4j:"Person"`
+	}
+	indexEmployee struct {
+		Node `neo4j:"Employee"`
+	}
+)
+
+func TestNodeIndex(t *testing.T) {
+	rOrganism := reflect.TypeOf((*tests.Organism)(nil)).Elem()
+	rPet := reflect.TypeOf((*tests.Pet)(nil)).Elem()
+	rINode := reflect.TypeOf((*INode)(nil)).Elem()
+
+	x := &nodeIndex{}
+	require.NoError(t, x.addAbstract(&tests.BaseOrganism{}))
+	require.NoError(t, x.add(&tests.Person{}, true))
+	require.NoError(t, x.add(&indexEmployee{}, true))
+
+	t.Run("looks up nodes by their labels", func(t *testing.T) {
+		for _, test := range []struct {
+			labels   []string
+			iface    reflect.Type
+			expected any
+		}{
+			{[]string{"Organism"}, rOrganism, &tests.BaseOrganism{}},
+			{[]string{"Human", "Organism"}, rOrganism, &tests.Human{}},
+			{[]string{"Dog", "Pet", "Organism"}, rPet, &tests.Dog{}},
+			{[]string{"Person"}, rINode, &tests.Person{}},
+		} {
+			node := x.lookup(test.labels, test.iface)
+			require.NotNil(t, node, test.labels)
+			assert.Equal(t, reflect.TypeOf(test.expected), node.typ)
+		}
+	})
+
+	t.Run("falls back to the most specific supertype", func(t *testing.T) {
+		node := x.lookup([]string{"Organism", "Human", "Admin"}, rOrganism)
+		require.NotNil(t, node)
+		assert.Equal(t, reflect.TypeOf(&tests.Human{}), node.typ)
+		assert.False(t, node.base)
+	})
+
+	t.Run("caches fallback lookups", func(t *testing.T) {
+		y := &nodeIndex{}
+		require.NoError(t, y.addAbstract(&tests.BaseOrganism{}))
+		labels := []string{"Organism", "Human", "Admin"}
+		node := y.lookup(labels, rOrganism)
+		require.NotNil(t, node)
+		cached, ok := y.resolved.Load(resolvedKey{labels: "Admin:Human:Organism", iface: rOrganism})
+		require.True(t, ok)
+		assert.Same(t, node, cached)
+		assert.Same(t, node, y.lookup(labels, rOrganism))
+
+		assert.Nil(t, y.lookup([]string{"Person", "Admin"}, rINode))
+		_, ok = y.resolved.Load(resolvedKey{labels: "Admin:Person", iface: rINode})
+		assert.True(t, ok)
+
+		require.NoError(t, y.add(&tests.Person{}, true))
+		node = y.lookup([]string{"Person", "Admin"}, rINode)
+		require.NotNil(t, node)
+		assert.Equal(t, reflect.TypeOf(&tests.Person{}), node.typ)
+	})
+
+	t.Run("prefers nodes registered first", func(t *testing.T) {
+		node := x.lookup([]string{"Employee", "Person"}, rINode)
+		require.NotNil(t, node)
+		assert.Equal(t, reflect.TypeOf(&tests.Person{}), node.typ)
+	})
+
+	t.Run("only returns nodes implementing the interface", func(t *testing.T) {
+		assert.Nil(t, x.lookup([]string{"Human", "Organism"}, rPet))
+		assert.Nil(t, x.lookup([]string{"Cat"}, rINode))
+	})
+
+	t.Run("errors on ambiguous nodes", func(t *testing.T) {
+		err := x.add(&indexPerson{}, true)
+		assert.ErrorContains(t, err, "labels Person are ambiguous with *tests.Person")
+		assert.NoError(t, x.add(&tests.Person{}, true))
+	})
+
+	t.Run("errors on ambiguous nodes when registered", func(t *testing.T) {
+		r := &registry{}
+		err := r.registerTypes(&tests.Person{}, &indexPerson{})
+		assert.ErrorContains(t, err, "ambiguous")
+
+		_, err = New("neo4j://localhost", neo4j.NoAuth(), WithTypes(&tests.Person{}, &indexPerson{}))
+		assert.ErrorContains(t, err, "ambiguous")
+
+		m := NewMock(WithTypes(&tests.Person{}, &indexPerson{}))
+		m.Bind(nil)
+		err = m.Exec().Return(db.Qual(1, "n")).Run(context.Background())
+		assert.ErrorContains(t, err, "ambiguous")
+	})
+}
+
+func BenchmarkBindAbstractNode(b *testing.B) {
+	r := &registry{}
+	require.NoError(b, r.registerTypes(&tests.BaseOrganism{}, &tests.BasePet{}))
+	nodes := []neo4j.Node{
+		{Labels: []string{"Organism", "Human"}, Props: map[string]any{"name": "Raqeeb"}},
+		{Labels: []string{"Organism", "Pet", "Dog"}, Props: map[string]any{"borfs": true}},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var to tests.Organism
+		if err := r.bindValue(nodes[i%len(nodes)], reflect.ValueOf(&to)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
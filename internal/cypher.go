@@ -12,13 +12,20 @@ import (
 type cypher struct {
 	*Scope
 	*strings.Builder
+
+	// idGenerator generates the IDs of created nodes without them, if set.
+	idGenerator IDGenerator
+	// creating is true while writing a CREATE clause.
+	creating bool
 }
 
 type CompiledCypher struct {
 	Cypher     string
 	Parameters map[string]any
 	Bindings   map[string]reflect.Value
-	IsWrite    bool
+	// Columns are the names projected by the final RETURN clause, in order.
+	Columns []string
+	IsWrite bool
 }
 
 func newCypher() *cypher {
@@ -28,6 +35,24 @@ func newCypher() *cypher {
 	}
 }
 
+// SetIDGenerator sets the generator of the IDs of nodes created by the query
+// which don't have them.
+func (c *cypher) SetIDGenerator(g IDGenerator) {
+	c.idGenerator = g
+}
+
+// generateID generates the ID of the node identified by data, if it's being
+// created and doesn't have one.
+func (c *cypher) generateID(data any) {
+	if c.idGenerator == nil || !c.creating || c.lookupName(data) != "" {
+		return
+	}
+	identifier, _, _ := c.unfoldIdentifier(data)
+	if n, ok := identifier.(IDHolder); ok && !n.HasID() {
+		n.GenerateID(c.idGenerator)
+	}
+}
+
 func (c *cypher) Params() map[string]any {
 	return c.parameters
 }
@@ -297,13 +322,36 @@ func (cy *cypher) writeCondition(c *Condition, parseKey, parseValue func(any) st
 }
 
 func (cy *cypher) writePattern(pattern *nodePattern) {
+	cy.catch(func() {
+		related := cy.writePatternChain(pattern)
+		// Related nodes are only written when they're being created, as
+		// matching them would exclude nodes without them.
+		if !cy.creating {
+			return
+		}
+		for len(related) > 0 {
+			rel := related[0]
+			related = related[1:]
+			cy.WriteString(", ")
+			related = append(related, cy.writeRelatedPattern(rel)...)
+		}
+	})
+}
+
+// writePatternChain writes pattern, returning the patterns of the nodes
+// related to those within it.
+func (cy *cypher) writePatternChain(pattern *nodePattern) (related []*relatedPattern) {
 	cy.catch(func() {
 		if pattern.pathName != "" {
 			_, _ = fmt.Fprintf(cy, "%s = ", pattern.pathName)
 		}
 		for {
+			cy.generateID(pattern.data)
 			nodeM := cy.registerNode(pattern)
 			cy.writeNode(nodeM)
+			if nodeM != nil {
+				related = append(related, nodeM.related...)
+			}
 			rs := pattern.relationship
 			if rs == nil {
 				break
@@ -318,6 +366,20 @@ func (cy *cypher) writePattern(pattern *nodePattern) {
 			}
 		}
 	})
+	return related
+}
+
+// writeRelatedPattern writes the relationship from a node to its related node,
+// returning the patterns of the nodes related to it in turn.
+func (cy *cypher) writeRelatedPattern(rel *relatedPattern) []*relatedPattern {
+	cy.generateID(rel.node.data)
+	nodeM := cy.registerNode(rel.node)
+	_, _ = fmt.Fprintf(cy, "(%s)-[:%s]->", rel.from, rel.relationship)
+	cy.writeNode(nodeM)
+	if nodeM == nil {
+		return nil
+	}
+	return nodeM.related
 }
 
 func (cy *cypher) writeReadingClause(patterns []*nodePattern, optional bool) {
@@ -363,6 +425,9 @@ func (cy *cypher) writeUnionClause(unions []func(*CypherClient) *CypherRunner, a
 		}
 		queries[i] = comp.Cypher
 		cy.MergeChildScope(runner.Scope)
+		if i == 0 {
+			cy.columns = comp.Columns
+		}
 	}
 	cy.WriteString(strings.Join(queries, "\n"+clause+"\n"))
 }
@@ -370,6 +435,8 @@ func (cy *cypher) writeUnionClause(unions []func(*CypherClient) *CypherRunner, a
 func (cy *cypher) writeCreateClause(
 	nodes []*nodePattern,
 ) {
+	cy.creating = true
+	defer func() { cy.creating = false }()
 	cy.writeMultilineQuery("CREATE", len(nodes), func(i int) {
 		cy.writePattern(nodes[i])
 	})
@@ -385,7 +452,7 @@ func (cy *cypher) writeMergeClause(
 	}
 	cy.catch(func() {
 		cy.WriteString("MERGE ")
-		cy.writePattern(node)
+		related := cy.writePatternChain(node)
 		cy.newline()
 
 		if merge.OnCreate != nil {
@@ -400,6 +467,15 @@ func (cy *cypher) writeMergeClause(
 				cy.writeSetClause(merge.OnMatch...)
 			})
 		}
+		// MERGE accepts a single pattern, so related nodes are merged by the
+		// clauses which follow it.
+		for len(related) > 0 {
+			rel := related[0]
+			related = related[1:]
+			cy.WriteString("MERGE ")
+			related = append(related, cy.writeRelatedPattern(rel)...)
+			cy.newline()
+		}
 	})
 }
 
@@ -490,6 +566,7 @@ func (cy *cypher) writeProjectionBodyClause(clause string, parent *Scope, vars .
 		var (
 			subclause       *selectionSubClause
 			registeredNames = make(map[string]struct{}, len(vars))
+			columns         = make([]string, 0, len(vars))
 		)
 		for i, v := range vars {
 			m, allowAlias := register(v)
@@ -503,8 +580,12 @@ func (cy *cypher) writeProjectionBodyClause(clause string, parent *Scope, vars .
 					panic(errSubqueryImportAlias)
 				}
 				registeredNames[m.alias] = struct{}{}
+				columns = append(columns, m.alias)
 			} else {
 				registeredNames[m.expr] = struct{}{}
+				if m.expr != "" {
+					columns = append(columns, m.expr)
+				}
 			}
 			if m.projectionBody != nil {
 				if m.projectionBody.hasProjectionClauses() {
@@ -562,6 +643,9 @@ func (cy *cypher) writeProjectionBodyClause(clause string, parent *Scope, vars .
 			}
 		}
 		cy.newline()
+		if !isWith {
+			cy.columns = columns
+		}
 		if subclause != nil {
 			n := len(subclause.OrderBy)
 			if n > 0 {
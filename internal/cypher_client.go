@@ -236,13 +236,17 @@ func (c *CypherRunner) CompileWithParams(params map[string]any) (*CompiledCypher
 func (c *CypherRunner) Compile() (*CompiledCypher, error) {
 	out := c.String()
 	out = strings.TrimRight(out, "\n")
+	var columns []string
 	if !c.isReturn {
 		c.bindings = map[string]reflect.Value{}
+	} else {
+		columns = c.columns
 	}
 	cy := &CompiledCypher{
 		Cypher:     out,
 		Parameters: c.parameters,
 		Bindings:   c.bindings,
+		Columns:    columns,
 		IsWrite:    c.isWrite,
 	}
 	if c.err != nil {
@@ -130,4 +130,27 @@ func TestCypherClient(t *testing.T) {
 			assert.Equal(t, true, cy.isWrite)
 		})
 	})
+	t.Run("columns", func(t *testing.T) {
+		t.Run("follow the order of the RETURN clause", func(t *testing.T) {
+			var a, b any
+			cy, err := NewCypherClient().
+				With(&Variable{Identifier: &a, Expr: "x"}).
+				Return(
+					&Variable{Identifier: &b, Expr: "y", Name: "b"},
+					"z",
+					&a,
+				).
+				Compile()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"b", "z", "x"}, cy.Columns)
+		})
+
+		t.Run("are empty without a RETURN clause", func(t *testing.T) {
+			cy, err := NewCypherClient().
+				Cypher("MATCH (n)").
+				Compile()
+			assert.NoError(t, err)
+			assert.Empty(t, cy.Columns)
+		})
+	})
 }
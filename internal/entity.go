@@ -1,22 +1,9 @@
 package internal
 
-import (
-	"io"
-
-	"github.com/oklog/ulid/v2"
-)
-
-var defaultEntropySource io.Reader
-
-func init() {
-	// Seed the default entropy source.
-	defaultEntropySource = ulid.DefaultEntropy()
-}
-
 var (
 	_ interface {
 		INode
-		IDSetter
+		IDHolder
 	} = (*Node)(nil)
 	_ IRelationship = (*Relationship)(nil)
 )
@@ -28,7 +15,16 @@ type INode interface {
 
 type IDSetter interface {
 	SetID(id any)
-	GenerateID()
+	// GenerateID sets the ID to one generated by the first of generators, or
+	// by [DefaultIDGenerator] if there are none.
+	GenerateID(generators ...IDGenerator)
+}
+
+// IDHolder is implemented by nodes which report whether their ID has been
+// set, so that one can be generated for them when they're created.
+type IDHolder interface {
+	IDSetter
+	HasID() bool
 }
 
 type Node struct {
@@ -39,14 +35,28 @@ func (Node) IsNode() {}
 
 func (n Node) GetID() string { return n.ID }
 
+func (n Node) HasID() bool { return n.ID != "" }
+
+// SetID sets the ID of the node to id, converted to a string as by
+// [ConvertID]. It panics if id can't be converted.
 func (n *Node) SetID(id any) {
-	if s, ok := id.(string); ok {
-		n.ID = s
-	}
+	n.ID = MustConvertID[string](id)
 }
 
-func (n *Node) GenerateID() {
-	n.ID = ulid.MustNew(ulid.Now(), defaultEntropySource).String()
+// GenerateID sets the ID of the node to one generated by the first of
+// generators, or by [DefaultIDGenerator] if there are none. It panics if the
+// ID can't be converted to a string.
+func (n *Node) GenerateID(generators ...IDGenerator) {
+	n.SetID(generatorOf(generators).NewID())
+}
+
+// generatorOf returns the first of generators, or [DefaultIDGenerator] if
+// there are none.
+func generatorOf(generators []IDGenerator) IDGenerator {
+	if len(generators) > 0 && generators[0] != nil {
+		return generators[0]
+	}
+	return DefaultIDGenerator
 }
 
 type IAbstract interface {
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// IDGenerator generates the IDs of nodes.
+type IDGenerator interface {
+	NewID() any
+}
+
+// ULIDGenerator generates monotonic ULIDs as strings, using the default
+// entropy source of [ulid], which is safe for concurrent use.
+type ULIDGenerator struct{}
+
+func (ULIDGenerator) NewID() any { return ulid.Make().String() }
+
+// DefaultIDGenerator generates the IDs of [IDSetter.GenerateID] when no
+// generator is given.
+var DefaultIDGenerator IDGenerator = ULIDGenerator{}
+
+// ConvertID converts id to a key of type K. Numbers are converted if they fit
+// in K, numbers and strings are converted to and from one another, and
+// strings are unmarshalled into keys implementing [encoding.TextUnmarshaler].
+// Keys of string types also accept [encoding.TextMarshaler] and
+// [fmt.Stringer] values, such as UUIDs.
+func ConvertID[K comparable](id any) (K, error) {
+	var key K
+	if k, ok := id.(K); ok {
+		return k, nil
+	}
+	if id == nil {
+		return key, fmt.Errorf("cannot use nil as an ID of type %T", key)
+	}
+	fail := func(err error) (K, error) {
+		if err != nil {
+			return key, fmt.Errorf("cannot use %v (%T) as an ID of type %T: %w", id, id, key, err)
+		}
+		return key, fmt.Errorf("cannot use %v (%T) as an ID of type %T", id, id, key)
+	}
+	if u, ok := any(&key).(encoding.TextUnmarshaler); ok {
+		if s, ok := id.(string); ok {
+			if err := u.UnmarshalText([]byte(s)); err != nil {
+				return fail(err)
+			}
+			return key, nil
+		}
+	}
+
+	to := reflect.ValueOf(&key).Elem()
+	from := reflect.ValueOf(id)
+	switch {
+	case to.Kind() == reflect.String:
+		switch id := id.(type) {
+		case encoding.TextMarshaler:
+			text, err := id.MarshalText()
+			if err != nil {
+				return fail(err)
+			}
+			to.SetString(string(text))
+		case fmt.Stringer:
+			to.SetString(id.String())
+		default:
+			if from.Kind() == reflect.String {
+				to.SetString(from.String())
+			} else if isInt(from.Kind()) || isUint(from.Kind()) {
+				to.SetString(fmt.Sprint(id))
+			} else {
+				return fail(nil)
+			}
+		}
+	case isInt(to.Kind()) || isUint(to.Kind()) || isFloat(to.Kind()):
+		if from.Kind() == reflect.String {
+			var (
+				parsed any
+				err    error
+			)
+			switch {
+			case isInt(to.Kind()):
+				parsed, err = strconv.ParseInt(from.String(), 10, 64)
+			case isUint(to.Kind()):
+				parsed, err = strconv.ParseUint(from.String(), 10, 64)
+			default:
+				parsed, err = strconv.ParseFloat(from.String(), 64)
+			}
+			if err != nil {
+				return fail(err)
+			}
+			from = reflect.ValueOf(parsed)
+		}
+		if !isInt(from.Kind()) && !isUint(from.Kind()) && !isFloat(from.Kind()) {
+			return fail(nil)
+		}
+		converted := from.Convert(to.Type())
+		// Reject conversions which lose the value, such as overflows.
+		if converted.Convert(from.Type()).Interface() != from.Interface() ||
+			(from.CanInt() && from.Int() < 0) != (converted.CanInt() && converted.Int() < 0) {
+			return fail(nil)
+		}
+		to.Set(converted)
+	case from.Type().ConvertibleTo(to.Type()):
+		if from.Kind() == reflect.Slice && to.Kind() == reflect.Array && from.Len() != to.Len() {
+			return fail(nil)
+		}
+		to.Set(from.Convert(to.Type()))
+	default:
+		return fail(nil)
+	}
+	return key, nil
+}
+
+// MustConvertID is like [ConvertID], but panics if id can't be converted.
+func MustConvertID[K comparable](id any) K {
+	key, err := ConvertID[K](id)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// FormatID formats key as a string, for [INode.GetID].
+func FormatID[K comparable](key K) string {
+	switch k := any(key).(type) {
+	case string:
+		return k
+	case encoding.TextMarshaler:
+		if text, err := k.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(key)
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
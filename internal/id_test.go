@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"math"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountID int32
+
+func TestConvertID(t *testing.T) {
+	t.Run("converts IDs", func(t *testing.T) {
+		convert := func(t *testing.T, want, got any, err error) {
+			t.Helper()
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+		s, err := ConvertID[string]("id")
+		convert(t, "id", s, err)
+		s, err = ConvertID[string](int64(42))
+		convert(t, "42", s, err)
+		s, err = ConvertID[string](netip.MustParseAddr("127.0.0.1"))
+		convert(t, "127.0.0.1", s, err)
+		i, err := ConvertID[int64](42)
+		convert(t, int64(42), i, err)
+		i, err = ConvertID[int64]("-42")
+		convert(t, int64(-42), i, err)
+		i, err = ConvertID[int64](42.0)
+		convert(t, int64(42), i, err)
+		a, err := ConvertID[accountID](int64(7))
+		convert(t, accountID(7), a, err)
+		u, err := ConvertID[uint8]("255")
+		convert(t, uint8(255), u, err)
+		addr, err := ConvertID[netip.Addr]("::1")
+		convert(t, netip.IPv6Loopback(), addr, err)
+		arr, err := ConvertID[[2]byte]([]byte{1, 2})
+		convert(t, [2]byte{1, 2}, arr, err)
+	})
+
+	for name, convert := range map[string]func() error{
+		"nil":           func() error { _, err := ConvertID[string](nil); return err },
+		"float":         func() error { _, err := ConvertID[string](1.5); return err },
+		"fraction":      func() error { _, err := ConvertID[int](1.5); return err },
+		"overflow":      func() error { _, err := ConvertID[int8](300); return err },
+		"uint overflow": func() error { _, err := ConvertID[int64](uint64(math.MaxUint64)); return err },
+		"negative":      func() error { _, err := ConvertID[uint](-1); return err },
+		"not a number":  func() error { _, err := ConvertID[int]("one"); return err },
+		"invalid text":  func() error { _, err := ConvertID[netip.Addr]("localhost"); return err },
+		"bool":          func() error { _, err := ConvertID[int](true); return err },
+		"length":        func() error { _, err := ConvertID[[2]byte]([]byte{1}); return err },
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorContains(t, convert(), "as an ID of type")
+		})
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	var n Node
+	assert.False(t, n.HasID())
+	n.SetID(42)
+	assert.Equal(t, "42", n.ID)
+	assert.True(t, n.HasID())
+	assert.Panics(t, func() { n.SetID(struct{}{}) })
+	n.GenerateID()
+	assert.Len(t, n.ID, 26)
+	n.GenerateID(idGeneratorFunc(func() any { return 7 }))
+	assert.Equal(t, "7", n.ID)
+}
+
+type idGeneratorFunc func() any
+
+func (f idGeneratorFunc) NewID() any { return f() }
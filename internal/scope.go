@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/goccy/go-json"
 	"github.com/iancoleman/strcase"
 )
 
@@ -36,6 +37,9 @@ type (
 
 		parameters map[string]any
 		paramAddrs map[uintptr]string
+
+		// Names projected by the most recent RETURN clause.
+		columns []string
 	}
 	// An instance of a node/relationship in the cypher query
 	member struct {
@@ -57,6 +61,15 @@ type (
 
 		// The projection body that this member is associated with.
 		projectionBody *ProjectionBody
+
+		// related are the nodes stored by fields of the member using
+		// [StoreNode], which are written as patterns alongside it.
+		related []*relatedPattern
+	}
+	relatedPattern struct {
+		from         string
+		relationship string
+		node         *nodePattern
 	}
 	field struct {
 		name       string
@@ -145,6 +158,7 @@ func (s *Scope) clear() {
 	s.fields = map[uintptr]field{}
 	s.parameters = map[string]any{}
 	s.paramAddrs = map[uintptr]string{}
+	s.columns = nil
 }
 
 func (s *Scope) MergeChildScope(child *Scope) {
@@ -274,16 +288,52 @@ func (s *Scope) replaceBinding(m *member) {
 }
 
 func (s *Scope) bindFields(strct reflect.Value, memberName string) {
+	s.bindPrefixedFields(strct, memberName, "")
+}
+
+// bindPrefixedFields binds the fields of strct, prefixing the names of their
+// properties with prefix.
+func (s *Scope) bindPrefixedFields(strct reflect.Value, memberName, prefix string) {
 	vsT := strct.Type()
 	for i := 0; i < vsT.NumField(); i++ {
 		vf := strct.Field(i)
 		vfT := vsT.Field(i)
 
-		accessor, ok := extractJSONFieldName(vsT.Field(i))
-		if !ok {
+		tag, ok := ExtractPropertyTag(vfT)
+		if !ok || tag.Inline {
 			// Recurse into composite fields
-			if vfT.Anonymous {
-				s.bindFields(vf, memberName)
+			if vfT.Anonymous || tag.Inline {
+				for vf.Kind() == reflect.Ptr && !vf.IsNil() {
+					vf = vf.Elem()
+				}
+				if vf.Kind() == reflect.Struct {
+					s.bindPrefixedFields(vf, memberName, prefix)
+				}
+			}
+			continue
+		}
+		if tag.Skip || tag.Strategy == StoreNode {
+			continue
+		}
+		if tag.Metadata != "" {
+			// Metadata isn't a property, so it's accessed using the function
+			// which returns it.
+			if prefix == "" {
+				s.names[vf.Addr()] = metadataExpr(tag.Metadata, memberName)
+			}
+			continue
+		}
+		accessor := tag.Name
+		if accessor == "" {
+			accessor = vfT.Name
+		}
+		accessor = prefix + accessor
+		if tag.Strategy == StoreFlattened {
+			for vf.Kind() == reflect.Ptr && !vf.IsNil() {
+				vf = vf.Elem()
+			}
+			if vf.Kind() == reflect.Struct {
+				s.bindPrefixedFields(vf, memberName, accessor+"_")
 			}
 			continue
 		}
@@ -300,6 +350,22 @@ func (s *Scope) bindFields(strct reflect.Value, memberName string) {
 	}
 }
 
+func metadataExpr(m ElementMetadata, identifier string) string {
+	switch m {
+	case MetadataElementID:
+		return "elementId(" + identifier + ")"
+	case MetadataStartElementID:
+		return "elementId(startNode(" + identifier + "))"
+	case MetadataEndElementID:
+		return "elementId(endNode(" + identifier + "))"
+	case MetadataLabels:
+		return "labels(" + identifier + ")"
+	case MetadataType:
+		return "type(" + identifier + ")"
+	}
+	panic(fmt.Errorf("unknown element metadata %q", m))
+}
+
 func (s *Scope) lookup(value any) *member {
 	return s.register(value, true, nil)
 }
@@ -477,8 +543,8 @@ func (s *Scope) register(value any, lookup bool, isNode *bool) *member {
 			// qualified parameters. This allows props to be used in MATCH and MERGE
 			// clause for instance, where a property expression is not allowed.
 			props := make(Props)
-			var bindFieldsFrom func(reflect.Value)
-			bindFieldsFrom = func(value reflect.Value) {
+			var bindFieldsFrom func(reflect.Value, string)
+			bindFieldsFrom = func(value reflect.Value, prefix string) {
 				for value.Kind() == reflect.Ptr {
 					value = value.Elem()
 				}
@@ -489,26 +555,67 @@ func (s *Scope) register(value any, lookup bool, isNode *bool) *member {
 						continue
 					}
 					fT := innerT.Field(i)
-					name, ok := extractJSONFieldName(fT)
-					if !ok {
-						if fT.Anonymous {
-							bindFieldsFrom(f)
+					tag, ok := ExtractPropertyTag(fT)
+					if !ok || tag.Inline {
+						if fT.Anonymous || tag.Inline {
+							bindFieldsFrom(f, prefix)
 						}
 						continue
 					}
+					if tag.Skip || tag.Metadata != "" {
+						continue
+					}
+					name := tag.Name
+					if name == "" {
+						name = fT.Name
+					}
+					name = prefix + name
 					propName := name
 					if m.expr != "" {
 						propName = m.expr + "_" + name
 					}
 
-					prop := f.Interface()
+					var prop any
+					switch tag.Strategy {
+					case StoreFlattened:
+						for f.Kind() == reflect.Ptr {
+							f = f.Elem()
+						}
+						if f.Kind() == reflect.Map {
+							iter := f.MapRange()
+							for iter.Next() {
+								prop := iter.Value().Interface()
+								key := name + "_" + iter.Key().String()
+								props[key] = Param{
+									Name:  propName + "_" + iter.Key().String(),
+									Value: &prop,
+								}
+							}
+						} else {
+							bindFieldsFrom(f, name+"_")
+						}
+						continue
+					case StoreJSON:
+						bytes, err := json.Marshal(f.Interface())
+						if err != nil {
+							panic(fmt.Errorf("cannot encode %s as JSON: %w", fT.Name, err))
+						}
+						prop = string(bytes)
+					case StoreNode:
+						if isNode != nil && *isNode {
+							m.related = append(m.related, relatedNode(m.expr, name, tag.Relationship, f))
+						}
+						continue
+					default:
+						prop = f.Interface()
+					}
 					props[name] = Param{
 						Name:  propName,
 						Value: &prop,
 					}
 				}
 			}
-			bindFieldsFrom(inner)
+			bindFieldsFrom(inner, "")
 			if len(props) > 0 {
 				if m.variable == nil {
 					m.variable = &Variable{}
@@ -522,6 +629,33 @@ func (s *Scope) register(value any, lookup bool, isNode *bool) *member {
 	return m
 }
 
+// relatedNode returns the related node stored by the field f of a node named
+// from.
+func relatedNode(from, name, relationship string, f reflect.Value) *relatedPattern {
+	if from == "" {
+		panic(fmt.Errorf("cannot relate %s to a node without a name", name))
+	}
+	var identifier any
+	switch {
+	case f.Kind() == reflect.Ptr:
+		identifier = f.Interface()
+	case f.CanAddr():
+		identifier = f.Addr().Interface()
+	default:
+		ptr := reflect.New(f.Type())
+		ptr.Elem().Set(f)
+		identifier = ptr.Interface()
+	}
+	return &relatedPattern{
+		from:         from,
+		relationship: relationship,
+		node: &nodePattern{data: &Variable{
+			Identifier: identifier,
+			Name:       from + "_" + name,
+		}},
+	}
+}
+
 func (s *Scope) registerNode(n *nodePattern) *member {
 	t := true
 	return s.register(n.data, false, &t)
@@ -33,3 +33,28 @@ func TestBindFields(t *testing.T) {
 		}, s.names)
 	})
 }
+
+type account struct {
+	Node `neo4j:"Account"`
+
+	Email    string   `json:"email" neo4j:"emailAddress"`
+	Password string   `json:"-" neo4j:"password"`
+	Session  string   `json:"session" neo4j:"-"`
+	Profile  *profile `json:"profile" neo4j:",inline"`
+}
+
+type profile struct {
+	Bio string `json:"bio"`
+}
+
+func TestBindFieldsWithPropertyTags(t *testing.T) {
+	s := newScope()
+	a := &account{Profile: &profile{}}
+	s.bindFields(reflect.ValueOf(a).Elem(), "a")
+	require.Equal(t, map[reflect.Value]string{
+		reflect.ValueOf(&a.ID):          "a.id",
+		reflect.ValueOf(&a.Email):       "a.emailAddress",
+		reflect.ValueOf(&a.Password):    "a.password",
+		reflect.ValueOf(&a.Profile.Bio): "a.bio",
+	}, s.names)
+}
@@ -140,10 +140,172 @@ func extractNeo4JName(instance any, fields ...string) ([]neo4jName, error) {
 	return tags, nil
 }
 
-func extractJSONFieldName(field reflect.StructField) (string, bool) {
-	jsTag, ok := field.Tag.Lookup("json")
-	if !ok {
-		return "", false
+// PropertyTag describes how a struct field maps to a property.
+type PropertyTag struct {
+	// Name is the name of the property, or empty if the tag doesn't name it.
+	Name string
+	// Skip is true if the field isn't a property.
+	Skip bool
+	// OmitEmpty is true if the property is omitted when the field is empty.
+	OmitEmpty bool
+	// Inline is true if the properties of the field's struct are promoted to
+	// the parent, as though it were embedded. Only fields of struct or pointer
+	// to struct types can be inlined.
+	Inline bool
+	// Quoted is true if the field uses the ",string" json option.
+	Quoted bool
+	// Metadata is the element metadata the field receives, if any, in which
+	// case it isn't a property.
+	Metadata ElementMetadata
+	// Strategy determines how the field's value is stored. Only fields of
+	// struct, pointer to struct or map types can use a strategy other than
+	// [StoreProperty].
+	Strategy PropertyStrategy
+	// Relationship is the type of the relationship to the related node the
+	// field is stored as when using [StoreNode].
+	Relationship string
+}
+
+// PropertyStrategy determines how a field holding a nested struct or map is
+// stored, as Neo4j properties cannot hold maps.
+type PropertyStrategy int
+
+const (
+	// StoreProperty stores the field as a single property.
+	StoreProperty PropertyStrategy = iota
+	// StoreFlattened stores each property of the field as a property of its
+	// parent, prefixed with the field's name. The ",flatten" option stores an
+	// address field as address_city, address_postcode and so on.
+	StoreFlattened
+	// StoreJSON stores the field as a string property holding its JSON
+	// encoding. It's enabled by the ",json" option.
+	StoreJSON
+	// StoreNode stores the field as a related node, rather than a property of
+	// its parent. The ",node=TYPE" option relates it to its parent with a
+	// relationship of type TYPE. The field must be a node.
+	StoreNode
+)
+
+// ElementMetadata is metadata of a node or relationship that isn't stored as
+// a property, received by fields with the corresponding neo4j tag option.
+type ElementMetadata string
+
+const (
+	// MetadataElementID receives the element ID of a node or relationship.
+	MetadataElementID ElementMetadata = "elementId"
+	// MetadataStartElementID receives the element ID of the start node of a
+	// relationship.
+	MetadataStartElementID ElementMetadata = "startElementId"
+	// MetadataEndElementID receives the element ID of the end node of a
+	// relationship.
+	MetadataEndElementID ElementMetadata = "endElementId"
+	// MetadataLabels receives the labels of a node.
+	MetadataLabels ElementMetadata = "labels"
+	// MetadataType receives the type of a relationship.
+	MetadataType ElementMetadata = "type"
+)
+
+var elementMetadata = []ElementMetadata{
+	MetadataElementID,
+	MetadataStartElementID,
+	MetadataEndElementID,
+	MetadataLabels,
+	MetadataType,
+}
+
+// ExtractPropertyTag returns the property tag of field. The neo4j tag takes
+// precedence, falling back to the json tag. As the neo4j tag of an embedded
+// field names labels, only its json tag is considered. ok is false if the field
+// has neither tag.
+//
+//	type Person struct {
+//		neogo.Node `neo4j:"Person"`
+//
+//		Name     string `json:"name"`
+//		Password string `json:"-" neo4j:"password"`
+//		Internal string `json:"internal" neo4j:"-"`
+//		Address  Address `neo4j:",inline"`
+//		Element  string  `neo4j:",elementId"`
+//		Home     Address  `neo4j:"home,flatten"`
+//		Settings Settings `neo4j:"settings,json"`
+//		Employer *Company `neo4j:"employer,node=WORKS_AT"`
+//	}
+func ExtractPropertyTag(field reflect.StructField) (tag PropertyTag, ok bool) {
+	jsonTag, hasJSON := field.Tag.Lookup("json")
+	propTag, hasNeo4j := field.Tag.Lookup(neo4jTag)
+	if !hasNeo4j || field.Anonymous {
+		if !hasJSON {
+			return PropertyTag{}, false
+		}
+		if jsonTag == "-" {
+			return PropertyTag{Skip: true}, true
+		}
+		name, opts := parseTag(jsonTag)
+		tag.Name = name
+		tag.OmitEmpty = opts["omitempty"]
+		tag.Quoted = opts["string"]
+		return tag, true
+	}
+	if propTag == "-" {
+		return PropertyTag{Skip: true}, true
+	}
+	name, opts := parseTag(propTag)
+	tag.Name = name
+	tag.OmitEmpty = opts["omitempty"]
+	if opts["inline"] {
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		// Only structs can be inlined.
+		tag.Inline = ft.Kind() == reflect.Struct
+	}
+	for _, m := range elementMetadata {
+		if opts[string(m)] {
+			tag.Metadata = m
+		}
+	}
+	tag.Strategy, tag.Relationship = extractStrategy(field.Type, opts)
+	if tag.Name == "" && !tag.Inline && hasJSON && jsonTag != "-" {
+		tag.Name, _ = parseTag(jsonTag)
+	}
+	return tag, true
+}
+
+// extractStrategy returns the storage strategy of a field of type ft from the
+// options of its tag. Strategies are ignored for fields that cannot use them.
+func extractStrategy(ft reflect.Type, opts map[string]bool) (PropertyStrategy, string) {
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	isStruct := ft.Kind() == reflect.Struct
+	switch {
+	case opts["flatten"]:
+		if isStruct || (ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String) {
+			return StoreFlattened, ""
+		}
+	case opts["json"]:
+		return StoreJSON, ""
+	}
+	for opt := range opts {
+		if rel, ok := strings.CutPrefix(opt, "node="); ok && rel != "" &&
+			isStruct && reflect.PointerTo(ft).Implements(nodeType) {
+			return StoreNode, rel
+		}
+	}
+	return StoreProperty, ""
+}
+
+func parseTag(tag string) (string, map[string]bool) {
+	name, rest, _ := strings.Cut(tag, ",")
+	if rest == "" {
+		return name, nil
+	}
+	opts := map[string]bool{}
+	for rest != "" {
+		var opt string
+		opt, rest, _ = strings.Cut(rest, ",")
+		opts[opt] = true
 	}
-	return strings.Split(jsTag, ",")[0], true
+	return name, opts
 }
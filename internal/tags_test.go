@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -129,3 +130,60 @@ func TestExtractRelationshipType(t *testing.T) {
 		assert.Equal(t, "Friendship", ExtractRelationshipType(&[]*friendship{}))
 	})
 }
+
+func TestExtractPropertyTag(t *testing.T) {
+	type address struct{}
+	type company struct {
+		Node `neo4j:"Company"`
+	}
+	type props struct {
+		Node `neo4j:"Props" json:"node"`
+
+		JSON         string            `json:"json,omitempty"`
+		Neo4j        string            `json:"other" neo4j:"neo4j"`
+		HiddenJSON   string            `json:"-" neo4j:"hidden"`
+		HiddenNeo4j  string            `json:"hidden" neo4j:"-"`
+		FallbackName string            `json:"fallback" neo4j:",omitempty"`
+		Quoted       int               `json:"quoted,string"`
+		Inline       *address          `neo4j:",inline"`
+		NotInlinable string            `neo4j:"notInlinable,inline"`
+		Flattened    address           `neo4j:"flattened,flatten"`
+		FlatMap      map[string]string `neo4j:"flatMap,flatten"`
+		NotFlattened string            `neo4j:"notFlattened,flatten"`
+		Encoded      []address         `neo4j:"encoded,json"`
+		Related      *company          `neo4j:"related,node=WORKS_AT"`
+		NotRelated   *address          `neo4j:"notRelated,node=LIVES_AT"`
+		Untagged     string
+	}
+	typ := reflect.TypeOf(props{})
+	for field, expected := range map[string]PropertyTag{
+		"Node":         {Name: "node"},
+		"JSON":         {Name: "json", OmitEmpty: true},
+		"Neo4j":        {Name: "neo4j"},
+		"HiddenJSON":   {Name: "hidden"},
+		"HiddenNeo4j":  {Skip: true},
+		"FallbackName": {Name: "fallback", OmitEmpty: true},
+		"Quoted":       {Name: "quoted", Quoted: true},
+		"Inline":       {Inline: true},
+		"NotInlinable": {Name: "notInlinable"},
+		"Flattened":    {Name: "flattened", Strategy: StoreFlattened},
+		"FlatMap":      {Name: "flatMap", Strategy: StoreFlattened},
+		"NotFlattened": {Name: "notFlattened"},
+		"Encoded":      {Name: "encoded", Strategy: StoreJSON},
+		"Related":      {Name: "related", Strategy: StoreNode, Relationship: "WORKS_AT"},
+		"NotRelated":   {Name: "notRelated"},
+	} {
+		t.Run(field, func(t *testing.T) {
+			f, _ := typ.FieldByName(field)
+			tag, ok := ExtractPropertyTag(f)
+			assert.True(t, ok)
+			assert.Equal(t, expected, tag)
+		})
+	}
+
+	t.Run("Untagged", func(t *testing.T) {
+		f, _ := typ.FieldByName("Untagged")
+		_, ok := ExtractPropertyTag(f)
+		assert.False(t, ok)
+	})
+}
@@ -243,6 +243,100 @@ func TestCreate(t *testing.T) {
 			})
 		})
 
+		t.Run("Create node with neo4j property tags", func(t *testing.T) {
+			c := internal.NewCypherClient()
+			type Address struct {
+				City string `json:"city"`
+			}
+			type Account struct {
+				internal.Node `neo4j:"Account"`
+
+				Email    string   `json:"email" neo4j:"emailAddress"`
+				Password string   `json:"-" neo4j:"password"`
+				Session  string   `json:"session" neo4j:"-"`
+				Address  *Address `json:"address" neo4j:",inline"`
+			}
+			n := Account{
+				Email:    "andy@example.com",
+				Password: "hunter2",
+				Session:  "s",
+				Address:  &Address{City: "Stockholm"},
+			}
+			cy, err := c.
+				Create(db.Node(db.Qual(&n, "n"))).
+				Return(&n.Email).
+				Compile()
+
+			Check(t, cy, err, internal.CompiledCypher{
+				Cypher: `
+					CREATE (n:Account {city: $n_city, emailAddress: $n_emailAddress, password: $n_password})
+					RETURN n.emailAddress
+					`,
+				Parameters: map[string]any{
+					"n_emailAddress": n.Email,
+					"n_password":     n.Password,
+					"n_city":         n.Address.City,
+				},
+				Bindings: map[string]reflect.Value{
+					"n.emailAddress": reflect.ValueOf(&n.Email),
+				},
+			})
+		})
+
+		t.Run("Create node with nested property strategies", func(t *testing.T) {
+			c := internal.NewCypherClient()
+			type Address struct {
+				City     string `json:"city"`
+				Postcode string `json:"postcode"`
+			}
+			type Settings struct {
+				Theme string `json:"theme"`
+			}
+			type Company struct {
+				internal.Node `neo4j:"Company"`
+
+				Name    string  `json:"name"`
+				Address Address `neo4j:"address,flatten"`
+			}
+			type Employee struct {
+				internal.Node `neo4j:"Employee"`
+
+				Address  Address           `neo4j:"address,flatten"`
+				Labels   map[string]string `neo4j:"labels,flatten"`
+				Settings Settings          `neo4j:"settings,json"`
+				Employer *Company          `neo4j:"employer,node=WORKS_AT"`
+			}
+			n := Employee{
+				Address:  Address{City: "Stockholm", Postcode: "111 22"},
+				Labels:   map[string]string{"team": "graph"},
+				Settings: Settings{Theme: "dark"},
+				Employer: &Company{Name: "Neo4j", Address: Address{City: "Malmö"}},
+			}
+			cy, err := c.
+				Create(db.Node(db.Qual(&n, "n"))).
+				Return(&n.Address.City, n.Employer).
+				Compile()
+
+			Check(t, cy, err, internal.CompiledCypher{
+				Cypher: `
+					CREATE (n:Employee {address_city: $n_address_city, address_postcode: $n_address_postcode, labels_team: $n_labels_team, settings: $n_settings}), (n)-[:WORKS_AT]->(n_employer:Company {address_city: $n_employer_address_city, name: $n_employer_name})
+					RETURN n.address_city, n_employer
+					`,
+				Parameters: map[string]any{
+					"n_address_city":          "Stockholm",
+					"n_address_postcode":      "111 22",
+					"n_labels_team":           "graph",
+					"n_settings":              `{"theme":"dark"}`,
+					"n_employer_name":         "Neo4j",
+					"n_employer_address_city": "Malmö",
+				},
+				Bindings: map[string]reflect.Value{
+					"n.address_city": reflect.ValueOf(&n.Address.City),
+					"n_employer":     reflect.ValueOf(n.Employer),
+				},
+			})
+		})
+
 		t.Run("Create multiple nodes with a parameter for their properties", func(t *testing.T) {
 			c := internal.NewCypherClient()
 			people := []Person{
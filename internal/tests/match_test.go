@@ -452,4 +452,41 @@ func TestMatch(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("Nodes with related nodes", func(t *testing.T) {
+		type Company struct {
+			internal.Node `neo4j:"Company"`
+
+			Name string `json:"name"`
+		}
+		type Employee struct {
+			internal.Node `neo4j:"Employee"`
+
+			Name     string   `json:"name"`
+			Employer *Company `neo4j:"employer,node=WORKS_AT"`
+		}
+		n := Employee{Name: "Andy", Employer: &Company{Name: "Neo4j"}}
+		o := Employee{Employer: &Company{Name: "Neo4j"}}
+		c := internal.NewCypherClient()
+		cy, err := c.
+			Match(db.Node(db.Qual(&n, "n"))).
+			OptionalMatch(db.Node(db.Qual(&o, "o")).To(Knows{}, &n)).
+			Return(&n.Name, &o.Name).
+			Compile()
+
+		Check(t, cy, err, internal.CompiledCypher{
+			Cypher: `
+				MATCH (n:Employee {name: $n_name})
+				OPTIONAL MATCH (o:Employee)-[:KNOWS]->(n)
+				RETURN n.name, o.name
+				`,
+			Parameters: map[string]any{
+				"n_name": "Andy",
+			},
+			Bindings: map[string]reflect.Value{
+				"n.name": reflect.ValueOf(&n.Name),
+				"o.name": reflect.ValueOf(&o.Name),
+			},
+		})
+	})
 }
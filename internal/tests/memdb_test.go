@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal"
+	"github.com/rlch/neogo/memdb"
+)
+
+// TestMemdb runs the examples in this package end-to-end against memdb,
+// checking the results they bind rather than the Cypher they compile to.
+func TestMemdb(t *testing.T) {
+	ctx := context.Background()
+	newDriver := func(t *testing.T) neogo.Driver {
+		t.Helper()
+		d, err := neogo.NewWithDriver(memdb.New())
+		require.NoError(t, err)
+		err = d.Exec().Cypher(`
+			CREATE (oliver:Person {name: 'Oliver Stone', age: 77})
+			CREATE (michael:Person {name: 'Michael Douglas', age: 79})
+			CREATE (charlie:Person {name: 'Charlie Sheen', age: 58})
+			CREATE (martin:Person {name: 'Martin Sheen', age: 83})
+			CREATE (rob:Person {name: 'Rob Reiner', age: 29})
+			CREATE (andy:Person:Swedish {name: 'Andy', age: 36})
+			CREATE (peter:Person:German {name: 'Peter', age: 35})
+			CREATE (wallStreet:Movie {title: 'Wall Street', released: 1987})
+			CREATE (president:Movie {title: 'The American President', released: 1995})
+			CREATE (charlie)-[:ACTED_IN {role: 'Bud Fox'}]->(wallStreet)
+			CREATE (martin)-[:ACTED_IN {role: 'Carl Fox'}]->(wallStreet)
+			CREATE (michael)-[:ACTED_IN {role: 'Gordon Gekko'}]->(wallStreet)
+			CREATE (martin)-[:ACTED_IN {role: 'A.J. MacInerney'}]->(president)
+			CREATE (michael)-[:ACTED_IN {role: 'President Andrew Shepherd'}]->(president)
+			CREATE (oliver)-[:DIRECTED]->(wallStreet)
+			CREATE (rob)-[:DIRECTED]->(president)
+			CREATE (andy)-[:KNOWS {since: 2012}]->(peter)
+			`).Run(ctx)
+		require.NoError(t, err)
+		return d
+	}
+
+	t.Run("Outgoing relationships", func(t *testing.T) {
+		var m []string
+		err := newDriver(t).Exec().
+			Match(
+				db.Node(db.Var(
+					Person{},
+					db.Props{
+						"name": "'Oliver Stone'",
+					},
+				)).
+					To(nil, db.Var("movie"))).
+			Return(db.Qual(
+				&m,
+				"movie.title",
+			)).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Wall Street"}, m)
+	})
+
+	t.Run("Match on relationship type", func(t *testing.T) {
+		var names []string
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(
+				Movie{},
+				"wallstreet",
+				db.Props{
+					"title": "'Wall Street'",
+				},
+			)).From(ActedIn{}, db.Var("actor"))).
+			Return(db.Qual(&names, "actor.name")).Run(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Charlie Sheen", "Martin Sheen", "Michael Douglas"}, names)
+	})
+
+	t.Run("Match on multiple relationship types", func(t *testing.T) {
+		var names []string
+		err := newDriver(t).Exec().
+			Match(db.Node(
+				db.Var(
+					"wallstreet",
+					db.Props{"title": "'Wall Street'"},
+				),
+			).From(
+				db.Var("", db.Label("ACTED_IN|DIRECTED")),
+				db.Var("person"),
+			)).Return(db.Qual(&names, "person.name")).Run(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Charlie Sheen", "Martin Sheen", "Michael Douglas", "Oliver Stone"}, names)
+	})
+
+	t.Run("Match on relationship type and use a variable", func(t *testing.T) {
+		var r []*ActedIn
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Var(
+				"wallstreet",
+				db.Props{
+					"title": "'Wall Street'",
+				},
+			)).From(db.Qual(&r, "r"), db.Var("actor"))).
+			Return(&r).Run(ctx)
+		require.NoError(t, err)
+		roles := make([]string, len(r))
+		for i, r := range r {
+			roles[i] = r.Role
+		}
+		assert.ElementsMatch(t, []string{"Bud Fox", "Carl Fox", "Gordon Gekko"}, roles)
+	})
+
+	t.Run("Filter on node property", func(t *testing.T) {
+		var n Person
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(&n, "n"))).
+			Where(db.Cond(&n.Age, "<", "30")).
+			Return(
+				&n.Name,
+				&n.Age,
+			).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Rob Reiner", n.Name)
+		assert.Equal(t, 29, n.Age)
+	})
+
+	t.Run("Prefix string search using STARTS WITH", func(t *testing.T) {
+		var n Person
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(&n, "n"))).
+			Where(db.Cond(&n.Name, "STARTS WITH", "'Pet'")).
+			Return(&n.Name, &n.Age).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Peter", n.Name)
+		assert.Equal(t, 35, n.Age)
+	})
+
+	t.Run("Order nodes by property", func(t *testing.T) {
+		var (
+			names []string
+			ages  []int
+		)
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Var("n", db.Label("Person")))).
+			Return(
+				db.Return(db.Qual(&names, "n.name"), db.OrderBy("", true)),
+				db.Qual(&ages, "n.age"),
+			).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Andy", "Charlie Sheen", "Martin Sheen", "Michael Douglas",
+			"Oliver Stone", "Peter", "Rob Reiner",
+		}, names)
+		assert.Equal(t, []int{36, 58, 83, 79, 77, 35, 29}, ages)
+	})
+
+	t.Run("Return middle two rows", func(t *testing.T) {
+		var names []string
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(Person{}, "n"))).
+			Return(
+				db.Return(db.Qual(&names, "n.name"), db.OrderBy("", true), db.Skip("1"), db.Limit("2")),
+			).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Charlie Sheen", "Martin Sheen"}, names)
+	})
+
+	t.Run("Introducing variables for expressions", func(t *testing.T) {
+		var otherPersonName string
+		err := newDriver(t).Exec().
+			Match(
+				db.Node(db.Var("andy", db.Props{"name": "'Andy'"})).
+					To(nil, "otherPerson"),
+			).
+			With("otherPerson", db.Qual(db.Expr("toUpper(otherPerson.name)"), "upperCaseName")).
+			Where(db.Cond("upperCaseName", "STARTS WITH", "'P'")).
+			Return(db.Qual(&otherPersonName, "otherPerson.name")).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Peter", otherPersonName)
+	})
+
+	t.Run("Filter on aggregate function results", func(t *testing.T) {
+		var names []string
+		err := newDriver(t).Exec().
+			Match(
+				db.Node(db.Var("wallStreet", db.Props{"title": "'Wall Street'"})).
+					Related(nil, "otherPerson").To(nil, nil),
+			).
+			With("otherPerson", db.Qual("count(*)", "foaf")).
+			Where(db.Cond("foaf", ">", "0")).
+			Return(
+				db.Qual(&names, "otherPerson.name"),
+			).Run(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Martin Sheen", "Michael Douglas"}, names)
+	})
+
+	t.Run("Unwinding a list", func(t *testing.T) {
+		var (
+			x []any
+			y []string
+		)
+		err := newDriver(t).Exec().
+			Unwind(db.Expr("[1, 2, 3, null]"), "x").
+			Return(db.Bind("x", &x), db.Qual(&y, "'val'", db.Name("y"))).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []any{int64(1), int64(2), int64(3), nil}, x)
+		assert.Equal(t, []string{"val", "val", "val", "val"}, y)
+	})
+
+	t.Run("Create a relationship between two nodes", func(t *testing.T) {
+		var (
+			a     Person
+			b     Person
+			typeR string
+		)
+		type Reltype struct {
+			internal.Relationship `neo4j:"RELTYPE"`
+		}
+		err := newDriver(t).Exec().
+			Match(db.Patterns(
+				db.Node(db.Qual(&a, "a")),
+				db.Node(db.Qual(&b, "b")),
+			)).
+			Where(
+				db.And(
+					db.Cond(&a.Name, "=", "'Andy'"),
+					db.Cond(&b.Name, "=", "'Peter'"),
+				),
+			).
+			Create(
+				db.Node(&a).To(db.Qual(Reltype{}, "r"), &b),
+			).
+			Return(db.Qual(&typeR, "type(r)")).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "RELTYPE", typeR)
+	})
+
+	t.Run("Set a property", func(t *testing.T) {
+		var n Person
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(&n, "n", db.Props{"name": "'Andy'"}))).
+			Set(db.SetPropValue(&n.Surname, "'Taylor'")).
+			Return(&n.Name, &n.Surname).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Andy", n.Name)
+		assert.Equal(t, "Taylor", n.Surname)
+	})
+
+	t.Run("Remove a label from a node", func(t *testing.T) {
+		var (
+			n      Person
+			labels []string
+		)
+		err := newDriver(t).Exec().
+			Match(db.Node(db.Qual(&n, "n", db.Props{"name": "'Peter'"}))).
+			Remove(db.RemoveLabels(&n, "German")).
+			Return(&n.Name, db.Qual(&labels, "labels(n)")).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Peter", n.Name)
+		assert.Equal(t, []string{"Person"}, labels)
+	})
+
+	t.Run("Merge with ON CREATE", func(t *testing.T) {
+		var keanu Person
+		err := newDriver(t).Exec().
+			Merge(
+				db.Node(
+					db.Qual(&keanu, "keanu", db.Props{
+						"chauffeurName": "'Eric Brown'",
+						"name":          "'Keanu Reeves'",
+					}),
+				),
+				db.OnCreate(db.SetPropValue(&keanu.Created, "timestamp()")),
+			).
+			Return(&keanu.Name, &keanu.Created).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Keanu Reeves", keanu.Name)
+		assert.NotZero(t, keanu.Created)
+	})
+
+	t.Run("Delete a node with all its relationships", func(t *testing.T) {
+		d := newDriver(t)
+		var n Person
+		err := d.Exec().
+			Match(
+				db.Node(
+					db.Qual(&n, "n",
+						db.Props{"name": "'Charlie Sheen'"},
+					),
+				),
+			).
+			DetachDelete(&n).Run(ctx)
+		require.NoError(t, err)
+
+		var count int
+		err = d.Exec().
+			Match(db.Node(db.Qual(Person{}, "n"))).
+			Return(db.Qual(&count, "count(n)")).Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 6, count)
+	})
+}
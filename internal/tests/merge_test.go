@@ -77,6 +77,46 @@ func TestMerge(t *testing.T) {
 			})
 		})
 
+		t.Run("Merge single node with a related node", func(t *testing.T) {
+			type Company struct {
+				internal.Node `neo4j:"Company"`
+
+				Name string `json:"name"`
+			}
+			type Employee struct {
+				internal.Node `neo4j:"Employee"`
+
+				Name     string   `json:"name"`
+				Employer *Company `neo4j:"employer,node=WORKS_AT"`
+			}
+			n := Employee{Name: "Andy", Employer: &Company{Name: "Neo4j"}}
+			c := internal.NewCypherClient()
+			cy, err := c.
+				Merge(
+					db.Node(db.Qual(&n, "n")),
+					db.OnCreate(db.SetPropValue(&n.Name, db.String("Andy"))),
+				).
+				Return(&n).
+				Compile()
+
+			Check(t, cy, err, internal.CompiledCypher{
+				Cypher: `
+					MERGE (n:Employee {name: $n_name})
+					ON CREATE
+					  SET n.name = "Andy"
+					MERGE (n)-[:WORKS_AT]->(n_employer:Company {name: $n_employer_name})
+					RETURN n
+					`,
+				Parameters: map[string]any{
+					"n_name":          "Andy",
+					"n_employer_name": "Neo4j",
+				},
+				Bindings: map[string]reflect.Value{
+					"n": reflect.ValueOf(&n),
+				},
+			})
+		})
+
 		t.Run("Merge single node derived from an existing node property", func(t *testing.T) {
 			var (
 				person   Person
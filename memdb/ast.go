@@ -0,0 +1,230 @@
+package memdb
+
+type (
+	// statement is a query, or the union of several queries.
+	statement struct {
+		queries []*singleQuery
+		// all is true if the queries are combined with UNION ALL, rather than
+		// UNION.
+		all bool
+	}
+	singleQuery struct {
+		clauses []clause
+	}
+
+	clause interface{ isClause() }
+
+	matchClause struct {
+		optional bool
+		patterns []*patternPart
+		where    expr
+	}
+	createClause struct {
+		patterns []*patternPart
+	}
+	mergeClause struct {
+		pattern  *patternPart
+		onCreate []setItem
+		onMatch  []setItem
+	}
+	setClause struct {
+		items []setItem
+	}
+	removeClause struct {
+		items []removeItem
+	}
+	deleteClause struct {
+		detach bool
+		exprs  []expr
+	}
+	// projectionClause is a WITH or RETURN clause.
+	projectionClause struct {
+		with     bool
+		distinct bool
+		star     bool
+		items    []projectionItem
+		orderBy  []sortItem
+		skip     expr
+		limit    expr
+		where    expr
+	}
+	unwindClause struct {
+		expr     expr
+		variable string
+	}
+	subqueryClause struct {
+		query *statement
+	}
+	procedureClause struct {
+		name string
+		args []expr
+		// yields are the columns of the procedure that are bound, or nil if all
+		// of them are.
+		yields []yieldItem
+		where  expr
+	}
+	foreachClause struct {
+		variable string
+		list     expr
+		clauses  []clause
+	}
+	// useClause selects the graph, which is ignored as there's only one.
+	useClause struct{}
+
+	projectionItem struct {
+		expr expr
+		// name is the alias of the item, or its text if it has none.
+		name string
+		// text is the expression as written in the query.
+		text string
+	}
+	sortItem struct {
+		expr expr
+		text string
+		desc bool
+	}
+	yieldItem struct {
+		column   string
+		variable string
+	}
+
+	setItem struct {
+		// variable is the node or relationship being updated, or nil if
+		// property is set.
+		variable expr
+		property *propertyExpr
+		value    expr
+		// merge is true for +=.
+		merge  bool
+		labels []string
+	}
+	removeItem struct {
+		variable expr
+		property *propertyExpr
+		labels   []string
+	}
+)
+
+func (*matchClause) isClause()      {}
+func (*createClause) isClause()     {}
+func (*mergeClause) isClause()      {}
+func (*setClause) isClause()        {}
+func (*removeClause) isClause()     {}
+func (*deleteClause) isClause()     {}
+func (*projectionClause) isClause() {}
+func (*unwindClause) isClause()     {}
+func (*subqueryClause) isClause()   {}
+func (*procedureClause) isClause()  {}
+func (*foreachClause) isClause()    {}
+func (*useClause) isClause()        {}
+
+type (
+	// patternPart is a path of nodes connected by relationships, optionally
+	// bound to a variable.
+	patternPart struct {
+		variable string
+		nodes    []*nodePattern
+		// rels[i] connects nodes[i] and nodes[i+1].
+		rels []*relPattern
+	}
+	nodePattern struct {
+		variable string
+		// labels must all be matched, where each is one of several
+		// alternatives.
+		labels [][]string
+		props  expr
+		where  expr
+	}
+	relPattern struct {
+		variable string
+		types    []string
+		props    expr
+		where    expr
+		// dir is 1 for ->, -1 for <- and 0 if undirected.
+		dir       int
+		varLength bool
+		// minHops and maxHops bound variable length relationships, where a
+		// negative maxHops is unbounded.
+		minHops, maxHops int
+	}
+)
+
+type (
+	expr any
+
+	literalExpr struct {
+		value any
+	}
+	paramExpr struct {
+		name string
+	}
+	variableExpr struct {
+		name string
+	}
+	propertyExpr struct {
+		subject expr
+		key     string
+	}
+	indexExpr struct {
+		subject, index expr
+	}
+	sliceExpr struct {
+		subject, from, to expr
+	}
+	listExpr struct {
+		items []expr
+	}
+	mapExpr struct {
+		keys   []string
+		values []expr
+	}
+	binaryExpr struct {
+		op   string
+		l, r expr
+	}
+	unaryExpr struct {
+		op string
+		e  expr
+	}
+	isNullExpr struct {
+		e   expr
+		not bool
+	}
+	labelExpr struct {
+		e      expr
+		labels [][]string
+	}
+	funcExpr struct {
+		name     string
+		distinct bool
+		// star is true for count(*).
+		star bool
+		args []expr
+	}
+	caseExpr struct {
+		// subject is compared to each when, or nil if each when is a
+		// predicate.
+		subject expr
+		whens   []expr
+		thens   []expr
+		els     expr
+	}
+	// listComprehensionExpr is [variable IN list WHERE where | projection],
+	// or a quantifier such as any(variable IN list WHERE where).
+	listComprehensionExpr struct {
+		quantifier string
+		variable   string
+		list       expr
+		where      expr
+		projection expr
+	}
+	patternComprehensionExpr struct {
+		pattern    *patternPart
+		where      expr
+		projection expr
+	}
+	// patternExpr is a pattern used as a predicate.
+	patternExpr struct {
+		pattern *patternPart
+	}
+)
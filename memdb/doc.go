@@ -0,0 +1,20 @@
+// Package memdb provides an in-memory graph database implementing
+// [neo4j.DriverWithContext], for running neogo queries without a Neo4j server.
+//
+//	d, err := neogo.NewWithDriver(memdb.New())
+//
+// It executes the subset of Cypher emitted by neogo's query builder:
+//
+//   - MATCH, OPTIONAL MATCH, WHERE, CREATE, MERGE (with ON CREATE and ON
+//     MATCH), SET, REMOVE, DELETE, DETACH DELETE, WITH, UNWIND, RETURN,
+//     ORDER BY, SKIP, LIMIT, UNION, FOREACH and CALL subqueries
+//   - variable length relationships, named paths, list and pattern
+//     comprehensions, CASE, quantifiers and common scalar, list, string,
+//     math and aggregating functions
+//   - the db.labels, db.relationshipTypes, db.propertyKeys and
+//     tx.setMetaData procedures
+//
+// Indexes, constraints, temporal and spatial functions, EXPLAIN, PROFILE and
+// SHOW aren't supported, and fail with a [neo4j.Neo4jError] like any other
+// unsupported query.
+package memdb
@@ -0,0 +1,484 @@
+package memdb
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+)
+
+// maxRetries is the number of times ExecuteRead and ExecuteWrite retry work
+// which conflicts with a concurrent transaction.
+const maxRetries = 100
+
+var (
+	errSessionClosed     = errors.New("memdb: session is closed")
+	errTransactionClosed = errors.New("memdb: transaction is closed")
+	errTransactionOpen   = errors.New("memdb: session already has an open transaction")
+)
+
+type (
+	// DB is an in-memory graph database, which implements
+	// [neo4j.DriverWithContext].
+	//
+	// Transactions are isolated from each other: each reads a snapshot of the
+	// graph taken when it began. A transaction which writes fails to commit if
+	// another has committed writes since it began, with an error that
+	// ExecuteWrite retries.
+	//
+	// It's safe for concurrent use.
+	DB struct {
+		mu      sync.Mutex
+		graph   *graph
+		version int64
+	}
+
+	session struct {
+		neo4j.SessionWithContext
+		db        *DB
+		config    neo4j.SessionConfig
+		bookmarks neo4j.Bookmarks
+		tx        *transaction
+		closed    bool
+	}
+
+	transaction struct {
+		neo4j.ExplicitTransaction
+		session *session
+		mode    neo4j.AccessMode
+		// base is the graph the transaction began with, and graph is the graph
+		// it reads and writes, which is cloned from base when first written.
+		base, graph *graph
+		version     int64
+		done        bool
+		// err is the error of the first query that failed, after which the
+		// transaction can only be rolled back.
+		err error
+	}
+
+	result struct {
+		neo4j.ResultWithContext
+		keys    []string
+		records []*neo4j.Record
+		// cursor is the index of the current record.
+		cursor  int
+		summary *summary
+	}
+
+	summary struct {
+		query         queryInfo
+		statementType neo4j.StatementType
+		counters      counters
+	}
+
+	queryInfo struct {
+		text   string
+		params map[string]any
+	}
+
+	counters struct {
+		nodesCreated         int
+		nodesDeleted         int
+		relationshipsCreated int
+		relationshipsDeleted int
+		propertiesSet        int
+		labelsAdded          int
+		labelsRemoved        int
+	}
+
+	serverInfo struct{}
+
+	databaseInfo string
+)
+
+var (
+	_ neo4j.DriverWithContext   = (*DB)(nil)
+	_ neo4j.SessionWithContext  = (*session)(nil)
+	_ neo4j.ExplicitTransaction = (*transaction)(nil)
+	_ neo4j.ManagedTransaction  = (*transaction)(nil)
+	_ neo4j.ResultWithContext   = (*result)(nil)
+	_ neo4j.ResultSummary       = (*summary)(nil)
+	_ neo4j.Counters            = (*counters)(nil)
+)
+
+// New creates an empty in-memory graph database.
+func New() *DB {
+	return &DB{graph: newGraph()}
+}
+
+func (d *DB) ExecuteQueryBookmarkManager() neo4j.BookmarkManager {
+	return nil
+}
+
+func (d *DB) Target() url.URL {
+	return url.URL{Scheme: "memdb"}
+}
+
+func (d *DB) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &session{db: d, config: config, bookmarks: config.Bookmarks}
+}
+
+func (d *DB) VerifyConnectivity(ctx context.Context) error {
+	return nil
+}
+
+func (d *DB) VerifyAuthentication(ctx context.Context, auth *neo4j.AuthToken) error {
+	return nil
+}
+
+func (d *DB) Close(ctx context.Context) error {
+	return nil
+}
+
+func (d *DB) IsEncrypted() bool {
+	return false
+}
+
+func (d *DB) GetServerInfo(ctx context.Context) (neo4j.ServerInfo, error) {
+	return serverInfo{}, nil
+}
+
+// snapshot returns the current graph and its version.
+func (d *DB) snapshot() (*graph, int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.graph, d.version
+}
+
+// commit replaces the graph with g if it hasn't changed since version.
+func (d *DB) commit(g *graph, version int64) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.version != version {
+		return 0, newError(codeOutdated, "The transaction conflicts with a transaction committed after it began")
+	}
+	d.graph = g
+	d.version++
+	return d.version, nil
+}
+
+func bookmark(version int64) string {
+	return "memdb:" + strconv.FormatInt(version, 10)
+}
+
+func (s *session) LastBookmarks() neo4j.Bookmarks {
+	return s.bookmarks
+}
+
+func (s *session) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	return s.begin(ctx, s.config.AccessMode)
+}
+
+func (s *session) begin(ctx context.Context, mode neo4j.AccessMode) (*transaction, error) {
+	if s.closed {
+		return nil, errSessionClosed
+	}
+	if s.tx != nil {
+		return nil, errTransactionOpen
+	}
+	if bm := s.config.BookmarkManager; bm != nil {
+		if _, err := bm.GetBookmarks(ctx); err != nil {
+			return nil, err
+		}
+	}
+	g, version := s.db.snapshot()
+	s.tx = &transaction{session: s, mode: mode, base: g, graph: g, version: version}
+	return s.tx, nil
+}
+
+func (s *session) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(ctx, neo4j.AccessModeRead, work)
+}
+
+func (s *session) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(ctx, neo4j.AccessModeWrite, work)
+}
+
+// execute runs work in a transaction, retrying it if it fails with a
+// retriable error.
+func (s *session) execute(ctx context.Context, mode neo4j.AccessMode, work neo4j.ManagedTransactionWork) (out any, err error) {
+	for attempt := 0; ; attempt++ {
+		out, err = s.executeOnce(ctx, mode, work)
+		var neoErr *neo4j.Neo4jError
+		if err == nil || !errors.As(err, &neoErr) || !neoErr.IsRetriable() || attempt == maxRetries {
+			return out, err
+		}
+		// Back off for a random interval so concurrent work doesn't keep
+		// conflicting.
+		delay := time.Duration(rand.Int63n(int64(time.Millisecond) << min(attempt, 4)))
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *session) executeOnce(ctx context.Context, mode neo4j.AccessMode, work neo4j.ManagedTransactionWork) (any, error) {
+	tx, err := s.begin(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+	out, err := work(tx)
+	if err != nil {
+		return nil, errors.Join(err, tx.Rollback(ctx))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *session) Run(ctx context.Context, cypher string, params map[string]any, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+	tx, err := s.begin(ctx, s.config.AccessMode)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Run(ctx, cypher, params)
+	if err != nil {
+		return nil, errors.Join(err, tx.Rollback(ctx))
+	}
+	return res, tx.Commit(ctx)
+}
+
+func (s *session) Close(ctx context.Context) error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.tx != nil {
+		return s.tx.Close(ctx)
+	}
+	return nil
+}
+
+func (t *transaction) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	if t.done {
+		return nil, errTransactionClosed
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, err := t.run(cypher, params)
+	if err != nil {
+		t.err = err
+		return nil, err
+	}
+	return res, nil
+}
+
+func (t *transaction) run(cypher string, params map[string]any) (*result, error) {
+	stmt, err := parse(cypher)
+	if err != nil {
+		return nil, newError(codeSyntaxError, err.Error())
+	}
+	statementType := neo4j.StatementTypeReadOnly
+	if isUpdating(stmt) {
+		if t.mode == neo4j.AccessModeRead {
+			return nil, newError(codeAccessMode, "Writing in read access mode not allowed")
+		}
+		if t.graph == t.base {
+			t.graph = t.base.clone()
+		}
+		statementType = neo4j.StatementTypeReadWrite
+	}
+	normalized, _ := normalize(params).(map[string]any)
+	x := &executor{g: t.graph, params: normalized, stats: &counters{}}
+	qr, err := x.execute(stmt)
+	if err != nil {
+		return nil, err
+	}
+	res := &result{
+		keys:   qr.columns,
+		cursor: -1,
+		summary: &summary{
+			query:         queryInfo{text: cypher, params: params},
+			statementType: statementType,
+			counters:      *x.stats,
+		},
+	}
+	if res.keys == nil {
+		res.keys = []string{}
+	}
+	if qr.returns {
+		res.records = make([]*neo4j.Record, len(qr.rows))
+		for i, r := range qr.rows {
+			rec := &neo4j.Record{Keys: res.keys, Values: make([]any, len(res.keys))}
+			for j, k := range res.keys {
+				rec.Values[j] = toDriver(r[k])
+			}
+			res.records[i] = rec
+		}
+	}
+	return res, nil
+}
+
+func (t *transaction) Commit(ctx context.Context) error {
+	if t.done {
+		return errTransactionClosed
+	}
+	t.done = true
+	t.session.tx = nil
+	if t.err != nil {
+		return t.err
+	}
+	version := t.version
+	if t.graph != t.base {
+		var err error
+		if version, err = t.session.db.commit(t.graph, t.version); err != nil {
+			return err
+		}
+	}
+	previous := t.session.bookmarks
+	t.session.bookmarks = neo4j.Bookmarks{bookmark(version)}
+	if bm := t.session.config.BookmarkManager; bm != nil {
+		return bm.UpdateBookmarks(ctx, previous, t.session.bookmarks)
+	}
+	return nil
+}
+
+func (t *transaction) Rollback(ctx context.Context) error {
+	if t.done {
+		return errTransactionClosed
+	}
+	t.done = true
+	t.session.tx = nil
+	return nil
+}
+
+func (t *transaction) Close(ctx context.Context) error {
+	if t.done {
+		return nil
+	}
+	return t.Rollback(ctx)
+}
+
+func (r *result) Keys() ([]string, error) {
+	return r.keys, nil
+}
+
+func (r *result) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !r.Next(ctx) {
+		*record = nil
+		return false
+	}
+	*record = r.Record()
+	return true
+}
+
+func (r *result) Next(ctx context.Context) bool {
+	if r.cursor < len(r.records) {
+		r.cursor++
+	}
+	return r.cursor < len(r.records)
+}
+
+func (r *result) PeekRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !r.Peek(ctx) {
+		*record = nil
+		return false
+	}
+	*record = r.records[r.cursor+1]
+	return true
+}
+
+func (r *result) Peek(ctx context.Context) bool {
+	return r.cursor+1 < len(r.records)
+}
+
+func (r *result) Err() error {
+	return nil
+}
+
+func (r *result) Record() *neo4j.Record {
+	if r.cursor < 0 || r.cursor >= len(r.records) {
+		return nil
+	}
+	return r.records[r.cursor]
+}
+
+func (r *result) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	remaining := r.records[min(r.cursor+1, len(r.records)):]
+	r.cursor = len(r.records)
+	return remaining, nil
+}
+
+func (r *result) Records(ctx context.Context) func(yield func(*neo4j.Record, error) bool) {
+	return func(yield func(*neo4j.Record, error) bool) {
+		for r.Next(ctx) {
+			if !yield(r.Record(), nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *result) Single(ctx context.Context) (*neo4j.Record, error) {
+	remaining, _ := r.Collect(ctx)
+	switch len(remaining) {
+	case 0:
+		return nil, errors.New("memdb: result contains no more records")
+	case 1:
+		return remaining[0], nil
+	}
+	return nil, errors.New("memdb: result contains more than one record")
+}
+
+func (r *result) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
+	r.cursor = len(r.records)
+	return r.summary, nil
+}
+
+func (r *result) IsOpen() bool {
+	return r.cursor < len(r.records)
+}
+
+func (s *summary) Server() neo4j.ServerInfo            { return serverInfo{} }
+func (s *summary) Query() neo4j.Query                  { return s.query }
+func (s *summary) StatementType() neo4j.StatementType  { return s.statementType }
+func (s *summary) Counters() neo4j.Counters            { return &s.counters }
+func (s *summary) Plan() neo4j.Plan                    { return nil }
+func (s *summary) Profile() neo4j.ProfiledPlan         { return nil }
+func (s *summary) Notifications() []neo4j.Notification { return nil }
+func (s *summary) GqlStatusObjects() []neo4j.GqlStatusObject {
+	return nil
+}
+func (s *summary) ResultAvailableAfter() time.Duration { return 0 }
+func (s *summary) ResultConsumedAfter() time.Duration  { return 0 }
+func (s *summary) Database() neo4j.DatabaseInfo        { return databaseInfo("neo4j") }
+
+func (q queryInfo) Text() string               { return q.text }
+func (q queryInfo) Parameters() map[string]any { return q.params }
+
+func (c *counters) ContainsUpdates() bool {
+	return c.nodesCreated+c.nodesDeleted+c.relationshipsCreated+c.relationshipsDeleted+
+		c.propertiesSet+c.labelsAdded+c.labelsRemoved > 0
+}
+func (c *counters) NodesCreated() int           { return c.nodesCreated }
+func (c *counters) NodesDeleted() int           { return c.nodesDeleted }
+func (c *counters) RelationshipsCreated() int   { return c.relationshipsCreated }
+func (c *counters) RelationshipsDeleted() int   { return c.relationshipsDeleted }
+func (c *counters) PropertiesSet() int          { return c.propertiesSet }
+func (c *counters) LabelsAdded() int            { return c.labelsAdded }
+func (c *counters) LabelsRemoved() int          { return c.labelsRemoved }
+func (c *counters) IndexesAdded() int           { return 0 }
+func (c *counters) IndexesRemoved() int         { return 0 }
+func (c *counters) ConstraintsAdded() int       { return 0 }
+func (c *counters) ConstraintsRemoved() int     { return 0 }
+func (c *counters) SystemUpdates() int          { return 0 }
+func (c *counters) ContainsSystemUpdates() bool { return false }
+
+func (serverInfo) Address() string                     { return "memdb" }
+func (serverInfo) Agent() string                       { return "memdb" }
+func (serverInfo) ProtocolVersion() db.ProtocolVersion { return db.ProtocolVersion{Major: 5} }
+
+func (d databaseInfo) Name() string { return string(d) }
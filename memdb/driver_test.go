@@ -0,0 +1,168 @@
+package memdb
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSession(t *testing.T, d *DB, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	ctx := context.Background()
+	s := d.NewSession(ctx, config)
+	t.Cleanup(func() { assert.NoError(t, s.Close(ctx)) })
+	return s
+}
+
+func count(t *testing.T, d *DB, cypher string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	s := newSession(t, d, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	res, err := s.Run(ctx, cypher, nil)
+	require.NoError(t, err)
+	record, err := res.Single(ctx)
+	require.NoError(t, err)
+	return record.Values[0].(int64)
+}
+
+func TestDB(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records and summary", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		res, err := s.Run(ctx, `
+			UNWIND $names AS name
+			CREATE (p:Person {name: name})
+			RETURN p, name
+			`, map[string]any{"names": []string{"Jessie", "Walter"}})
+		require.NoError(t, err)
+
+		keys, err := res.Keys()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p", "name"}, keys)
+		require.True(t, res.Peek(ctx))
+		require.True(t, res.Next(ctx))
+		node, ok := res.Record().Values[0].(neo4j.Node)
+		require.True(t, ok)
+		assert.Equal(t, []string{"Person"}, node.Labels)
+		assert.Equal(t, map[string]any{"name": "Jessie"}, node.Props)
+		assert.NotEmpty(t, node.ElementId)
+
+		rest, err := res.Collect(ctx)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		assert.Equal(t, "Walter", rest[0].Values[1])
+		assert.False(t, res.Next(ctx))
+
+		summary, err := res.Consume(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.StatementTypeReadWrite, summary.StatementType())
+		assert.True(t, summary.Counters().ContainsUpdates())
+		assert.Equal(t, 2, summary.Counters().NodesCreated())
+		assert.Equal(t, 2, summary.Counters().PropertiesSet())
+		assert.Equal(t, 2, summary.Counters().LabelsAdded())
+		assert.NotEmpty(t, s.LastBookmarks())
+	})
+
+	t.Run("rollback discards writes", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		tx, err := s.BeginTransaction(ctx)
+		require.NoError(t, err)
+		_, err = tx.Run(ctx, "CREATE (:Person)", nil)
+		require.NoError(t, err)
+		res, err := tx.Run(ctx, "MATCH (n) RETURN count(n)", nil)
+		require.NoError(t, err)
+		record, err := res.Single(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), record.Values[0], "transactions read their own writes")
+		require.NoError(t, tx.Rollback(ctx))
+		assert.Equal(t, int64(0), count(t, d, "MATCH (n) RETURN count(n)"))
+	})
+
+	t.Run("transactions are isolated", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		tx, err := s.BeginTransaction(ctx)
+		require.NoError(t, err)
+		_, err = tx.Run(ctx, "CREATE (:Person)", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count(t, d, "MATCH (n) RETURN count(n)"))
+		require.NoError(t, tx.Commit(ctx))
+		assert.Equal(t, int64(1), count(t, d, "MATCH (n) RETURN count(n)"))
+	})
+
+	t.Run("failed queries fail the transaction", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		tx, err := s.BeginTransaction(ctx)
+		require.NoError(t, err)
+		_, err = tx.Run(ctx, "CREATE (:Person)", nil)
+		require.NoError(t, err)
+		_, err = tx.Run(ctx, "RETURN 1 / 0", nil)
+		var neoErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neoErr)
+		assert.Equal(t, codeArithmeticError, neoErr.Code)
+		require.Error(t, tx.Commit(ctx))
+		assert.Equal(t, int64(0), count(t, d, "MATCH (n) RETURN count(n)"))
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		_, err := s.Run(ctx, "MATCH (n RETURN n", nil)
+		var neoErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neoErr)
+		assert.Equal(t, codeSyntaxError, neoErr.Code)
+	})
+
+	t.Run("read access mode rejects writes", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		_, err := s.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			return tx.Run(ctx, "CREATE (:Person)", nil)
+		})
+		var neoErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neoErr)
+		assert.Equal(t, codeAccessMode, neoErr.Code)
+	})
+
+	t.Run("conflicting writes are retried", func(t *testing.T) {
+		d := New()
+		s := newSession(t, d, neo4j.SessionConfig{})
+		_, err := s.Run(ctx, "CREATE (:Counter {count: 0})", nil)
+		require.NoError(t, err)
+
+		const n = 20
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for range n {
+			go func() {
+				defer wg.Done()
+				s := d.NewSession(ctx, neo4j.SessionConfig{})
+				_, err := s.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+					return tx.Run(ctx, "MATCH (c:Counter) SET c.count = c.count + 1", nil)
+				})
+				assert.NoError(t, err)
+				assert.NoError(t, s.Close(ctx))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(n), count(t, d, "MATCH (c:Counter) RETURN c.count"))
+	})
+
+	t.Run("bookmark manager", func(t *testing.T) {
+		d := New()
+		bm := neo4j.NewBookmarkManager(neo4j.BookmarkManagerConfig{})
+		s := newSession(t, d, neo4j.SessionConfig{BookmarkManager: bm})
+		_, err := s.Run(ctx, "CREATE ()", nil)
+		require.NoError(t, err)
+		bookmarks, err := bm.GetBookmarks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, s.LastBookmarks(), bookmarks)
+	})
+}
@@ -0,0 +1,33 @@
+package memdb
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Errors are reported with the codes Neo4j uses for them, so they can be
+// handled in the same way.
+const (
+	codeSyntaxError         = "Neo.ClientError.Statement.SyntaxError"
+	codeSemanticError       = "Neo.ClientError.Statement.SemanticError"
+	codeTypeError           = "Neo.ClientError.Statement.TypeError"
+	codeArgumentError       = "Neo.ClientError.Statement.ArgumentError"
+	codeArithmeticError     = "Neo.ClientError.Statement.ArithmeticError"
+	codeParameterMissing    = "Neo.ClientError.Statement.ParameterMissing"
+	codeAccessMode          = "Neo.ClientError.Statement.AccessMode"
+	codeUnknownFunction     = "Neo.ClientError.Statement.UnknownFunction"
+	codeProcedureNotFound   = "Neo.ClientError.Procedure.ProcedureNotFound"
+	codeConstraintViolation = "Neo.ClientError.Schema.ConstraintValidationFailed"
+	codeOutdated            = "Neo.TransientError.Transaction.Outdated"
+)
+
+func newError(code, msg string) error {
+	return &neo4j.Neo4jError{Code: code, Msg: msg}
+}
+
+// fail aborts the execution of a query with an error, which is recovered by
+// [executor.execute].
+func fail(code, format string, args ...any) {
+	panic(newError(code, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,522 @@
+package memdb
+
+import (
+	"maps"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// row binds the variables in scope to their values.
+type row map[string]any
+
+// with returns a copy of r binding name to v.
+func (r row) with(name string, v any) row {
+	c := make(row, len(r)+1)
+	maps.Copy(c, r)
+	c[name] = v
+	return c
+}
+
+// eval evaluates e with the variables bound by r.
+func (x *executor) eval(e expr, r row) any {
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case *literalExpr:
+		return e.value
+	case *paramExpr:
+		v, ok := x.params[e.name]
+		if !ok {
+			fail(codeParameterMissing, "Expected parameter(s): %s", e.name)
+		}
+		return v
+	case *variableExpr:
+		v, ok := r[e.name]
+		if !ok {
+			fail(codeSemanticError, "Variable `%s` not defined", e.name)
+		}
+		return v
+	case *propertyExpr:
+		return property(x.eval(e.subject, r), e.key)
+	case *indexExpr:
+		return x.evalIndex(x.eval(e.subject, r), x.eval(e.index, r))
+	case *sliceExpr:
+		return x.evalSlice(e, r)
+	case *listExpr:
+		l := make([]any, len(e.items))
+		for i, item := range e.items {
+			l[i] = x.eval(item, r)
+		}
+		return l
+	case *mapExpr:
+		m := make(map[string]any, len(e.keys))
+		for i, k := range e.keys {
+			m[k] = x.eval(e.values[i], r)
+		}
+		return m
+	case *binaryExpr:
+		return x.evalBinary(e, r)
+	case *unaryExpr:
+		v := x.eval(e.e, r)
+		if e.op == "NOT" {
+			return not(truth(v, e.op))
+		}
+		switch v := v.(type) {
+		case nil:
+			return nil
+		case int64:
+			return -v
+		case float64:
+			return -v
+		}
+		fail(codeTypeError, "Cannot negate %s", typeName(v))
+	case *isNullExpr:
+		return (x.eval(e.e, r) == nil) != e.not
+	case *labelExpr:
+		return hasLabels(x.eval(e.e, r), e.labels)
+	case *funcExpr:
+		if isAggregate(e.name) {
+			v, ok := x.aggregates[e]
+			if !ok {
+				fail(codeSemanticError, "Invalid use of aggregating function %s(...) in this context", e.name)
+			}
+			return v
+		}
+		return x.call(e, r)
+	case *caseExpr:
+		return x.evalCase(e, r)
+	case *listComprehensionExpr:
+		return x.evalListComprehension(e, r)
+	case *patternComprehensionExpr:
+		var out []any
+		for _, m := range x.match([]*patternPart{e.pattern}, r) {
+			if e.where != nil && x.eval(e.where, m) != true {
+				continue
+			}
+			out = append(out, x.eval(e.projection, m))
+		}
+		if out == nil {
+			out = []any{}
+		}
+		return out
+	case *patternExpr:
+		return len(x.match([]*patternPart{e.pattern}, r)) > 0
+	}
+	fail(codeSyntaxError, "unsupported expression %T", e)
+	return nil
+}
+
+// property returns the property key of v, which is a node, relationship or
+// map.
+func property(v any, key string) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *node:
+		return v.props[key]
+	case *relationship:
+		return v.props[key]
+	case map[string]any:
+		return v[key]
+	}
+	fail(codeTypeError, "Type mismatch: expected a map but was %s", typeName(v))
+	return nil
+}
+
+// properties returns the properties of v, which is a node, relationship or
+// map.
+func properties(v any) map[string]any {
+	switch v := v.(type) {
+	case *node:
+		return v.props
+	case *relationship:
+		return v.props
+	case map[string]any:
+		return v
+	}
+	fail(codeTypeError, "Expected %s to be a node, relationship or map", typeName(v))
+	return nil
+}
+
+// truth returns v as a boolean, or nil if it's null.
+func truth(v any, op string) any {
+	switch v.(type) {
+	case nil, bool:
+		return v
+	}
+	fail(codeTypeError, "Type mismatch: %s expected Boolean but was %s", op, typeName(v))
+	return nil
+}
+
+func not(v any) any {
+	if v == nil {
+		return nil
+	}
+	return !v.(bool)
+}
+
+func hasLabels(v any, labels [][]string) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *node:
+		for _, alternatives := range labels {
+			found := false
+			for _, l := range alternatives {
+				found = found || v.hasLabel(l)
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case *relationship:
+		for _, alternatives := range labels {
+			found := false
+			for _, l := range alternatives {
+				found = found || v.typ == l
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	fail(codeTypeError, "Type mismatch: expected Node but was %s", typeName(v))
+	return nil
+}
+
+func (x *executor) evalIndex(subject, index any) any {
+	if subject == nil || index == nil {
+		return nil
+	}
+	switch s := subject.(type) {
+	case []any:
+		i, ok := index.(int64)
+		if !ok {
+			fail(codeTypeError, "List index must be an Integer but was %s", typeName(index))
+		}
+		if i < 0 {
+			i += int64(len(s))
+		}
+		if i < 0 || i >= int64(len(s)) {
+			return nil
+		}
+		return s[i]
+	case *node, *relationship, map[string]any:
+		key, ok := index.(string)
+		if !ok {
+			fail(codeTypeError, "Property key must be a String but was %s", typeName(index))
+		}
+		return property(s, key)
+	}
+	fail(codeTypeError, "Cannot index %s", typeName(subject))
+	return nil
+}
+
+func (x *executor) evalSlice(e *sliceExpr, r row) any {
+	subject := x.eval(e.subject, r)
+	if subject == nil {
+		return nil
+	}
+	l, ok := subject.([]any)
+	if !ok {
+		fail(codeTypeError, "Cannot slice %s", typeName(subject))
+	}
+	bound := func(b expr, def int) (int, bool) {
+		if b == nil {
+			return def, true
+		}
+		v := x.eval(b, r)
+		if v == nil {
+			return 0, false
+		}
+		i, ok := v.(int64)
+		if !ok {
+			fail(codeTypeError, "List slice bound must be an Integer but was %s", typeName(v))
+		}
+		if i < 0 {
+			i += int64(len(l))
+		}
+		return int(min(max(i, 0), int64(len(l)))), true
+	}
+	from, ok := bound(e.from, 0)
+	if !ok {
+		return nil
+	}
+	to, ok := bound(e.to, len(l))
+	if !ok {
+		return nil
+	}
+	if from >= to {
+		return []any{}
+	}
+	return append([]any{}, l[from:to]...)
+}
+
+func (x *executor) evalBinary(e *binaryExpr, r row) any {
+	switch e.op {
+	case "AND":
+		l := truth(x.eval(e.l, r), e.op)
+		if l == false {
+			return false
+		}
+		rv := truth(x.eval(e.r, r), e.op)
+		if rv == false {
+			return false
+		}
+		if l == nil || rv == nil {
+			return nil
+		}
+		return true
+	case "OR":
+		l := truth(x.eval(e.l, r), e.op)
+		if l == true {
+			return true
+		}
+		rv := truth(x.eval(e.r, r), e.op)
+		if rv == true {
+			return true
+		}
+		if l == nil || rv == nil {
+			return nil
+		}
+		return false
+	case "XOR":
+		l, rv := truth(x.eval(e.l, r), e.op), truth(x.eval(e.r, r), e.op)
+		if l == nil || rv == nil {
+			return nil
+		}
+		return l != rv
+	}
+	l, rv := x.eval(e.l, r), x.eval(e.r, r)
+	switch e.op {
+	case "=":
+		return equal(l, rv)
+	case "<>":
+		return not(equal(l, rv))
+	case "<", ">", "<=", ">=":
+		if l == nil || rv == nil {
+			return nil
+		}
+		c, ok := compare(l, rv)
+		if !ok {
+			return nil
+		}
+		switch e.op {
+		case "<":
+			return c < 0
+		case ">":
+			return c > 0
+		case "<=":
+			return c <= 0
+		}
+		return c >= 0
+	case "IN":
+		return in(l, rv)
+	case "STARTS WITH", "ENDS WITH", "CONTAINS", "=~":
+		ls, lok := l.(string)
+		rs, rok := rv.(string)
+		if !lok || !rok {
+			return nil
+		}
+		switch e.op {
+		case "STARTS WITH":
+			return strings.HasPrefix(ls, rs)
+		case "ENDS WITH":
+			return strings.HasSuffix(ls, rs)
+		case "CONTAINS":
+			return strings.Contains(ls, rs)
+		}
+		re, err := x.regexp(rs)
+		if err != nil {
+			fail(codeArgumentError, "Invalid Regex: %v", err)
+		}
+		return re.MatchString(ls)
+	}
+	return arithmetic(e.op, l, rv)
+}
+
+// regexp compiles pattern so that it must match the whole string, as in Java.
+func (x *executor) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := x.regexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	if x.regexps == nil {
+		x.regexps = map[string]*regexp.Regexp{}
+	}
+	x.regexps[pattern] = re
+	return re, nil
+}
+
+func in(v, list any) any {
+	if list == nil {
+		return nil
+	}
+	l, ok := list.([]any)
+	if !ok {
+		fail(codeTypeError, "Type mismatch: expected a List but was %s", typeName(list))
+	}
+	var result any = false
+	for _, e := range l {
+		switch equal(v, e) {
+		case true:
+			return true
+		case nil:
+			result = nil
+		}
+	}
+	return result
+}
+
+func arithmetic(op string, l, r any) any {
+	if l == nil || r == nil {
+		return nil
+	}
+	if op == "+" {
+		switch l := l.(type) {
+		case []any:
+			if r, ok := r.([]any); ok {
+				return append(append([]any{}, l...), r...)
+			}
+			return append(append([]any{}, l...), r)
+		case string:
+			switch r.(type) {
+			case string, int64, float64:
+				return l + toString(r)
+			}
+		}
+		switch r := r.(type) {
+		case []any:
+			return append([]any{l}, r...)
+		case string:
+			switch l.(type) {
+			case int64, float64:
+				return toString(l) + r
+			}
+		}
+	}
+	li, lInt := l.(int64)
+	ri, rInt := r.(int64)
+	if lInt && rInt {
+		switch op {
+		case "+":
+			return li + ri
+		case "-":
+			return li - ri
+		case "*":
+			return li * ri
+		case "/", "%":
+			if ri == 0 {
+				fail(codeArithmeticError, "/ by zero")
+			}
+			if op == "/" {
+				return li / ri
+			}
+			return li % ri
+		case "^":
+			return math.Pow(float64(li), float64(ri))
+		}
+	}
+	lf, lok := asFloat(l)
+	rf, rok := asFloat(r)
+	if !lok || !rok {
+		fail(codeTypeError, "Cannot apply %s to %s and %s", op, typeName(l), typeName(r))
+	}
+	switch op {
+	case "+":
+		return lf + rf
+	case "-":
+		return lf - rf
+	case "*":
+		return lf * rf
+	case "/":
+		return lf / rf
+	case "%":
+		return math.Mod(lf, rf)
+	}
+	return math.Pow(lf, rf)
+}
+
+func (x *executor) evalCase(e *caseExpr, r row) any {
+	var subject any
+	if e.subject != nil {
+		subject = x.eval(e.subject, r)
+	}
+	for i, when := range e.whens {
+		v := x.eval(when, r)
+		if e.subject != nil {
+			v = equal(subject, v)
+		}
+		if v == true {
+			return x.eval(e.thens[i], r)
+		}
+	}
+	return x.eval(e.els, r)
+}
+
+func (x *executor) evalListComprehension(e *listComprehensionExpr, r row) any {
+	v := x.eval(e.list, r)
+	if v == nil {
+		return nil
+	}
+	l, ok := v.([]any)
+	if !ok {
+		fail(codeTypeError, "Type mismatch: expected a List but was %s", typeName(v))
+	}
+	out := []any{}
+	var matched, unmatched, unknown int
+	for _, item := range l {
+		scope := r.with(e.variable, item)
+		if e.where != nil {
+			switch truth(x.eval(e.where, scope), "WHERE") {
+			case nil:
+				unknown++
+				continue
+			case false:
+				unmatched++
+				continue
+			}
+		}
+		matched++
+		if e.projection != nil {
+			item = x.eval(e.projection, scope)
+		}
+		out = append(out, item)
+	}
+	switch e.quantifier {
+	case "any":
+		if matched > 0 {
+			return true
+		}
+	case "all":
+		if unmatched > 0 {
+			return false
+		}
+	case "none":
+		if matched > 0 {
+			return false
+		}
+	case "single":
+		if matched > 1 {
+			return false
+		}
+	default:
+		return out
+	}
+	if unknown > 0 {
+		return nil
+	}
+	switch e.quantifier {
+	case "any":
+		return false
+	case "single":
+		return matched == 1
+	}
+	return true
+}
@@ -0,0 +1,789 @@
+package memdb
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// executor executes a statement in a transaction.
+type executor struct {
+	g      *graph
+	params map[string]any
+	stats  *counters
+	// aggregates holds the values of the aggregating functions of the group
+	// being projected.
+	aggregates map[*funcExpr]any
+	regexps    map[string]*regexp.Regexp
+	// now is the time at which the statement was first asked for the time, so
+	// that it's the same throughout the statement.
+	now time.Time
+}
+
+// queryResult is the outcome of a query: its columns and the rows binding them.
+type queryResult struct {
+	columns []string
+	rows    []row
+	// returns is true if the query ends with RETURN, and so has columns.
+	returns bool
+}
+
+// execute executes stmt, recovering from the errors raised by [fail].
+func (x *executor) execute(stmt *statement) (res queryResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var neoErr *neo4j.Neo4jError
+			e, ok := r.(error)
+			if !ok || !errors.As(e, &neoErr) {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	return x.executeStatement(stmt, func(*singleQuery) row { return row{} }), nil
+}
+
+// executeStatement executes stmt, where each query starts with the row
+// returned by init.
+func (x *executor) executeStatement(stmt *statement, init func(*singleQuery) row) queryResult {
+	var res queryResult
+	seen := map[string]bool{}
+	for i, q := range stmt.queries {
+		qr := x.executeQuery(q, init(q))
+		if i == 0 {
+			res.columns, res.returns = qr.columns, qr.returns
+		} else if !slices.Equal(res.columns, qr.columns) {
+			fail(codeSemanticError, "All sub queries in an UNION must have the same return column names")
+		}
+		for _, r := range qr.rows {
+			if len(stmt.queries) > 1 && !stmt.all {
+				key := rowKey(r, res.columns)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			res.rows = append(res.rows, r)
+		}
+	}
+	return res
+}
+
+func rowKey(r row, columns []string) string {
+	values := make([]any, len(columns))
+	for i, c := range columns {
+		values[i] = r[c]
+	}
+	return keyOf(values)
+}
+
+func (x *executor) executeQuery(q *singleQuery, init row) queryResult {
+	rows := []row{init}
+	for i, c := range q.clauses {
+		last := i == len(q.clauses)-1
+		switch c := c.(type) {
+		case *projectionClause:
+			columns, projected := x.project(c, rows)
+			if !c.with {
+				if !last {
+					fail(codeSemanticError, "RETURN can only be used at the end of the query")
+				}
+				return queryResult{columns: columns, rows: projected, returns: true}
+			}
+			rows = projected
+		case *procedureClause:
+			columns, out := x.callProcedure(c, rows)
+			if last && len(columns) > 0 {
+				return queryResult{columns: columns, rows: out, returns: true}
+			}
+			rows = out
+		default:
+			rows = x.executeClause(c, rows)
+		}
+	}
+	return queryResult{rows: rows}
+}
+
+// executeClause executes a clause which doesn't project its rows.
+func (x *executor) executeClause(c clause, rows []row) []row {
+	switch c := c.(type) {
+	case *matchClause:
+		return x.executeMatch(c, rows)
+	case *createClause:
+		for i, r := range rows {
+			for _, part := range c.patterns {
+				r = x.create(part, r)
+			}
+			rows[i] = r
+		}
+		return rows
+	case *mergeClause:
+		return x.executeMerge(c, rows)
+	case *setClause:
+		for _, r := range rows {
+			x.set(c.items, r)
+		}
+		return rows
+	case *removeClause:
+		for _, r := range rows {
+			x.remove(c.items, r)
+		}
+		return rows
+	case *deleteClause:
+		x.executeDelete(c, rows)
+		return rows
+	case *unwindClause:
+		var out []row
+		for _, r := range rows {
+			switch v := x.eval(c.expr, r).(type) {
+			case nil:
+			case []any:
+				for _, item := range v {
+					out = append(out, r.with(c.variable, item))
+				}
+			default:
+				out = append(out, r.with(c.variable, v))
+			}
+		}
+		return out
+	case *subqueryClause:
+		return x.executeSubquery(c, rows)
+	case *foreachClause:
+		for _, r := range rows {
+			v := x.eval(c.list, r)
+			if v == nil {
+				continue
+			}
+			l, ok := v.([]any)
+			if !ok {
+				fail(codeTypeError, "FOREACH expected a List but was %s", typeName(v))
+			}
+			for _, item := range l {
+				inner := []row{r.with(c.variable, item)}
+				for _, ic := range c.clauses {
+					inner = x.executeClause(ic, inner)
+				}
+			}
+		}
+		return rows
+	case *useClause:
+		return rows
+	}
+	fail(codeSemanticError, "%T must be the last clause of a query", c)
+	return nil
+}
+
+func (x *executor) executeMatch(c *matchClause, rows []row) []row {
+	var out []row
+	for _, r := range rows {
+		matched := false
+		for _, m := range x.match(c.patterns, r) {
+			if c.where != nil && truth(x.eval(c.where, m), "WHERE") != true {
+				continue
+			}
+			matched = true
+			out = append(out, m)
+		}
+		if !matched && c.optional {
+			for _, v := range patternVariables(c.patterns) {
+				if _, bound := r[v]; !bound {
+					r = r.with(v, nil)
+				}
+			}
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (x *executor) executeMerge(c *mergeClause, rows []row) []row {
+	var out []row
+	for _, r := range rows {
+		matches := x.match([]*patternPart{c.pattern}, r)
+		if len(matches) == 0 {
+			created := x.create(c.pattern, r)
+			x.set(c.onCreate, created)
+			out = append(out, created)
+			continue
+		}
+		for _, m := range matches {
+			x.set(c.onMatch, m)
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (x *executor) executeSubquery(c *subqueryClause, rows []row) []row {
+	var out []row
+	for _, r := range rows {
+		res := x.executeStatement(c.query, func(q *singleQuery) row {
+			// Variables are imported into the subquery by a leading WITH.
+			if p, ok := q.clauses[0].(*projectionClause); ok && p.with {
+				return r
+			}
+			return row{}
+		})
+		if !res.returns {
+			out = append(out, r)
+			continue
+		}
+		for _, sr := range res.rows {
+			merged := make(row, len(r)+len(res.columns))
+			for k, v := range r {
+				merged[k] = v
+			}
+			for _, col := range res.columns {
+				merged[col] = sr[col]
+			}
+			out = append(out, merged)
+		}
+	}
+	return out
+}
+
+// create creates the nodes and relationships of part which aren't bound by r,
+// returning r binding them.
+func (x *executor) create(part *patternPart, r row) row {
+	p := &path{}
+	for i, np := range part.nodes {
+		var n *node
+		if v, bound := r[np.variable]; bound && np.variable != "" {
+			if len(np.labels) > 0 || np.props != nil {
+				fail(codeSemanticError, "Can't create node `%s` with labels or properties here. The variable is already declared in this context", np.variable)
+			}
+			var ok bool
+			if n, ok = v.(*node); !ok {
+				fail(codeSemanticError, "Failed to create relationship, node `%s` is %s", np.variable, typeName(v))
+			}
+		} else {
+			var labels []string
+			for _, alternatives := range np.labels {
+				if len(alternatives) > 1 {
+					fail(codeSemanticError, "Label expressions are not allowed when creating nodes")
+				}
+				if !slices.Contains(labels, alternatives[0]) {
+					labels = append(labels, alternatives[0])
+				}
+			}
+			n = x.g.createNode(labels, x.createProps(np.props, r))
+			x.stats.nodesCreated++
+			x.stats.labelsAdded += len(labels)
+			x.stats.propertiesSet += len(n.props)
+			if np.variable != "" {
+				r = r.with(np.variable, n)
+			}
+		}
+		p.nodes = append(p.nodes, n)
+		if i == 0 {
+			continue
+		}
+		rp := part.rels[i-1]
+		switch {
+		case len(rp.types) != 1:
+			fail(codeSemanticError, "Exactly one relationship type must be specified for CREATE")
+		case rp.dir == 0:
+			fail(codeSemanticError, "Only directed relationships are supported in CREATE")
+		case rp.varLength:
+			fail(codeSemanticError, "Variable length relationships cannot be used in CREATE")
+		}
+		if _, bound := r[rp.variable]; bound && rp.variable != "" {
+			fail(codeSemanticError, "Can't create relationship `%s`. The variable is already declared in this context", rp.variable)
+		}
+		start, end := p.nodes[i-1], n
+		if rp.dir < 0 {
+			start, end = end, start
+		}
+		rel := x.g.createRel(rp.types[0], start, end, x.createProps(rp.props, r))
+		x.stats.relationshipsCreated++
+		x.stats.propertiesSet += len(rel.props)
+		if rp.variable != "" {
+			r = r.with(rp.variable, rel)
+		}
+		p.rels = append(p.rels, rel)
+	}
+	if part.variable != "" {
+		r = r.with(part.variable, p)
+	}
+	return r
+}
+
+// createProps returns the properties of a new node or relationship.
+func (x *executor) createProps(e expr, r row) map[string]any {
+	props := map[string]any{}
+	if e == nil {
+		return props
+	}
+	for k, v := range x.patternProps(e, r) {
+		if v == nil {
+			continue
+		}
+		checkStorable(k, v)
+		props[k] = v
+	}
+	return props
+}
+
+func checkStorable(key string, v any) {
+	if !isStorable(v) {
+		fail(codeTypeError, "Property values can only be of primitive types or arrays thereof, but %s was %s", key, typeName(v))
+	}
+}
+
+// entity evaluates e, which must be a node, relationship or null.
+func (x *executor) entity(e expr, r row) any {
+	v := x.eval(e, r)
+	switch v.(type) {
+	case nil, *node, *relationship:
+		return v
+	}
+	fail(codeTypeError, "Expected %s to be a node or relationship", typeName(v))
+	return nil
+}
+
+func (x *executor) setProperty(v any, key string, value any) {
+	props := properties(v)
+	if value == nil {
+		if _, ok := props[key]; ok {
+			delete(props, key)
+			x.stats.propertiesSet++
+		}
+		return
+	}
+	checkStorable(key, value)
+	props[key] = value
+	x.stats.propertiesSet++
+}
+
+func (x *executor) set(items []setItem, r row) {
+	for _, item := range items {
+		switch {
+		case item.property != nil:
+			target := x.entity(item.property.subject, r)
+			if target != nil {
+				x.setProperty(target, item.property.key, x.eval(item.value, r))
+			}
+		case item.labels != nil:
+			n, ok := x.entity(item.variable, r).(*node)
+			if !ok {
+				continue
+			}
+			for _, l := range item.labels {
+				if !n.hasLabel(l) {
+					n.labels = append(n.labels, l)
+					x.stats.labelsAdded++
+				}
+			}
+		default:
+			target := x.entity(item.variable, r)
+			if target == nil {
+				continue
+			}
+			value := x.eval(item.value, r)
+			var props map[string]any
+			if value != nil {
+				// Copy the properties in case target is assigned its own.
+				props = map[string]any{}
+				for k, v := range properties(value) {
+					props[k] = v
+				}
+			}
+			if !item.merge {
+				for k := range properties(target) {
+					if _, ok := props[k]; !ok {
+						x.setProperty(target, k, nil)
+					}
+				}
+			}
+			for _, k := range sortedKeys(props) {
+				x.setProperty(target, k, props[k])
+			}
+		}
+	}
+}
+
+func (x *executor) remove(items []removeItem, r row) {
+	for _, item := range items {
+		if item.property != nil {
+			if target := x.entity(item.property.subject, r); target != nil {
+				x.setProperty(target, item.property.key, nil)
+			}
+			continue
+		}
+		n, ok := x.entity(item.variable, r).(*node)
+		if !ok {
+			continue
+		}
+		for _, l := range item.labels {
+			if i := slices.Index(n.labels, l); i >= 0 {
+				n.labels = slices.Delete(n.labels, i, i+1)
+				x.stats.labelsRemoved++
+			}
+		}
+	}
+}
+
+func (x *executor) executeDelete(c *deleteClause, rows []row) {
+	var (
+		nodes []*node
+		rels  []*relationship
+	)
+	for _, r := range rows {
+		for _, e := range c.exprs {
+			switch v := x.eval(e, r).(type) {
+			case nil:
+			case *node:
+				nodes = append(nodes, v)
+			case *relationship:
+				rels = append(rels, v)
+			case *path:
+				nodes = append(nodes, v.nodes...)
+				rels = append(rels, v.rels...)
+			default:
+				fail(codeTypeError, "Expected %s to be a node, relationship or path", typeName(v))
+			}
+		}
+	}
+	for _, rel := range rels {
+		if x.g.deleteRel(rel) {
+			x.stats.relationshipsDeleted++
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := x.g.nodes[n.id]; !ok {
+			continue
+		}
+		deleted, err := x.g.deleteNode(n, c.detach)
+		if err != nil {
+			panic(err)
+		}
+		x.stats.nodesDeleted++
+		x.stats.relationshipsDeleted += deleted
+	}
+}
+
+// project executes a WITH or RETURN clause, returning the projected columns
+// and rows.
+func (x *executor) project(c *projectionClause, rows []row) ([]string, []row) {
+	items := c.items
+	if c.star {
+		var vars []string
+		if len(rows) > 0 {
+			for v := range rows[0] {
+				vars = append(vars, v)
+			}
+		}
+		slices.Sort(vars)
+		star := make([]projectionItem, len(vars))
+		for i, v := range vars {
+			star[i] = projectionItem{expr: &variableExpr{name: v}, name: v, text: v}
+		}
+		items = append(star, items...)
+	}
+	columns := make([]string, len(items))
+	for i, item := range items {
+		columns[i] = item.name
+	}
+
+	type projected struct {
+		row row
+		// scope binds the variables which ORDER BY may refer to.
+		scope row
+	}
+	var out []projected
+	var aggregating bool
+	for _, item := range items {
+		aggregating = aggregating || containsAggregate(item.expr)
+	}
+	if !aggregating {
+		for _, r := range rows {
+			p := make(row, len(items))
+			for _, item := range items {
+				p[item.name] = x.eval(item.expr, r)
+			}
+			scope := p
+			if !c.distinct {
+				scope = make(row, len(r)+len(p))
+				for k, v := range r {
+					scope[k] = v
+				}
+				for k, v := range p {
+					scope[k] = v
+				}
+			}
+			out = append(out, projected{row: p, scope: scope})
+		}
+	} else {
+		for _, p := range x.group(items, rows) {
+			out = append(out, projected{row: p, scope: p})
+		}
+	}
+
+	if c.distinct {
+		seen := map[string]bool{}
+		out = slices.DeleteFunc(out, func(p projected) bool {
+			key := rowKey(p.row, columns)
+			if seen[key] {
+				return true
+			}
+			seen[key] = true
+			return false
+		})
+	}
+
+	if len(c.orderBy) > 0 {
+		keys := make([][]any, len(out))
+		for i, p := range out {
+			keys[i] = make([]any, len(c.orderBy))
+			for j, s := range c.orderBy {
+				keys[i][j] = x.sortKey(s, items, p.row, p.scope)
+			}
+		}
+		indices := make([]int, len(out))
+		for i := range indices {
+			indices[i] = i
+		}
+		slices.SortStableFunc(indices, func(a, b int) int {
+			for j, s := range c.orderBy {
+				if cmp := order(keys[a][j], keys[b][j]); cmp != 0 {
+					if s.desc {
+						return -cmp
+					}
+					return cmp
+				}
+			}
+			return 0
+		})
+		sorted := make([]projected, len(out))
+		for i, j := range indices {
+			sorted[i] = out[j]
+		}
+		out = sorted
+	}
+
+	if c.skip != nil {
+		n := x.count(c.skip, "SKIP")
+		out = out[min(n, len(out)):]
+	}
+	if c.limit != nil {
+		n := x.count(c.limit, "LIMIT")
+		out = out[:min(n, len(out))]
+	}
+
+	result := make([]row, 0, len(out))
+	for _, p := range out {
+		if c.where != nil && truth(x.eval(c.where, p.row), "WHERE") != true {
+			continue
+		}
+		result = append(result, p.row)
+	}
+	return columns, result
+}
+
+// sortKey evaluates the sort item s of a projected row p, which may refer to
+// the projected items by their text.
+func (x *executor) sortKey(s sortItem, items []projectionItem, p, scope row) any {
+	for _, item := range items {
+		if item.text == s.text {
+			return p[item.name]
+		}
+	}
+	return x.eval(s.expr, scope)
+}
+
+// group groups rows by the items which don't aggregate, and projects each
+// group.
+func (x *executor) group(items []projectionItem, rows []row) []row {
+	type group struct {
+		rows []row
+	}
+	var (
+		keys   []expr
+		calls  []*funcExpr
+		groups []*group
+	)
+	for _, item := range items {
+		if aggs := aggregateCalls(item.expr); len(aggs) > 0 {
+			calls = append(calls, aggs...)
+		} else {
+			keys = append(keys, item.expr)
+		}
+	}
+	byKey := map[string]*group{}
+	for _, r := range rows {
+		values := make([]any, len(keys))
+		for i, k := range keys {
+			values[i] = x.eval(k, r)
+		}
+		key := keyOf(values)
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, r)
+	}
+	// Aggregating without grouping keys always returns a row.
+	if len(groups) == 0 && len(keys) == 0 {
+		groups = append(groups, &group{})
+	}
+
+	defer func() { x.aggregates = nil }()
+	out := make([]row, len(groups))
+	for i, g := range groups {
+		x.aggregates = make(map[*funcExpr]any, len(calls))
+		for _, call := range calls {
+			x.aggregates[call] = x.aggregateCall(call, g.rows)
+		}
+		scope := row{}
+		if len(g.rows) > 0 {
+			scope = g.rows[0]
+		}
+		p := make(row, len(items))
+		for _, item := range items {
+			p[item.name] = x.eval(item.expr, scope)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// count evaluates the argument of SKIP or LIMIT.
+func (x *executor) count(e expr, clause string) int {
+	v := x.eval(e, row{})
+	n, ok := v.(int64)
+	if !ok || n < 0 {
+		fail(codeArgumentError, "Invalid input for %s. Expected a non-negative integer, got: %v", clause, toString(v))
+	}
+	return int(n)
+}
+
+// callProcedure executes a procedure call, returning the columns it yields
+// and the rows binding them.
+func (x *executor) callProcedure(c *procedureClause, rows []row) ([]string, []row) {
+	name := strings.ToLower(c.name)
+	proc, ok := procedures[name]
+	if !ok {
+		fail(codeProcedureNotFound, "There is no procedure with the name `%s` registered for this database instance", c.name)
+	}
+	yields := c.yields
+	if yields == nil {
+		for _, col := range proc.columns {
+			yields = append(yields, yieldItem{column: col, variable: col})
+		}
+	}
+	columns := make([]string, len(yields))
+	for i, y := range yields {
+		if !slices.Contains(proc.columns, y.column) {
+			fail(codeSemanticError, "Unknown procedure output: `%s`", y.column)
+		}
+		columns[i] = y.variable
+	}
+	var out []row
+	for _, r := range rows {
+		args := make([]any, len(c.args))
+		for i, arg := range c.args {
+			args[i] = x.eval(arg, r)
+		}
+		results := proc.call(x, args)
+		if len(proc.columns) == 0 {
+			out = append(out, r)
+			continue
+		}
+		for _, result := range results {
+			yielded := r
+			for _, y := range yields {
+				yielded = yielded.with(y.variable, result[slices.Index(proc.columns, y.column)])
+			}
+			if c.where != nil && truth(x.eval(c.where, yielded), "WHERE") != true {
+				continue
+			}
+			out = append(out, yielded)
+		}
+	}
+	return columns, out
+}
+
+type procedure struct {
+	columns []string
+	call    func(x *executor, args []any) [][]any
+}
+
+var procedures = map[string]procedure{
+	"db.labels": {
+		columns: []string{"label"},
+		call: func(x *executor, _ []any) [][]any {
+			var labels []string
+			for _, n := range x.g.nodes {
+				labels = append(labels, n.labels...)
+			}
+			return distinctSorted(labels)
+		},
+	},
+	"db.relationshiptypes": {
+		columns: []string{"relationshipType"},
+		call: func(x *executor, _ []any) [][]any {
+			var types []string
+			for _, r := range x.g.rels {
+				types = append(types, r.typ)
+			}
+			return distinctSorted(types)
+		},
+	},
+	"db.propertykeys": {
+		columns: []string{"propertyKey"},
+		call: func(x *executor, _ []any) [][]any {
+			var keys []string
+			for _, n := range x.g.nodes {
+				keys = append(keys, sortedKeys(n.props)...)
+			}
+			for _, r := range x.g.rels {
+				keys = append(keys, sortedKeys(r.props)...)
+			}
+			return distinctSorted(keys)
+		},
+	},
+	// tx.setMetaData is accepted, but metadata isn't used.
+	"tx.setmetadata": {
+		call: func(*executor, []any) [][]any { return nil },
+	},
+}
+
+func distinctSorted(values []string) [][]any {
+	slices.Sort(values)
+	values = slices.Compact(values)
+	out := make([][]any, len(values))
+	for i, v := range values {
+		out[i] = []any{v}
+	}
+	return out
+}
+
+// isUpdating returns true if stmt writes to the graph.
+func isUpdating(stmt *statement) bool {
+	for _, q := range stmt.queries {
+		if clausesUpdate(q.clauses) {
+			return true
+		}
+	}
+	return false
+}
+
+func clausesUpdate(clauses []clause) bool {
+	for _, c := range clauses {
+		switch c := c.(type) {
+		case *createClause, *mergeClause, *setClause, *removeClause, *deleteClause, *foreachClause:
+			return true
+		case *subqueryClause:
+			if isUpdating(c.query) {
+				return true
+			}
+		}
+	}
+	return false
+}
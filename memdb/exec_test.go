@@ -0,0 +1,207 @@
+package memdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run executes cypher against g, returning the values of each row.
+func run(t *testing.T, g *graph, cypher string, params map[string]any) [][]any {
+	t.Helper()
+	stmt, err := parse(cypher)
+	require.NoError(t, err)
+	normalized, _ := normalize(params).(map[string]any)
+	x := &executor{g: g, params: normalized, stats: &counters{}, now: time.Now()}
+	res, err := x.execute(stmt)
+	require.NoError(t, err)
+	out := make([][]any, len(res.rows))
+	for i, r := range res.rows {
+		out[i] = make([]any, len(res.columns))
+		for j, c := range res.columns {
+			out[i][j] = toDriver(r[c])
+		}
+	}
+	return out
+}
+
+func TestExecute(t *testing.T) {
+	t.Run("expressions", func(t *testing.T) {
+		for _, tc := range []struct {
+			expr string
+			want any
+		}{
+			{"1 + 2 * 3", int64(7)},
+			{"7 / 2", int64(3)},
+			{"7 / 2.0", 3.5},
+			{"2 ^ 3", 8.0},
+			{"-(1 + 1) % 3", int64(-2)},
+			{"'a' + 1", "a1"},
+			{"[1, 2] + 3", []any{int64(1), int64(2), int64(3)}},
+			{"null + 1", nil},
+			{"1 = 1.0", true},
+			{"1 < 'a'", nil},
+			{"null = null", nil},
+			{"null IS NULL", true},
+			{"true AND null", nil},
+			{"false AND null", false},
+			{"true OR null", true},
+			{"true XOR false", true},
+			{"NOT null", nil},
+			{"2 IN [1, 2]", true},
+			{"3 IN [1, null]", nil},
+			{"'neogo' STARTS WITH 'neo'", true},
+			{"'neogo' ENDS WITH 'go'", true},
+			{"'neogo' CONTAINS 'og'", true},
+			{"'neogo' =~ 'neo.*'", true},
+			{"'neogo' =~ 'neo'", false},
+			{"[1, 2, 3][-1]", int64(3)},
+			{"[1, 2, 3][1..]", []any{int64(2), int64(3)}},
+			{"{a: {b: 1}}.a.b", int64(1)},
+			{"CASE 2 WHEN 1 THEN 'one' WHEN 2 THEN 'two' END", "two"},
+			{"CASE WHEN false THEN 1 ELSE 2 END", int64(2)},
+			{"[x IN range(1, 5) WHERE x % 2 = 0 | x * 10]", []any{int64(20), int64(40)}},
+			{"all(x IN [1, 2] WHERE x > 0)", true},
+			{"any(x IN [1, 2] WHERE x > 1)", true},
+			{"none(x IN [1, 2] WHERE x > 1)", false},
+			{"single(x IN [1, 2] WHERE x > 1)", true},
+			{"coalesce(null, 1)", int64(1)},
+			{"size('neogo')", int64(5)},
+			{"toUpper('neogo')", "NEOGO"},
+			{"split('a,b', ',')", []any{"a", "b"}},
+			{"substring('neogo', 1, 2)", "eo"},
+			{"toInteger('42')", int64(42)},
+			{"toString(1.0)", "1.0"},
+			{"reverse([1, 2])", []any{int64(2), int64(1)}},
+			{"head([]) IS NULL", true},
+		} {
+			t.Run(tc.expr, func(t *testing.T) {
+				rows := run(t, newGraph(), "RETURN "+tc.expr, nil)
+				require.Len(t, rows, 1)
+				assert.Equal(t, tc.want, rows[0][0])
+			})
+		}
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		type name string
+		rows := run(t, newGraph(), "RETURN $i + 1, $s, $l", map[string]any{
+			"i": 1,
+			"s": name("neogo"),
+			"l": []int{1},
+		})
+		assert.Equal(t, [][]any{{int64(2), "neogo", []any{int64(1)}}}, rows)
+	})
+
+	t.Run("aggregation", func(t *testing.T) {
+		rows := run(t, newGraph(), `
+			UNWIND [1, 2, 2, 3, null] AS x
+			RETURN x % 2 AS parity, count(*), count(x), count(DISTINCT x), sum(x), collect(x)
+			ORDER BY parity
+			`, nil)
+		assert.Equal(t, [][]any{
+			{int64(0), int64(2), int64(2), int64(1), int64(4), []any{int64(2), int64(2)}},
+			{int64(1), int64(2), int64(2), int64(2), int64(4), []any{int64(1), int64(3)}},
+			{nil, int64(1), int64(0), int64(0), int64(0), []any{}},
+		}, rows)
+
+		rows = run(t, newGraph(), "MATCH (n) RETURN count(n), avg(n.age), min(n.age)", nil)
+		assert.Equal(t, [][]any{{int64(0), nil, nil}}, rows)
+	})
+
+	t.Run("variable length relationships", func(t *testing.T) {
+		g := newGraph()
+		run(t, g, `
+			CREATE (a:Node {name: 'a'})-[:NEXT]->(b:Node {name: 'b'})-[:NEXT]->(c:Node {name: 'c'})
+			CREATE (c)-[:NEXT]->(a)
+			`, nil)
+		rows := run(t, g, `
+			MATCH p = (:Node {name: 'a'})-[:NEXT*1..]->(n)
+			RETURN n.name, length(p)
+			ORDER BY length(p)
+			`, nil)
+		assert.Equal(t, [][]any{
+			{"b", int64(1)},
+			{"c", int64(2)},
+			{"a", int64(3)},
+		}, rows)
+
+		rows = run(t, g, "MATCH (a {name: 'a'})-[r*2]-(n) RETURN n.name, size(r) ORDER BY n.name", nil)
+		assert.Equal(t, [][]any{{"b", int64(2)}, {"c", int64(2)}}, rows)
+	})
+
+	t.Run("MERGE relationships", func(t *testing.T) {
+		g := newGraph()
+		for range 2 {
+			run(t, g, `
+				MERGE (a:Person {name: 'a'})
+				MERGE (b:Person {name: 'b'})
+				MERGE (a)-[:KNOWS]->(b)
+				`, nil)
+		}
+		rows := run(t, g, "MATCH (:Person)-[r:KNOWS]->(:Person) RETURN count(r)", nil)
+		assert.Equal(t, [][]any{{int64(1)}}, rows)
+	})
+
+	t.Run("FOREACH", func(t *testing.T) {
+		g := newGraph()
+		run(t, g, "FOREACH (i IN range(1, 3) | CREATE (:Item {i: i}))", nil)
+		rows := run(t, g, "MATCH (n:Item) RETURN n.i ORDER BY n.i DESC", nil)
+		assert.Equal(t, [][]any{{int64(3)}, {int64(2)}, {int64(1)}}, rows)
+	})
+
+	t.Run("SET", func(t *testing.T) {
+		g := newGraph()
+		rows := run(t, g, `
+			CREATE (n:Person {name: 'a', age: 1})
+			SET n += {age: 2, email: 'a@example.com'}, n:Admin
+			REMOVE n.name
+			RETURN n
+			`, nil)
+		require.Len(t, rows, 1)
+		n := rows[0][0].(neo4j.Node)
+		assert.Equal(t, []string{"Person", "Admin"}, n.Labels)
+		assert.Equal(t, map[string]any{"age": int64(2), "email": "a@example.com"}, n.Props)
+
+		rows = run(t, g, "MATCH (n:Person) SET n = {name: 'b'} RETURN properties(n)", nil)
+		assert.Equal(t, [][]any{{map[string]any{"name": "b"}}}, rows)
+	})
+
+	t.Run("procedures", func(t *testing.T) {
+		g := newGraph()
+		run(t, g, "CREATE (:B {y: 1})-[:R]->(:A {x: 1})", nil)
+		assert.Equal(t, [][]any{{"A"}, {"B"}}, run(t, g, "CALL db.labels()", nil))
+		assert.Equal(t, [][]any{{"R"}}, run(t, g, "CALL db.relationshipTypes() YIELD relationshipType RETURN relationshipType", nil))
+		assert.Equal(t, [][]any{{"x"}}, run(t, g, "CALL db.propertyKeys() YIELD propertyKey AS key WHERE key < 'y' RETURN key", nil))
+	})
+}
+
+func TestExecuteErrors(t *testing.T) {
+	for _, tc := range []struct {
+		cypher string
+		code   string
+	}{
+		{"RETURN $missing", codeParameterMissing},
+		{"RETURN x", codeSemanticError},
+		{"RETURN nope(1)", codeUnknownFunction},
+		{"CALL nope()", codeProcedureNotFound},
+		{"RETURN 1 / 0", codeArithmeticError},
+		{"RETURN -'a'", codeTypeError},
+		{"CREATE (n {m: {a: 1}})", codeTypeError},
+		{"CREATE (a)-[:R]->(b) DELETE a", codeConstraintViolation},
+		{"WITH 1 AS x WHERE count(x) > 0 RETURN x", codeSemanticError},
+	} {
+		t.Run(tc.cypher, func(t *testing.T) {
+			stmt, err := parse(tc.cypher)
+			require.NoError(t, err)
+			x := &executor{g: newGraph(), stats: &counters{}}
+			_, err = x.execute(stmt)
+			var neoErr *neo4j.Neo4jError
+			require.ErrorAs(t, err, &neoErr)
+			assert.Equal(t, tc.code, neoErr.Code)
+		})
+	}
+}
@@ -0,0 +1,571 @@
+package memdb
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var aggregates = map[string]bool{
+	"count":   true,
+	"collect": true,
+	"sum":     true,
+	"avg":     true,
+	"min":     true,
+	"max":     true,
+}
+
+func isAggregate(name string) bool { return aggregates[name] }
+
+// containsAggregate returns true if e calls an aggregating function outside of
+// a nested scope.
+func containsAggregate(e expr) bool {
+	found := false
+	walkExpr(e, func(e expr) bool {
+		if f, ok := e.(*funcExpr); ok && isAggregate(f.name) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// aggregateCalls returns the calls to aggregating functions in e.
+func aggregateCalls(e expr) []*funcExpr {
+	var calls []*funcExpr
+	walkExpr(e, func(e expr) bool {
+		if f, ok := e.(*funcExpr); ok && isAggregate(f.name) {
+			calls = append(calls, f)
+			return false
+		}
+		return true
+	})
+	return calls
+}
+
+// walkExpr calls f for e and each of its subexpressions while f returns true,
+// excluding those of comprehensions and patterns.
+func walkExpr(e expr, f func(expr) bool) {
+	if e == nil || !f(e) {
+		return
+	}
+	switch e := e.(type) {
+	case *propertyExpr:
+		walkExpr(e.subject, f)
+	case *indexExpr:
+		walkExpr(e.subject, f)
+		walkExpr(e.index, f)
+	case *sliceExpr:
+		walkExpr(e.subject, f)
+		walkExpr(e.from, f)
+		walkExpr(e.to, f)
+	case *listExpr:
+		for _, item := range e.items {
+			walkExpr(item, f)
+		}
+	case *mapExpr:
+		for _, v := range e.values {
+			walkExpr(v, f)
+		}
+	case *binaryExpr:
+		walkExpr(e.l, f)
+		walkExpr(e.r, f)
+	case *unaryExpr:
+		walkExpr(e.e, f)
+	case *isNullExpr:
+		walkExpr(e.e, f)
+	case *labelExpr:
+		walkExpr(e.e, f)
+	case *funcExpr:
+		for _, arg := range e.args {
+			walkExpr(arg, f)
+		}
+	case *caseExpr:
+		walkExpr(e.subject, f)
+		for i := range e.whens {
+			walkExpr(e.whens[i], f)
+			walkExpr(e.thens[i], f)
+		}
+		walkExpr(e.els, f)
+	}
+}
+
+// aggregateCall computes the aggregating function f over rows.
+func (x *executor) aggregateCall(f *funcExpr, rows []row) any {
+	if f.star {
+		return int64(len(rows))
+	}
+	if len(f.args) != 1 {
+		fail(codeArgumentError, "%s() expects a single argument", f.name)
+	}
+	var values []any
+	seen := map[string]bool{}
+	for _, r := range rows {
+		v := x.eval(f.args[0], r)
+		if v == nil {
+			continue
+		}
+		if f.distinct {
+			key := keyOf(v)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		values = append(values, v)
+	}
+	switch f.name {
+	case "count":
+		return int64(len(values))
+	case "collect":
+		if values == nil {
+			return []any{}
+		}
+		return values
+	case "sum", "avg":
+		var (
+			isum   int64
+			fsum   float64
+			floats bool
+		)
+		for _, v := range values {
+			switch v := v.(type) {
+			case int64:
+				isum += v
+			case float64:
+				fsum += v
+				floats = true
+			default:
+				fail(codeTypeError, "%s() expected numbers but found %s", f.name, typeName(v))
+			}
+		}
+		if f.name == "avg" {
+			if len(values) == 0 {
+				return nil
+			}
+			return (float64(isum) + fsum) / float64(len(values))
+		}
+		if floats {
+			return float64(isum) + fsum
+		}
+		return isum
+	case "min", "max":
+		var result any
+		for _, v := range values {
+			c := order(v, result)
+			if result == nil || f.name == "min" && c < 0 || f.name == "max" && c > 0 {
+				result = v
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// call calls the scalar function f.
+func (x *executor) call(f *funcExpr, r row) any {
+	if f.name == "coalesce" {
+		for _, arg := range f.args {
+			if v := x.eval(arg, r); v != nil {
+				return v
+			}
+		}
+		return nil
+	}
+	args := make([]any, len(f.args))
+	for i, arg := range f.args {
+		args[i] = x.eval(arg, r)
+	}
+	arity := func(n ...int) {
+		if !slices.Contains(n, len(args)) {
+			fail(codeArgumentError, "Wrong number of arguments to %s()", f.name)
+		}
+	}
+	// Most functions return null given null.
+	switch f.name {
+	case "rand", "timestamp", "randomuuid", "range", "exists":
+	default:
+		for _, arg := range args {
+			if arg == nil {
+				return nil
+			}
+		}
+	}
+	switch f.name {
+	case "id":
+		arity(1)
+		switch v := args[0].(type) {
+		case *node:
+			return v.id
+		case *relationship:
+			return v.id
+		}
+	case "elementid":
+		arity(1)
+		switch v := args[0].(type) {
+		case *node:
+			return elementID(v.id)
+		case *relationship:
+			return elementID(v.id)
+		}
+	case "labels":
+		arity(1)
+		if n, ok := args[0].(*node); ok {
+			labels := make([]any, len(n.labels))
+			for i, l := range n.labels {
+				labels[i] = l
+			}
+			return labels
+		}
+	case "type":
+		arity(1)
+		if rel, ok := args[0].(*relationship); ok {
+			return rel.typ
+		}
+	case "properties":
+		arity(1)
+		props := properties(args[0])
+		out := make(map[string]any, len(props))
+		for k, v := range props {
+			out[k] = v
+		}
+		return out
+	case "keys":
+		arity(1)
+		keys := sortedKeys(properties(args[0]))
+		out := make([]any, len(keys))
+		for i, k := range keys {
+			out[i] = k
+		}
+		return out
+	case "startnode", "endnode":
+		arity(1)
+		if rel, ok := args[0].(*relationship); ok {
+			id := rel.start
+			if f.name == "endnode" {
+				id = rel.end
+			}
+			if n, ok := x.g.nodes[id]; ok {
+				return n
+			}
+			return nil
+		}
+	case "nodes":
+		arity(1)
+		if p, ok := args[0].(*path); ok {
+			out := make([]any, len(p.nodes))
+			for i, n := range p.nodes {
+				out[i] = n
+			}
+			return out
+		}
+	case "relationships":
+		arity(1)
+		if p, ok := args[0].(*path); ok {
+			out := make([]any, len(p.rels))
+			for i, rel := range p.rels {
+				out[i] = rel
+			}
+			return out
+		}
+	case "size", "length":
+		arity(1)
+		switch v := args[0].(type) {
+		case []any:
+			return int64(len(v))
+		case string:
+			return int64(utf8.RuneCountInString(v))
+		case *path:
+			return int64(len(v.rels))
+		}
+	case "isempty":
+		arity(1)
+		switch v := args[0].(type) {
+		case []any:
+			return len(v) == 0
+		case string:
+			return v == ""
+		case map[string]any:
+			return len(v) == 0
+		}
+	case "exists":
+		arity(1)
+		if b, ok := args[0].(bool); ok {
+			return b
+		}
+		return args[0] != nil
+	case "head", "last":
+		arity(1)
+		if l, ok := args[0].([]any); ok {
+			if len(l) == 0 {
+				return nil
+			}
+			if f.name == "head" {
+				return l[0]
+			}
+			return l[len(l)-1]
+		}
+	case "tail":
+		arity(1)
+		if l, ok := args[0].([]any); ok {
+			if len(l) == 0 {
+				return []any{}
+			}
+			return append([]any{}, l[1:]...)
+		}
+	case "reverse":
+		arity(1)
+		switch v := args[0].(type) {
+		case []any:
+			out := slices.Clone(v)
+			slices.Reverse(out)
+			return out
+		case string:
+			runes := []rune(v)
+			slices.Reverse(runes)
+			return string(runes)
+		}
+	case "range":
+		arity(2, 3)
+		return rangeList(args)
+	case "tostring":
+		arity(1)
+		switch args[0].(type) {
+		case string, int64, float64, bool:
+			return toString(args[0])
+		}
+		if t, ok := asTime(args[0]); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	case "tointeger":
+		arity(1)
+		switch v := args[0].(type) {
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		case bool:
+			if v {
+				return int64(1)
+			}
+			return int64(0)
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return int64(f)
+			}
+			return nil
+		}
+	case "tofloat":
+		arity(1)
+		switch v := args[0].(type) {
+		case int64:
+			return float64(v)
+		case float64:
+			return v
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+			return nil
+		}
+	case "toboolean":
+		arity(1)
+		switch v := args[0].(type) {
+		case bool:
+			return v
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true":
+				return true
+			case "false":
+				return false
+			}
+			return nil
+		case int64:
+			return v != 0
+		}
+	case "tolower", "lower", "toupper", "upper", "trim", "ltrim", "rtrim":
+		arity(1)
+		if s, ok := args[0].(string); ok {
+			switch f.name {
+			case "tolower", "lower":
+				return strings.ToLower(s)
+			case "toupper", "upper":
+				return strings.ToUpper(s)
+			case "trim":
+				return strings.TrimSpace(s)
+			case "ltrim":
+				return strings.TrimLeft(s, " \t\n\r")
+			}
+			return strings.TrimRight(s, " \t\n\r")
+		}
+	case "replace":
+		arity(3)
+		s, ok1 := args[0].(string)
+		old, ok2 := args[1].(string)
+		replacement, ok3 := args[2].(string)
+		if ok1 && ok2 && ok3 {
+			return strings.ReplaceAll(s, old, replacement)
+		}
+	case "split":
+		arity(2)
+		s, ok1 := args[0].(string)
+		sep, ok2 := args[1].(string)
+		if ok1 && ok2 {
+			parts := strings.Split(s, sep)
+			out := make([]any, len(parts))
+			for i, p := range parts {
+				out[i] = p
+			}
+			return out
+		}
+	case "substring", "left", "right":
+		arity(1, 2, 3)
+		return substring(f.name, args)
+	case "abs", "ceil", "floor", "round", "sqrt", "sign":
+		arity(1)
+		return mathFunction(f.name, args[0])
+	case "rand":
+		arity(0)
+		return mathrand.Float64()
+	case "timestamp":
+		arity(0)
+		if x.now.IsZero() {
+			x.now = time.Now()
+		}
+		return x.now.UnixMilli()
+	case "randomuuid":
+		arity(0)
+		var b [16]byte
+		_, _ = rand.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	default:
+		fail(codeUnknownFunction, "Unknown function '%s'", f.name)
+	}
+	fail(codeTypeError, "Invalid arguments to %s(): %s", f.name, argTypes(args))
+	return nil
+}
+
+func argTypes(args []any) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = typeName(arg)
+	}
+	return strings.Join(types, ", ")
+}
+
+func rangeList(args []any) any {
+	bounds := make([]int64, 3)
+	bounds[2] = 1
+	for i, arg := range args {
+		n, ok := arg.(int64)
+		if !ok {
+			fail(codeTypeError, "range() expects Integer arguments but found %s", typeName(arg))
+		}
+		bounds[i] = n
+	}
+	start, end, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		fail(codeArgumentError, "Step argument to range() can't be 0")
+	}
+	out := []any{}
+	for i := start; step > 0 && i <= end || step < 0 && i >= end; i += step {
+		out = append(out, i)
+	}
+	return out
+}
+
+func substring(name string, args []any) any {
+	s, ok := args[0].(string)
+	if !ok {
+		fail(codeTypeError, "%s() expects a String but found %s", name, typeName(args[0]))
+	}
+	runes := []rune(s)
+	ints := make([]int, len(args)-1)
+	for i, arg := range args[1:] {
+		n, ok := arg.(int64)
+		if !ok || n < 0 {
+			fail(codeArgumentError, "%s() expects non-negative Integer arguments", name)
+		}
+		ints[i] = int(min(n, int64(len(runes))))
+	}
+	switch {
+	case name == "left" && len(ints) == 1:
+		return string(runes[:ints[0]])
+	case name == "right" && len(ints) == 1:
+		return string(runes[len(runes)-ints[0]:])
+	case name == "substring" && len(ints) == 1:
+		return string(runes[ints[0]:])
+	case name == "substring" && len(ints) == 2:
+		return string(runes[ints[0]:min(ints[0]+ints[1], len(runes))])
+	}
+	fail(codeArgumentError, "Wrong number of arguments to %s()", name)
+	return nil
+}
+
+func mathFunction(name string, v any) any {
+	if i, ok := v.(int64); ok {
+		switch name {
+		case "abs":
+			if i < 0 {
+				return -i
+			}
+			return i
+		case "sign":
+			return int64(cmpInt(i, 0))
+		}
+	}
+	f, ok := asFloat(v)
+	if !ok {
+		fail(codeTypeError, "%s() expects a number but found %s", name, typeName(v))
+	}
+	switch name {
+	case "abs":
+		return math.Abs(f)
+	case "ceil":
+		return math.Ceil(f)
+	case "floor":
+		return math.Floor(f)
+	case "round":
+		return math.Floor(f + 0.5)
+	case "sqrt":
+		return math.Sqrt(f)
+	}
+	switch {
+	case f > 0:
+		return int64(1)
+	case f < 0:
+		return int64(-1)
+	}
+	return int64(0)
+}
+
+// toString formats a string, number or boolean as Cypher does.
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
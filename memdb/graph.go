@@ -0,0 +1,164 @@
+package memdb
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+type (
+	// graph is a snapshot of the database. Committed graphs are never modified;
+	// transactions which write modify a clone.
+	graph struct {
+		nodes map[int64]*node
+		rels  map[int64]*relationship
+		// adjacent maps the ID of each node to the IDs of its relationships.
+		adjacent map[int64][]int64
+		nextID   int64
+	}
+	node struct {
+		id      int64
+		labels  []string
+		props   map[string]any
+		deleted bool
+	}
+	relationship struct {
+		id         int64
+		typ        string
+		start, end int64
+		props      map[string]any
+		deleted    bool
+	}
+	// path is a sequence of nodes connected by relationships.
+	path struct {
+		nodes []*node
+		rels  []*relationship
+	}
+)
+
+func newGraph() *graph {
+	return &graph{
+		nodes:    map[int64]*node{},
+		rels:     map[int64]*relationship{},
+		adjacent: map[int64][]int64{},
+	}
+}
+
+func (g *graph) clone() *graph {
+	c := &graph{
+		nodes:    make(map[int64]*node, len(g.nodes)),
+		rels:     make(map[int64]*relationship, len(g.rels)),
+		adjacent: make(map[int64][]int64, len(g.adjacent)),
+		nextID:   g.nextID,
+	}
+	for id, n := range g.nodes {
+		c.nodes[id] = &node{id: n.id, labels: slices.Clone(n.labels), props: maps.Clone(n.props)}
+	}
+	for id, r := range g.rels {
+		c.rels[id] = &relationship{id: r.id, typ: r.typ, start: r.start, end: r.end, props: maps.Clone(r.props)}
+	}
+	for id, rels := range g.adjacent {
+		c.adjacent[id] = slices.Clone(rels)
+	}
+	return c
+}
+
+// allNodes returns the nodes of the graph, in the order they were created.
+func (g *graph) allNodes() []*node {
+	nodes := make([]*node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	slices.SortFunc(nodes, func(a, b *node) int { return int(a.id - b.id) })
+	return nodes
+}
+
+// allRels returns the relationships of the graph, in the order they were
+// created.
+func (g *graph) allRels() []*relationship {
+	rels := make([]*relationship, 0, len(g.rels))
+	for _, r := range g.rels {
+		rels = append(rels, r)
+	}
+	slices.SortFunc(rels, func(a, b *relationship) int { return int(a.id - b.id) })
+	return rels
+}
+
+// relsOf returns the relationships starting or ending at n.
+func (g *graph) relsOf(n *node) []*relationship {
+	ids := g.adjacent[n.id]
+	rels := make([]*relationship, len(ids))
+	for i, id := range ids {
+		rels[i] = g.rels[id]
+	}
+	return rels
+}
+
+func (g *graph) createNode(labels []string, props map[string]any) *node {
+	g.nextID++
+	n := &node{id: g.nextID, labels: labels, props: props}
+	if n.props == nil {
+		n.props = map[string]any{}
+	}
+	g.nodes[n.id] = n
+	return n
+}
+
+func (g *graph) createRel(typ string, start, end *node, props map[string]any) *relationship {
+	g.nextID++
+	r := &relationship{id: g.nextID, typ: typ, start: start.id, end: end.id, props: props}
+	if r.props == nil {
+		r.props = map[string]any{}
+	}
+	g.rels[r.id] = r
+	g.adjacent[start.id] = append(g.adjacent[start.id], r.id)
+	if end.id != start.id {
+		g.adjacent[end.id] = append(g.adjacent[end.id], r.id)
+	}
+	return r
+}
+
+func (g *graph) deleteRel(r *relationship) bool {
+	if _, ok := g.rels[r.id]; !ok {
+		return false
+	}
+	r.deleted = true
+	delete(g.rels, r.id)
+	for _, id := range []int64{r.start, r.end} {
+		g.adjacent[id] = slices.DeleteFunc(g.adjacent[id], func(rel int64) bool { return rel == r.id })
+	}
+	return true
+}
+
+// deleteNode deletes n and, if detach is true, its relationships. It returns
+// the number of relationships deleted.
+func (g *graph) deleteNode(n *node, detach bool) (int, error) {
+	if _, ok := g.nodes[n.id]; !ok {
+		return 0, nil
+	}
+	rels := g.relsOf(n)
+	if len(rels) > 0 && !detach {
+		return 0, newError(
+			codeConstraintViolation,
+			fmt.Sprintf("Cannot delete node<%d>, because it still has relationships. To delete this node, you must first delete its relationships.", n.id),
+		)
+	}
+	for _, r := range rels {
+		g.deleteRel(r)
+	}
+	n.deleted = true
+	delete(g.nodes, n.id)
+	delete(g.adjacent, n.id)
+	return len(rels), nil
+}
+
+func (n *node) hasLabel(label string) bool {
+	return slices.Contains(n.labels, label)
+}
+
+func (r *relationship) other(id int64) int64 {
+	if r.start == id {
+		return r.end
+	}
+	return r.start
+}
@@ -0,0 +1,230 @@
+package memdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	// tokenQuotedIdent is an identifier escaped with backticks, which is never
+	// a keyword.
+	tokenQuotedIdent
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenParam
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	// text is the identifier, symbol, parameter name or unescaped string.
+	text string
+	// start and end are the offsets of the token in the query.
+	start, end int
+}
+
+// is returns true if t is the keyword or symbol s, ignoring case.
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenSymbol) && strings.EqualFold(t.text, s)
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenParam:
+		return "$" + t.text
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// symbols are the multi-character symbols of Cypher, longest first.
+var symbols = []string{"..", "<>", "<=", ">=", "!=", "=~", "+="}
+
+// lex splits a Cypher query into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 4
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], start: start, end: i})
+		case r == '`':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated identifier at offset %d", start)
+				}
+				if src[i] == '`' {
+					// Backticks are escaped by doubling them.
+					if i+1 < len(src) && src[i+1] == '`' {
+						b.WriteByte('`')
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(src[i])
+			}
+			tokens = append(tokens, token{kind: tokenQuotedIdent, text: b.String(), start: start, end: i})
+		case r == '$':
+			start := i
+			i++
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("invalid parameter at offset %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenParam, text: src[start+1 : i], start: start, end: i})
+		case r >= '0' && r <= '9':
+			tok, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = tok.end
+		case r == '\'' || r == '"':
+			tok, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = tok.end
+		default:
+			text := string(r)
+			for _, s := range symbols {
+				if strings.HasPrefix(src[i:], s) {
+					text = s
+					break
+				}
+			}
+			if !strings.Contains("()[]{},.:;|+-*/%^=<>!", string(r)) {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: text, start: i, end: i + len(text)})
+			i += len(text)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(src), end: len(src)}), nil
+}
+
+func lexNumber(src string, start int) (token, error) {
+	i := start
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	if strings.HasPrefix(src[i:], "0x") {
+		i += 2
+		for i < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[i]) >= 0 {
+			i++
+		}
+		n, err := strconv.ParseInt(src[start+2:i], 16, 64)
+		if err != nil {
+			return token{}, fmt.Errorf("invalid number %s", src[start:i])
+		}
+		return token{kind: tokenInt, text: strconv.FormatInt(n, 10), start: start, end: i}, nil
+	}
+	digits()
+	kind := tokenInt
+	// A dot followed by another dot is a range, as in [*1..3].
+	if i+1 < len(src) && src[i] == '.' && src[i+1] >= '0' && src[i+1] <= '9' {
+		kind = tokenFloat
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '-' || src[j] == '+') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			kind = tokenFloat
+			i = j
+			digits()
+		}
+	}
+	return token{kind: kind, text: src[start:i], start: start, end: i}, nil
+}
+
+func lexString(src string, start int) (token, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return token{kind: tokenString, text: b.String(), start: start, end: i + 1}, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'u':
+				if i+4 >= len(src) {
+					return token{}, fmt.Errorf("invalid escape sequence at offset %d", i-1)
+				}
+				n, err := strconv.ParseUint(src[i+1:i+5], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("invalid escape sequence at offset %d", i-1)
+				}
+				b.WriteRune(rune(n))
+				i += 4
+			default:
+				b.WriteByte(src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at offset %d", start)
+}
@@ -0,0 +1,208 @@
+package memdb
+
+import "slices"
+
+// match returns the rows extending r with the bindings of each match of
+// parts. Relationships are matched at most once in each row.
+func (x *executor) match(parts []*patternPart, r row) []row {
+	var out []row
+	var matchParts func(i int, r row, used []int64)
+	matchParts = func(i int, r row, used []int64) {
+		if i == len(parts) {
+			out = append(out, r)
+			return
+		}
+		x.matchPart(parts[i], r, used, func(r row, used []int64) {
+			matchParts(i+1, r, used)
+		})
+	}
+	matchParts(0, r, nil)
+	return out
+}
+
+// matchPart calls yield with each match of part extending r.
+func (x *executor) matchPart(part *patternPart, r row, used []int64, yield func(row, []int64)) {
+	first := part.nodes[0]
+	var candidates []*node
+	if v, bound := r[first.variable]; bound && first.variable != "" {
+		n, ok := v.(*node)
+		if !ok {
+			if v != nil {
+				fail(codeTypeError, "Type mismatch: expected Node but was %s", typeName(v))
+			}
+			return
+		}
+		candidates = []*node{n}
+	} else {
+		candidates = x.g.allNodes()
+	}
+	p := &path{}
+	for _, n := range candidates {
+		r, ok := x.matchNode(first, n, r)
+		if !ok {
+			continue
+		}
+		p.nodes = append(p.nodes[:0], n)
+		p.rels = p.rels[:0]
+		x.matchRels(part, 0, n, r, used, p, yield)
+	}
+}
+
+// matchRels matches the relationships of part from the ith onwards, starting
+// from the node n.
+func (x *executor) matchRels(
+	part *patternPart,
+	i int,
+	n *node,
+	r row,
+	used []int64,
+	p *path,
+	yield func(row, []int64),
+) {
+	if i == len(part.rels) {
+		if part.variable != "" {
+			r = r.with(part.variable, &path{nodes: slices.Clone(p.nodes), rels: slices.Clone(p.rels)})
+		}
+		yield(r, used)
+		return
+	}
+	rp, np := part.rels[i], part.nodes[i+1]
+	nodes, rels := len(p.nodes), len(p.rels)
+	next := func(to *node, r row, used []int64, hops []*relationship) {
+		r, ok := x.matchNode(np, to, r)
+		if !ok {
+			return
+		}
+		p.nodes = append(p.nodes[:nodes], to)
+		p.rels = append(p.rels[:rels], hops...)
+		x.matchRels(part, i+1, to, r, used, p, yield)
+	}
+	if !rp.varLength {
+		for _, rel := range x.expand(rp, n, r, used) {
+			r := r
+			if rp.variable != "" {
+				v, bound := r[rp.variable]
+				if bound && equal(v, rel) != true {
+					continue
+				}
+				r = r.with(rp.variable, rel)
+			}
+			if rp.where != nil && x.eval(rp.where, r) != true {
+				continue
+			}
+			next(x.g.nodes[rel.other(n.id)], r, append(slices.Clone(used), rel.id), []*relationship{rel})
+		}
+		return
+	}
+	// Variable length relationships are matched depth first.
+	var walk func(from *node, hops []*relationship, used []int64)
+	walk = func(from *node, hops []*relationship, used []int64) {
+		if len(hops) >= rp.minHops {
+			r := r
+			if rp.variable != "" {
+				l := make([]any, len(hops))
+				for i, rel := range hops {
+					l[i] = rel
+				}
+				v, bound := r[rp.variable]
+				if !bound || equal(v, l) == true {
+					r = r.with(rp.variable, l)
+					next(from, r, used, hops)
+				}
+			} else {
+				next(from, r, used, hops)
+			}
+		}
+		if rp.maxHops >= 0 && len(hops) >= rp.maxHops {
+			return
+		}
+		for _, rel := range x.expand(rp, from, r, used) {
+			walk(x.g.nodes[rel.other(from.id)], append(slices.Clone(hops), rel), append(slices.Clone(used), rel.id))
+		}
+	}
+	walk(n, nil, used)
+}
+
+// expand returns the relationships matching rp from n, excluding those used.
+func (x *executor) expand(rp *relPattern, n *node, r row, used []int64) []*relationship {
+	var props map[string]any
+	if rp.props != nil {
+		props = x.patternProps(rp.props, r)
+	}
+	var out []*relationship
+	for _, rel := range x.g.relsOf(n) {
+		switch {
+		case slices.Contains(used, rel.id),
+			rp.dir > 0 && rel.start != n.id,
+			rp.dir < 0 && rel.end != n.id,
+			len(rp.types) > 0 && !slices.Contains(rp.types, rel.typ),
+			!matchProps(rel.props, props):
+			continue
+		}
+		out = append(out, rel)
+	}
+	return out
+}
+
+// matchNode returns r binding the variable of np to n if n matches np.
+func (x *executor) matchNode(np *nodePattern, n *node, r row) (row, bool) {
+	for _, alternatives := range np.labels {
+		if !slices.ContainsFunc(alternatives, n.hasLabel) {
+			return nil, false
+		}
+	}
+	if np.props != nil && !matchProps(n.props, x.patternProps(np.props, r)) {
+		return nil, false
+	}
+	if np.variable != "" {
+		if v, bound := r[np.variable]; bound {
+			if equal(v, n) != true {
+				return nil, false
+			}
+		} else {
+			r = r.with(np.variable, n)
+		}
+	}
+	if np.where != nil && x.eval(np.where, r) != true {
+		return nil, false
+	}
+	return r, true
+}
+
+func (x *executor) patternProps(e expr, r row) map[string]any {
+	v := x.eval(e, r)
+	props, ok := v.(map[string]any)
+	if !ok {
+		fail(codeTypeError, "Expected properties to be a Map but was %s", typeName(v))
+	}
+	return props
+}
+
+func matchProps(props, want map[string]any) bool {
+	for k, v := range want {
+		if equal(props[k], v) != true {
+			return false
+		}
+	}
+	return true
+}
+
+// patternVariables returns the variables bound by parts.
+func patternVariables(parts []*patternPart) []string {
+	var vars []string
+	add := func(v string) {
+		if v != "" && !slices.Contains(vars, v) {
+			vars = append(vars, v)
+		}
+	}
+	for _, part := range parts {
+		add(part.variable)
+		for _, n := range part.nodes {
+			add(n.variable)
+		}
+		for _, rel := range part.rels {
+			add(rel.variable)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,267 @@
+package memdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+	"github.com/rlch/neogo/query"
+)
+
+func newTestDriver(t *testing.T) neogo.Driver {
+	t.Helper()
+	d, err := neogo.NewWithDriver(New())
+	require.NoError(t, err)
+	ctx := context.Background()
+	err = d.Exec().Cypher(`
+		CREATE (keanu:Person {id: 'keanu', name: 'Keanu Reeves', age: 60})
+		CREATE (carrie:Person {id: 'carrie', name: 'Carrie-Anne Moss', age: 57})
+		CREATE (lana:Person {id: 'lana', name: 'Lana Wachowski', age: 59})
+		CREATE (matrix:Movie {id: 'matrix', title: 'The Matrix', released: 1999})
+		CREATE (wick:Movie {id: 'wick', title: 'John Wick', released: 2014})
+		CREATE (keanu)-[:ACTED_IN {role: 'Neo'}]->(matrix)
+		CREATE (keanu)-[:ACTED_IN {role: 'John Wick'}]->(wick)
+		CREATE (carrie)-[:ACTED_IN {role: 'Trinity'}]->(matrix)
+		CREATE (lana)-[:DIRECTED]->(matrix)
+		`).Run(ctx)
+	require.NoError(t, err)
+	return d
+}
+
+func TestNeogo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MATCH with WHERE, ORDER BY, SKIP and LIMIT", func(t *testing.T) {
+		d := newTestDriver(t)
+		var people []*tests.Person
+		err := d.Exec().
+			Match(db.Node(db.Qual(&people, "p"))).
+			Where(db.Cond("p.age", ">", "57")).
+			Return(
+				&people,
+				db.Return(nil, db.OrderBy("p.age", false), db.Skip("0"), db.Limit("5")),
+			).
+			Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, people, 2)
+		assert.Equal(t, "Keanu Reeves", people[0].Name)
+		assert.Equal(t, "keanu", people[0].ID)
+		assert.Equal(t, "Lana Wachowski", people[1].Name)
+	})
+
+	t.Run("related nodes and relationships", func(t *testing.T) {
+		d := newTestDriver(t)
+		var (
+			p     tests.Person
+			roles []*tests.ActedIn
+			m     []*tests.Movie
+		)
+		err := d.Exec().
+			Match(
+				db.Node(db.Qual(&p, "p", db.Props{"name": "'Keanu Reeves'"})).
+					To(db.Qual(&roles, "r"), db.Qual(&m, "m")),
+			).
+			Return(&roles, &m, db.Return(nil, db.OrderBy("m.released", true))).
+			Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, roles, 2)
+		assert.Equal(t, "Neo", roles[0].Role)
+		assert.Equal(t, "The Matrix", m[0].Title)
+		assert.Equal(t, "John Wick", roles[1].Role)
+		assert.Equal(t, 2014, m[1].Released)
+	})
+
+	t.Run("OPTIONAL MATCH binds null", func(t *testing.T) {
+		d := newTestDriver(t)
+		var (
+			names  []string
+			titles []*string
+		)
+		err := d.Exec().
+			Match(db.Node(db.Var("p", db.Label("Person")))).
+			OptionalMatch(db.Node("p").Related(db.Var(nil, db.Label("DIRECTED")), db.Var("m"))).
+			Return(
+				db.Qual(&names, "p.name", db.Name("name")),
+				db.Qual(&titles, "m.title", db.Name("title")),
+				db.Return(nil, db.OrderBy("name", true)),
+			).
+			Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Carrie-Anne Moss", "Keanu Reeves", "Lana Wachowski"}, names)
+		require.Len(t, titles, 3)
+		assert.Nil(t, titles[0])
+		assert.Nil(t, titles[1])
+		require.NotNil(t, titles[2])
+		assert.Equal(t, "The Matrix", *titles[2])
+	})
+
+	t.Run("WITH and aggregation", func(t *testing.T) {
+		d := newTestDriver(t)
+		var (
+			titles []string
+			counts []int
+			names  [][]string
+		)
+		err := d.Exec().
+			Match(db.Node(db.Var("p", db.Label("Person"))).To(db.Var(nil, db.Label("ACTED_IN")), db.Var("m", db.Label("Movie")))).
+			With(
+				db.Qual("m.title", "title"),
+				db.Qual("count(p)", "actors"),
+				db.Qual("collect(p.name)", "names"),
+			).
+			Where(db.Cond("actors", ">=", 1)).
+			Return(
+				db.Qual(&titles, "title"),
+				db.Qual(&counts, "actors"),
+				db.Qual(&names, "names"),
+				db.Return(nil, db.OrderBy("actors", false)),
+			).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"The Matrix", "John Wick"}, titles)
+		assert.Equal(t, []int{2, 1}, counts)
+		assert.ElementsMatch(t, []string{"Keanu Reeves", "Carrie-Anne Moss"}, names[0])
+	})
+
+	t.Run("UNWIND", func(t *testing.T) {
+		d := newTestDriver(t)
+		var squares []int
+		err := d.Exec().
+			Unwind("range(1, 4)", "i").
+			Return(db.Qual(&squares, "i * i", db.Name("square"))).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 4, 9, 16}, squares)
+	})
+
+	t.Run("CREATE with struct parameters", func(t *testing.T) {
+		d := newTestDriver(t)
+		p := tests.Person{Name: "Hugo Weaving", Age: 64}
+		p.ID = "hugo"
+		summary, err := d.Exec().
+			Create(db.Node(db.Qual(&p, "p"))).
+			RunSummary(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Counters().NodesCreated())
+		assert.Equal(t, 3, summary.Counters().PropertiesSet())
+		assert.Equal(t, 1, summary.Counters().LabelsAdded())
+
+		var got tests.Person
+		err = d.Exec().
+			Match(db.Node(db.Qual(&got, "p", db.Props{"id": "'hugo'"}))).
+			Return(&got).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, p.Name, got.Name)
+		assert.Equal(t, p.Age, got.Age)
+	})
+
+	t.Run("MERGE with ON CREATE and ON MATCH", func(t *testing.T) {
+		d := newTestDriver(t)
+		merge := func(name string) (found bool) {
+			err := d.Exec().
+				Merge(
+					db.Node(db.Var("p", db.Label("Person"), db.Props{"name": db.Param(name)})),
+					db.OnCreate(db.SetPropValue("p.found", false)),
+					db.OnMatch(db.SetPropValue("p.found", true)),
+				).
+				Return(db.Qual(&found, "p.found")).
+				Run(ctx)
+			require.NoError(t, err)
+			return found
+		}
+		assert.True(t, merge("Keanu Reeves"))
+		assert.False(t, merge("Laurence Fishburne"))
+		assert.True(t, merge("Laurence Fishburne"))
+	})
+
+	t.Run("SET, REMOVE and DELETE", func(t *testing.T) {
+		d := newTestDriver(t)
+		var p tests.Person
+		err := d.Exec().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"id": "'carrie'"}))).
+			Set(
+				db.SetPropValue(&p.Position, "'Actor'"),
+				db.SetLabels(&p, "Star"),
+			).
+			Remove(db.RemoveProp(&p.Age)).
+			Return(&p).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "Actor", p.Position)
+		assert.Zero(t, p.Age)
+
+		var stars int
+		err = d.Exec().
+			Match(db.Node(db.Var("s", db.Label("Star")))).
+			Return(db.Qual(&stars, "count(s)")).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stars)
+
+		err = d.Exec().
+			Match(db.Node(db.Var("p", db.Label("Person"), db.Props{"id": "'carrie'"}))).
+			Delete("p").
+			Run(ctx)
+		require.Error(t, err, "nodes with relationships can't be deleted without DETACH")
+
+		summary, err := d.Exec().
+			Match(db.Node(db.Var("p", db.Label("Person"), db.Props{"id": "'carrie'"}))).
+			DetachDelete("p").
+			RunSummary(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Counters().NodesDeleted())
+		assert.Equal(t, 1, summary.Counters().RelationshipsDeleted())
+	})
+
+	t.Run("UNION", func(t *testing.T) {
+		d := newTestDriver(t)
+		var names []string
+		err := d.Exec().
+			Union(
+				func(c neogo.Query) query.Runner {
+					return c.Match(db.Node(db.Var("n", db.Label("Person")))).
+						Return(db.Qual(&names, "n.name", db.Name("name")))
+				},
+				func(c neogo.Query) query.Runner {
+					return c.Match(db.Node(db.Var("n", db.Label("Movie")))).
+						Return(db.Qual(&names, "n.title", db.Name("name")))
+				},
+			).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Keanu Reeves", "Carrie-Anne Moss", "Lana Wachowski", "The Matrix", "John Wick",
+		}, names)
+	})
+
+	t.Run("CALL subquery", func(t *testing.T) {
+		d := newTestDriver(t)
+		var (
+			names  []string
+			movies []int
+		)
+		err := d.Exec().
+			Match(db.Node(db.Var("p", db.Label("Person")))).
+			Subquery(func(c neogo.Query) query.Runner {
+				return c.
+					With("p").
+					OptionalMatch(db.Node("p").To(nil, db.Var("m", db.Label("Movie")))).
+					Return(db.Qual("count(m)", "movies"))
+			}).
+			Return(
+				db.Qual(&names, "p.name", db.Name("name")),
+				db.Qual(&movies, "movies"),
+				db.Return(nil, db.OrderBy("name", true)),
+			).
+			Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Carrie-Anne Moss", "Keanu Reeves", "Lana Wachowski"}, names)
+		assert.Equal(t, []int{1, 2, 1}, movies)
+	})
+}
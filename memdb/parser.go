@@ -0,0 +1,827 @@
+package memdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// syntaxError is raised by the parser with panic, and recovered by parse.
+type syntaxError struct {
+	msg string
+}
+
+func (e syntaxError) Error() string { return e.msg }
+
+// parse parses a Cypher query.
+func parse(src string) (stmt *statement, err error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			stmt, err = nil, e
+		}
+	}()
+	if p.peek().is("EXPLAIN") || p.peek().is("PROFILE") {
+		p.fail("%s is not supported", strings.ToUpper(p.peek().text))
+	}
+	stmt = p.parseStatement()
+	p.accept(";")
+	if p.peek().kind != tokenEOF {
+		p.fail("unexpected %s", p.peek())
+	}
+	return stmt, nil
+}
+
+func (p *parser) fail(format string, args ...any) {
+	tok := p.peek()
+	line := strings.Count(p.src[:tok.start], "\n") + 1
+	panic(syntaxError{fmt.Sprintf("%s (line %d, offset %d)", fmt.Sprintf(format, args...), line, tok.start)})
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it's the keyword or symbol s.
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// acceptAll consumes the next tokens if they're the keywords or symbols ss.
+func (p *parser) acceptAll(ss ...string) bool {
+	for i, s := range ss {
+		if !p.peekAt(i).is(s) {
+			return false
+		}
+	}
+	p.pos += len(ss)
+	return true
+}
+
+func (p *parser) expect(s string) {
+	if !p.accept(s) {
+		p.fail("expected %s but found %s", s, p.peek())
+	}
+}
+
+// name parses an identifier, such as a variable, label or property key.
+func (p *parser) name() string {
+	tok := p.peek()
+	if tok.kind != tokenIdent && tok.kind != tokenQuotedIdent {
+		p.fail("expected an identifier but found %s", tok)
+	}
+	p.pos++
+	return tok.text
+}
+
+// textFrom returns the query text from the token at start to the last token
+// consumed.
+func (p *parser) textFrom(start int) string {
+	return p.src[p.tokens[start].start:p.tokens[p.pos-1].end]
+}
+
+// try runs f, restoring the position of the parser and returning false if it
+// fails.
+func (p *parser) try(f func()) (ok bool) {
+	pos := p.pos
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isSyntax := r.(syntaxError); !isSyntax {
+				panic(r)
+			}
+			p.pos = pos
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+func (p *parser) parseStatement() *statement {
+	stmt := &statement{queries: []*singleQuery{p.parseSingleQuery()}}
+	for p.accept("UNION") {
+		all := p.accept("ALL")
+		if len(stmt.queries) > 1 && all != stmt.all {
+			p.fail("cannot mix UNION and UNION ALL")
+		}
+		stmt.all = all
+		stmt.queries = append(stmt.queries, p.parseSingleQuery())
+	}
+	return stmt
+}
+
+func (p *parser) parseSingleQuery() *singleQuery {
+	q := &singleQuery{}
+	for {
+		c := p.parseClause()
+		if c == nil {
+			break
+		}
+		q.clauses = append(q.clauses, c)
+	}
+	if len(q.clauses) == 0 {
+		p.fail("unexpected %s", p.peek())
+	}
+	return q
+}
+
+// parseClause parses the next clause, returning nil if there isn't one.
+func (p *parser) parseClause() clause {
+	tok := p.peek()
+	if tok.kind != tokenIdent {
+		return nil
+	}
+	switch {
+	case p.accept("MATCH"):
+		return p.parseMatch(false)
+	case p.acceptAll("OPTIONAL", "MATCH"):
+		return p.parseMatch(true)
+	case p.accept("CREATE"):
+		return &createClause{patterns: p.parsePattern()}
+	case p.accept("MERGE"):
+		return p.parseMerge()
+	case p.accept("SET"):
+		return &setClause{items: p.parseSetItems()}
+	case p.accept("REMOVE"):
+		return p.parseRemove()
+	case p.accept("DELETE"):
+		return &deleteClause{exprs: p.parseExprList()}
+	case p.acceptAll("DETACH", "DELETE"):
+		return &deleteClause{detach: true, exprs: p.parseExprList()}
+	case p.accept("WITH"):
+		return p.parseProjection(true)
+	case p.accept("RETURN"):
+		return p.parseProjection(false)
+	case p.accept("UNWIND"):
+		e := p.parseExpr()
+		p.expect("AS")
+		return &unwindClause{expr: e, variable: p.name()}
+	case p.accept("CALL"):
+		if p.accept("{") {
+			stmt := p.parseStatement()
+			p.expect("}")
+			return &subqueryClause{query: stmt}
+		}
+		return p.parseProcedure()
+	case p.accept("FOREACH"):
+		return p.parseForeach()
+	case p.accept("USE"):
+		p.parseExpr()
+		return &useClause{}
+	case tok.is("SHOW"), tok.is("LOAD"), tok.is("FINISH"):
+		p.fail("%s is not supported", strings.ToUpper(tok.text))
+	}
+	return nil
+}
+
+func (p *parser) parseMatch(optional bool) *matchClause {
+	m := &matchClause{optional: optional, patterns: p.parsePattern()}
+	if p.accept("WHERE") {
+		m.where = p.parseExpr()
+	}
+	return m
+}
+
+func (p *parser) parseMerge() *mergeClause {
+	m := &mergeClause{pattern: p.parsePatternPart()}
+	for {
+		switch {
+		case p.acceptAll("ON", "CREATE", "SET"):
+			m.onCreate = append(m.onCreate, p.parseSetItems()...)
+		case p.acceptAll("ON", "MATCH", "SET"):
+			m.onMatch = append(m.onMatch, p.parseSetItems()...)
+		default:
+			return m
+		}
+	}
+}
+
+func (p *parser) parseSetItems() []setItem {
+	var items []setItem
+	for {
+		v := &variableExpr{name: p.name()}
+		switch {
+		case p.peek().is(":"):
+			items = append(items, setItem{variable: v, labels: p.parseLabelList()})
+		case p.accept("."):
+			prop := &propertyExpr{subject: v, key: p.name()}
+			p.expect("=")
+			items = append(items, setItem{property: prop, value: p.parseExpr()})
+		case p.accept("="):
+			items = append(items, setItem{variable: v, value: p.parseExpr()})
+		case p.accept("+="):
+			items = append(items, setItem{variable: v, value: p.parseExpr(), merge: true})
+		default:
+			p.fail("expected a property, labels or properties to set but found %s", p.peek())
+		}
+		if !p.accept(",") {
+			return items
+		}
+	}
+}
+
+func (p *parser) parseRemove() *removeClause {
+	r := &removeClause{}
+	for {
+		v := &variableExpr{name: p.name()}
+		switch {
+		case p.peek().is(":"):
+			r.items = append(r.items, removeItem{variable: v, labels: p.parseLabelList()})
+		case p.accept("."):
+			r.items = append(r.items, removeItem{property: &propertyExpr{subject: v, key: p.name()}})
+		default:
+			p.fail("expected a property or labels to remove but found %s", p.peek())
+		}
+		if !p.accept(",") {
+			return r
+		}
+	}
+}
+
+func (p *parser) parseProjection(with bool) *projectionClause {
+	c := &projectionClause{with: with, distinct: p.accept("DISTINCT")}
+	c.star = p.accept("*")
+	if !c.star || p.accept(",") {
+		for {
+			start := p.pos
+			e := p.parseExpr()
+			item := projectionItem{expr: e, text: p.textFrom(start)}
+			item.name = item.text
+			if p.accept("AS") {
+				item.name = p.name()
+			}
+			c.items = append(c.items, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.acceptAll("ORDER", "BY") {
+		for {
+			start := p.pos
+			item := sortItem{expr: p.parseExpr(), text: p.textFrom(start)}
+			switch {
+			case p.accept("DESC"), p.accept("DESCENDING"):
+				item.desc = true
+			case p.accept("ASC"), p.accept("ASCENDING"):
+			}
+			c.orderBy = append(c.orderBy, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("SKIP") || p.accept("OFFSET") {
+		c.skip = p.parseExpr()
+	}
+	if p.accept("LIMIT") {
+		c.limit = p.parseExpr()
+	}
+	if with && p.accept("WHERE") {
+		c.where = p.parseExpr()
+	}
+	return c
+}
+
+func (p *parser) parseProcedure() *procedureClause {
+	c := &procedureClause{name: p.parseQualifiedName()}
+	if p.accept("(") {
+		if !p.accept(")") {
+			c.args = p.parseExprList()
+			p.expect(")")
+		}
+	}
+	if p.accept("YIELD") {
+		if p.accept("*") {
+			return c
+		}
+		for {
+			item := yieldItem{column: p.name()}
+			item.variable = item.column
+			if p.accept("AS") {
+				item.variable = p.name()
+			}
+			c.yields = append(c.yields, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if p.accept("WHERE") {
+			c.where = p.parseExpr()
+		}
+	}
+	return c
+}
+
+func (p *parser) parseForeach() *foreachClause {
+	p.expect("(")
+	c := &foreachClause{variable: p.name()}
+	p.expect("IN")
+	c.list = p.parseExpr()
+	p.expect("|")
+	for {
+		inner := p.parseClause()
+		if inner == nil {
+			break
+		}
+		c.clauses = append(c.clauses, inner)
+	}
+	p.expect(")")
+	return c
+}
+
+func (p *parser) parseQualifiedName() string {
+	name := p.name()
+	for p.peek().is(".") {
+		p.pos++
+		name += "." + p.name()
+	}
+	return name
+}
+
+func (p *parser) parseExprList() []expr {
+	exprs := []expr{p.parseExpr()}
+	for p.accept(",") {
+		exprs = append(exprs, p.parseExpr())
+	}
+	return exprs
+}
+
+func (p *parser) parsePattern() []*patternPart {
+	parts := []*patternPart{p.parsePatternPart()}
+	for p.accept(",") {
+		parts = append(parts, p.parsePatternPart())
+	}
+	return parts
+}
+
+func (p *parser) parsePatternPart() *patternPart {
+	part := &patternPart{}
+	if (p.peek().kind == tokenIdent || p.peek().kind == tokenQuotedIdent) && p.peekAt(1).is("=") {
+		part.variable = p.name()
+		p.pos++
+	}
+	part.nodes = append(part.nodes, p.parseNodePattern())
+	for p.peek().is("-") || p.peek().is("<") {
+		part.rels = append(part.rels, p.parseRelPattern())
+		part.nodes = append(part.nodes, p.parseNodePattern())
+	}
+	return part
+}
+
+func (p *parser) parseNodePattern() *nodePattern {
+	p.expect("(")
+	n := &nodePattern{}
+	if tok := p.peek(); tok.kind == tokenQuotedIdent || tok.kind == tokenIdent && !tok.is("WHERE") {
+		n.variable = p.name()
+	}
+	if p.peek().is(":") {
+		n.labels = p.parseLabels()
+	}
+	n.props = p.parsePatternProps()
+	if p.accept("WHERE") {
+		n.where = p.parseExpr()
+	}
+	p.expect(")")
+	return n
+}
+
+func (p *parser) parseRelPattern() *relPattern {
+	r := &relPattern{minHops: 1, maxHops: 1}
+	left := p.accept("<")
+	p.expect("-")
+	if p.accept("[") {
+		if tok := p.peek(); tok.kind == tokenQuotedIdent || tok.kind == tokenIdent && !tok.is("WHERE") {
+			r.variable = p.name()
+		}
+		if p.accept(":") {
+			r.types = append(r.types, p.name())
+			for p.accept("|") {
+				p.accept(":")
+				r.types = append(r.types, p.name())
+			}
+		}
+		if p.accept("*") {
+			r.varLength = true
+			r.minHops, r.maxHops = 1, -1
+			if p.peek().kind == tokenInt {
+				r.minHops = p.parseInt()
+				r.maxHops = r.minHops
+			}
+			if p.accept("..") {
+				r.maxHops = -1
+				if p.peek().kind == tokenInt {
+					r.maxHops = p.parseInt()
+				}
+			}
+		}
+		r.props = p.parsePatternProps()
+		if p.accept("WHERE") {
+			r.where = p.parseExpr()
+		}
+		p.expect("]")
+	}
+	p.expect("-")
+	right := p.accept(">")
+	switch {
+	case left && right:
+		p.fail("relationships cannot be bidirectional")
+	case left:
+		r.dir = -1
+	case right:
+		r.dir = 1
+	}
+	return r
+}
+
+func (p *parser) parseInt() int {
+	n, err := strconv.Atoi(p.next().text)
+	if err != nil {
+		p.fail("invalid integer: %v", err)
+	}
+	return n
+}
+
+func (p *parser) parsePatternProps() expr {
+	switch tok := p.peek(); {
+	case tok.is("{"):
+		return p.parseMap()
+	case tok.kind == tokenParam:
+		p.pos++
+		return &paramExpr{name: tok.text}
+	}
+	return nil
+}
+
+// parseLabelList parses labels such as :A:B, without alternatives.
+func (p *parser) parseLabelList() []string {
+	var labels []string
+	for p.accept(":") {
+		labels = append(labels, p.name())
+	}
+	return labels
+}
+
+// parseLabels parses labels such as :A:B or :A|B.
+func (p *parser) parseLabels() [][]string {
+	var labels [][]string
+	for p.accept(":") {
+		alternatives := []string{p.name()}
+		for p.accept("|") {
+			p.accept(":")
+			alternatives = append(alternatives, p.name())
+		}
+		labels = append(labels, alternatives)
+	}
+	return labels
+}
+
+func (p *parser) parseExpr() expr {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() expr {
+	e := p.parseXor()
+	for p.accept("OR") {
+		e = &binaryExpr{op: "OR", l: e, r: p.parseXor()}
+	}
+	return e
+}
+
+func (p *parser) parseXor() expr {
+	e := p.parseAnd()
+	for p.accept("XOR") {
+		e = &binaryExpr{op: "XOR", l: e, r: p.parseAnd()}
+	}
+	return e
+}
+
+func (p *parser) parseAnd() expr {
+	e := p.parseNot()
+	for p.accept("AND") {
+		e = &binaryExpr{op: "AND", l: e, r: p.parseNot()}
+	}
+	return e
+}
+
+func (p *parser) parseNot() expr {
+	if p.accept("NOT") {
+		return &unaryExpr{op: "NOT", e: p.parseNot()}
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() expr {
+	e := p.parseAdditive()
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenSymbol && strings.Contains(" = <> != < > <= >= =~ ", " "+tok.text+" "):
+			p.pos++
+			op := tok.text
+			if op == "!=" {
+				op = "<>"
+			}
+			e = &binaryExpr{op: op, l: e, r: p.parseAdditive()}
+		case p.accept("IN"):
+			e = &binaryExpr{op: "IN", l: e, r: p.parseAdditive()}
+		case p.acceptAll("STARTS", "WITH"):
+			e = &binaryExpr{op: "STARTS WITH", l: e, r: p.parseAdditive()}
+		case p.acceptAll("ENDS", "WITH"):
+			e = &binaryExpr{op: "ENDS WITH", l: e, r: p.parseAdditive()}
+		case p.accept("CONTAINS"):
+			e = &binaryExpr{op: "CONTAINS", l: e, r: p.parseAdditive()}
+		case p.acceptAll("IS", "NULL"):
+			e = &isNullExpr{e: e}
+		case p.acceptAll("IS", "NOT", "NULL"):
+			e = &isNullExpr{e: e, not: true}
+		default:
+			return e
+		}
+	}
+}
+
+func (p *parser) parseAdditive() expr {
+	e := p.parseMultiplicative()
+	for {
+		switch {
+		case p.accept("+"):
+			e = &binaryExpr{op: "+", l: e, r: p.parseMultiplicative()}
+		case p.accept("-"):
+			e = &binaryExpr{op: "-", l: e, r: p.parseMultiplicative()}
+		default:
+			return e
+		}
+	}
+}
+
+func (p *parser) parseMultiplicative() expr {
+	e := p.parsePower()
+	for {
+		tok := p.peek()
+		if !tok.is("*") && !tok.is("/") && !tok.is("%") {
+			return e
+		}
+		p.pos++
+		e = &binaryExpr{op: tok.text, l: e, r: p.parsePower()}
+	}
+}
+
+func (p *parser) parsePower() expr {
+	e := p.parseUnary()
+	for p.accept("^") {
+		e = &binaryExpr{op: "^", l: e, r: p.parseUnary()}
+	}
+	return e
+}
+
+func (p *parser) parseUnary() expr {
+	switch {
+	case p.accept("-"):
+		return &unaryExpr{op: "-", e: p.parseUnary()}
+	case p.accept("+"):
+		return p.parseUnary()
+	}
+	return p.parsePostfix(p.parseAtom())
+}
+
+func (p *parser) parsePostfix(e expr) expr {
+	for {
+		switch {
+		case p.accept("."):
+			e = &propertyExpr{subject: e, key: p.name()}
+		case p.accept("["):
+			var from, to expr
+			if !p.peek().is("..") {
+				from = p.parseExpr()
+			}
+			if p.accept("..") {
+				if !p.peek().is("]") {
+					to = p.parseExpr()
+				}
+				p.expect("]")
+				e = &sliceExpr{subject: e, from: from, to: to}
+				continue
+			}
+			p.expect("]")
+			e = &indexExpr{subject: e, index: from}
+		case p.peek().is(":") && (p.peekAt(1).kind == tokenIdent || p.peekAt(1).kind == tokenQuotedIdent):
+			e = &labelExpr{e: e, labels: p.parseLabels()}
+		default:
+			return e
+		}
+	}
+}
+
+func (p *parser) parseAtom() expr {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenInt:
+		p.pos++
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			p.fail("invalid integer %s", tok.text)
+		}
+		return &literalExpr{value: n}
+	case tokenFloat:
+		p.pos++
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			p.fail("invalid float %s", tok.text)
+		}
+		return &literalExpr{value: f}
+	case tokenString:
+		p.pos++
+		return &literalExpr{value: tok.text}
+	case tokenParam:
+		p.pos++
+		return &paramExpr{name: tok.text}
+	case tokenQuotedIdent:
+		p.pos++
+		return &variableExpr{name: tok.text}
+	case tokenSymbol:
+		switch tok.text {
+		case "[":
+			return p.parseListOrComprehension()
+		case "{":
+			return p.parseMap()
+		case "(":
+			var part *patternPart
+			if p.try(func() {
+				part = p.parsePatternPart()
+				if len(part.rels) == 0 {
+					p.fail("not a pattern")
+				}
+			}) {
+				return &patternExpr{pattern: part}
+			}
+			p.pos++
+			e := p.parseExpr()
+			p.expect(")")
+			return e
+		}
+	case tokenIdent:
+		switch {
+		case p.accept("TRUE"):
+			return &literalExpr{value: true}
+		case p.accept("FALSE"):
+			return &literalExpr{value: false}
+		case p.accept("NULL"):
+			return &literalExpr{value: nil}
+		case p.accept("CASE"):
+			return p.parseCase()
+		}
+		// Functions may be namespaced, as in db.labels().
+		n := 1
+		for p.peekAt(n).is(".") && p.peekAt(n+1).kind == tokenIdent {
+			n += 2
+		}
+		if p.peekAt(n).is("(") {
+			return p.parseFunction()
+		}
+		p.pos++
+		return &variableExpr{name: tok.text}
+	}
+	p.fail("unexpected %s", tok)
+	return nil
+}
+
+func (p *parser) parseListOrComprehension() expr {
+	p.expect("[")
+	if p.peek().kind == tokenIdent && p.peekAt(1).is("IN") {
+		c := &listComprehensionExpr{variable: p.name()}
+		p.expect("IN")
+		c.list = p.parseExpr()
+		if p.accept("WHERE") {
+			c.where = p.parseExpr()
+		}
+		if p.accept("|") {
+			c.projection = p.parseExpr()
+		}
+		p.expect("]")
+		return c
+	}
+	var c *patternComprehensionExpr
+	if p.try(func() {
+		c = &patternComprehensionExpr{pattern: p.parsePatternPart()}
+		if p.accept("WHERE") {
+			c.where = p.parseExpr()
+		}
+		p.expect("|")
+	}) {
+		c.projection = p.parseExpr()
+		p.expect("]")
+		return c
+	}
+	l := &listExpr{}
+	if p.accept("]") {
+		return l
+	}
+	l.items = p.parseExprList()
+	p.expect("]")
+	return l
+}
+
+func (p *parser) parseMap() *mapExpr {
+	p.expect("{")
+	m := &mapExpr{}
+	if p.accept("}") {
+		return m
+	}
+	for {
+		var key string
+		if tok := p.peek(); tok.kind == tokenString {
+			p.pos++
+			key = tok.text
+		} else {
+			key = p.name()
+		}
+		p.expect(":")
+		m.keys = append(m.keys, key)
+		m.values = append(m.values, p.parseExpr())
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect("}")
+	return m
+}
+
+func (p *parser) parseCase() expr {
+	c := &caseExpr{}
+	if !p.peek().is("WHEN") {
+		c.subject = p.parseExpr()
+	}
+	for p.accept("WHEN") {
+		c.whens = append(c.whens, p.parseExpr())
+		p.expect("THEN")
+		c.thens = append(c.thens, p.parseExpr())
+	}
+	if len(c.whens) == 0 {
+		p.fail("expected WHEN but found %s", p.peek())
+	}
+	if p.accept("ELSE") {
+		c.els = p.parseExpr()
+	}
+	p.expect("END")
+	return c
+}
+
+// quantifiers are the functions taking a list comprehension as their argument.
+var quantifiers = map[string]bool{"any": true, "all": true, "none": true, "single": true}
+
+func (p *parser) parseFunction() expr {
+	f := &funcExpr{name: strings.ToLower(p.parseQualifiedName())}
+	p.expect("(")
+	if quantifiers[f.name] && p.peek().kind == tokenIdent && p.peekAt(1).is("IN") {
+		c := &listComprehensionExpr{quantifier: f.name, variable: p.name()}
+		p.expect("IN")
+		c.list = p.parseExpr()
+		p.expect("WHERE")
+		c.where = p.parseExpr()
+		p.expect(")")
+		return c
+	}
+	switch {
+	case p.accept(")"):
+		return f
+	case p.accept("*"):
+		f.star = true
+	default:
+		f.distinct = p.accept("DISTINCT")
+		f.args = p.parseExprList()
+	}
+	p.expect(")")
+	return f
+}
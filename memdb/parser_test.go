@@ -0,0 +1,143 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("builder output", func(t *testing.T) {
+		for _, cypher := range []string{
+			`
+UNWIND [0, 1, 2] AS x
+CALL {
+  WITH x
+  RETURN x * 10 AS y
+}
+RETURN x, y`,
+			`
+MATCH (person:Person)
+WITH person
+ORDER BY person.age
+LIMIT 1
+SET person:ListHead
+WITH *
+MATCH (next:Person)
+WHERE NOT next:ListHead
+WITH next
+ORDER BY next.age
+CALL {
+  WITH next
+  MATCH (current:ListHead)
+  REMOVE current:ListHead
+  SET next:ListHead
+  CREATE (current)-[r:IS_YOUNGER_THAN]->(next)
+  RETURN current AS from, next AS to
+}
+RETURN from.name AS name, from.age AS age, to.name AS closestOlderName, to.age AS closestOlderAge`,
+			`
+CALL {
+  MATCH (p:Person)
+  RETURN p
+  ORDER BY p.age
+  LIMIT 1
+UNION
+  MATCH (p:Person)
+  RETURN p
+  ORDER BY p.age DESC
+  LIMIT 1
+}
+RETURN p.name, p.age
+ORDER BY p.name`,
+			`
+MATCH (david {name: 'David'})--(otherPerson)-->()
+WITH otherPerson, count(*) AS foaf
+WHERE foaf > 1
+RETURN otherPerson.name`,
+			`
+WITH 30 AS minAge
+MATCH (a:Person WHERE a.name = 'Andy')-[:KNOWS]->(b:Person WHERE b.age > minAge)
+RETURN b.name`,
+			`
+MATCH (a:Person {name: 'Andy'})
+RETURN [(a)-->(b WHERE b:Person) | b.name] AS friends`,
+			`
+WITH 'AGE' AS propname
+MATCH (n:Person)
+WHERE n[toLower(propname)] < 30
+RETURN n.name, n.age`,
+			`
+MATCH (n:Person)
+WHERE n.email =~ '.*\\.com'
+RETURN n.name`,
+			`
+MERGE (n:Employee {name: $n_name})
+ON CREATE
+  SET n.name = "Andy"
+MERGE (n)-[:WORKS_AT]->(n_employer:Company {name: $n_employer_name})
+RETURN n`,
+			`
+MERGE (person:Person)
+ON MATCH
+  SET
+    person.found = true,
+    person.lastSeen = timestamp()
+RETURN person.name, person.found, person.lastSeen`,
+			`
+MATCH p = (start)-[*]->(finish)
+WHERE start.name = 'A' AND finish.name = 'D'
+FOREACH (n IN nodes(p) | SET n.marked = true)`,
+			`
+MATCH (n:Person|Movie)-[r:ACTED_IN|DIRECTED*1..3]-(m:` + "`Some Label`" + `)
+DETACH DELETE n, r`,
+			`
+CALL db.labels() YIELD label AS l
+WHERE l STARTS WITH 'P'
+RETURN count(l) AS numLabels`,
+		} {
+			_, err := parse(cypher)
+			assert.NoError(t, err, cypher)
+		}
+	})
+
+	t.Run("structure", func(t *testing.T) {
+		stmt, err := parse(`
+			MATCH p = (a:A:B {x: 1})<-[r:R*2..]-(b)
+			RETURN DISTINCT a.x AS x
+			UNION ALL
+			RETURN 1 AS x
+			`)
+		require.NoError(t, err)
+		assert.True(t, stmt.all)
+		require.Len(t, stmt.queries, 2)
+
+		match := stmt.queries[0].clauses[0].(*matchClause)
+		part := match.patterns[0]
+		assert.Equal(t, "p", part.variable)
+		assert.Equal(t, [][]string{{"A"}, {"B"}}, part.nodes[0].labels)
+		rel := part.rels[0]
+		assert.Equal(t, -1, rel.dir)
+		assert.True(t, rel.varLength)
+		assert.Equal(t, 2, rel.minHops)
+		assert.Equal(t, -1, rel.maxHops)
+
+		ret := stmt.queries[0].clauses[1].(*projectionClause)
+		assert.True(t, ret.distinct)
+		assert.Equal(t, "x", ret.items[0].name)
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		for _, cypher := range []string{
+			"MATCH (n RETURN n",
+			"RETURN 'unterminated",
+			"MATCH (n) RETURN n LIMIT",
+			"EXPLAIN MATCH (n) RETURN n",
+			"RETURN 1 2",
+		} {
+			_, err := parse(cypher)
+			assert.Error(t, err, cypher)
+		}
+	})
+}
@@ -0,0 +1,500 @@
+package memdb
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Values are represented as nil, bool, int64, float64, string, []any,
+// map[string]any, *node, *relationship, *path or a temporal or spatial type
+// of the driver.
+
+var rTime = reflect.TypeOf(time.Time{})
+
+// normalize converts a parameter to the representation of its Cypher value.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string, []byte, time.Time, time.Duration:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = normalize(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = normalize(e)
+		}
+		return out
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = normalize(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = normalize(iter.Value().Interface())
+		}
+		return out
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return v
+}
+
+// asTime returns v as a [time.Time] if it's a temporal type, such as
+// [neo4j.Date].
+func asTime(v any) (time.Time, bool) {
+	if t, ok := v.(time.Time); ok {
+		return t, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Struct && rv.Type().ConvertibleTo(rTime) {
+		return rv.Convert(rTime).Interface().(time.Time), true
+	}
+	return time.Time{}, false
+}
+
+func asFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// equal compares a and b with =, returning nil if the comparison is null.
+func equal(a, b any) any {
+	if a == nil || b == nil {
+		return nil
+	}
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return a == b
+		case float64:
+			return float64(a) == b
+		}
+		return false
+	case float64:
+		if f, ok := asFloat(b); ok {
+			return a == f
+		}
+		return false
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		var result any = true
+		for i := range a {
+			switch equal(a[i], b[i]) {
+			case false:
+				return false
+			case nil:
+				result = nil
+			}
+		}
+		return result
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		var result any = true
+		for k, va := range a {
+			vb, ok := b[k]
+			if !ok {
+				return false
+			}
+			switch equal(va, vb) {
+			case false:
+				return false
+			case nil:
+				result = nil
+			}
+		}
+		return result
+	case *node:
+		b, ok := b.(*node)
+		return ok && a.id == b.id
+	case *relationship:
+		b, ok := b.(*relationship)
+		return ok && a.id == b.id
+	case *path:
+		b, ok := b.(*path)
+		return ok && keyOf(a) == keyOf(b)
+	}
+	if ta, ok := asTime(a); ok {
+		tb, ok := asTime(b)
+		return ok && reflect.TypeOf(a) == reflect.TypeOf(b) && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders a and b for the comparison operators, returning false if
+// they can't be compared.
+func compare(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmpInt(a, b), true
+		}
+		if f, ok := asFloat(b); ok {
+			return cmpFloat(float64(a), f)
+		}
+	case float64:
+		if f, ok := asFloat(b); ok {
+			return cmpFloat(a, f)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0, true
+			case b:
+				return -1, true
+			}
+			return 1, true
+		}
+	case []any:
+		b, ok := b.([]any)
+		if !ok {
+			return 0, false
+		}
+		for i := 0; i < len(a) && i < len(b); i++ {
+			c, ok := compare(a[i], b[i])
+			if !ok || c != 0 {
+				return c, ok
+			}
+		}
+		return cmpInt(int64(len(a)), int64(len(b))), true
+	}
+	if ta, ok := asTime(a); ok {
+		if tb, ok := asTime(b); ok && reflect.TypeOf(a) == reflect.TypeOf(b) {
+			return ta.Compare(tb), true
+		}
+	}
+	return 0, false
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func cmpFloat(a, b float64) (int, bool) {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return 0, false
+	}
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	}
+	return 0, true
+}
+
+// orderRank orders values of different types, as in ORDER BY.
+func orderRank(v any) int {
+	switch v.(type) {
+	case map[string]any:
+		return 0
+	case *node:
+		return 1
+	case *relationship:
+		return 2
+	case []any:
+		return 3
+	case *path:
+		return 4
+	case string:
+		return 6
+	case bool:
+		return 7
+	case int64, float64:
+		return 8
+	case nil:
+		return 10
+	}
+	if _, ok := asTime(v); ok {
+		return 5
+	}
+	return 9
+}
+
+// order orders any two values, as in ORDER BY. Nulls are ordered last.
+func order(a, b any) int {
+	ra, rb := orderRank(a), orderRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case *node:
+		return cmpInt(a.id, b.(*node).id)
+	case *relationship:
+		return cmpInt(a.id, b.(*relationship).id)
+	case []any:
+		b := b.([]any)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := order(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return cmpInt(int64(len(a)), int64(len(b)))
+	case float64:
+		// NaN is ordered after every other number.
+		if f, _ := asFloat(b); math.IsNaN(a) || math.IsNaN(f) {
+			switch {
+			case math.IsNaN(a) && math.IsNaN(f):
+				return 0
+			case math.IsNaN(a):
+				return 1
+			}
+			return -1
+		}
+	}
+	if c, ok := compare(a, b); ok {
+		return c
+	}
+	return strings.Compare(keyOf(a), keyOf(b))
+}
+
+// keyOf returns a key which is equal for equivalent values, used to group
+// and deduplicate them.
+func keyOf(v any) string {
+	var b strings.Builder
+	writeKey(&b, v)
+	return b.String()
+}
+
+func writeKey(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case int64:
+		b.WriteString("#" + strconv.FormatInt(v, 10))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			b.WriteString("#" + strconv.FormatInt(int64(v), 10))
+		} else {
+			b.WriteString("#" + strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case []any:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeKey(b, e)
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		keys := sortedKeys(v)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.Quote(k) + ":")
+			writeKey(b, v[k])
+		}
+		b.WriteByte('}')
+	case *node:
+		fmt.Fprintf(b, "node(%d)", v.id)
+	case *relationship:
+		fmt.Fprintf(b, "rel(%d)", v.id)
+	case *path:
+		b.WriteString("path(")
+		for _, n := range v.nodes {
+			fmt.Fprintf(b, "%d,", n.id)
+		}
+		for _, r := range v.rels {
+			fmt.Fprintf(b, "%d,", r.id)
+		}
+		b.WriteByte(')')
+	default:
+		if t, ok := asTime(v); ok {
+			fmt.Fprintf(b, "%T(%s)", v, t.Format(time.RFC3339Nano))
+			return
+		}
+		fmt.Fprintf(b, "%T(%v)", v, v)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// typeName returns the Cypher type of v, used in error messages.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return "Boolean"
+	case int64:
+		return "Integer"
+	case float64:
+		return "Float"
+	case string:
+		return "String"
+	case []any:
+		return "List"
+	case map[string]any:
+		return "Map"
+	case *node:
+		return "Node"
+	case *relationship:
+		return "Relationship"
+	case *path:
+		return "Path"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// isStorable returns true if v can be stored as a property.
+func isStorable(v any) bool {
+	switch v := v.(type) {
+	case map[string]any, *node, *relationship, *path:
+		return false
+	case []any:
+		for _, e := range v {
+			if _, nested := e.([]any); nested || e == nil || !isStorable(e) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func elementID(id int64) string {
+	return "4:memdb:" + strconv.FormatInt(id, 10)
+}
+
+// toDriver converts a value to the representation of the driver.
+func toDriver(v any) any {
+	switch v := v.(type) {
+	case *node:
+		return toDriverNode(v)
+	case *relationship:
+		return toDriverRel(v)
+	case *path:
+		p := neo4j.Path{
+			Nodes:         make([]neo4j.Node, len(v.nodes)),
+			Relationships: make([]neo4j.Relationship, len(v.rels)),
+		}
+		for i, n := range v.nodes {
+			p.Nodes[i] = toDriverNode(n)
+		}
+		for i, r := range v.rels {
+			p.Relationships[i] = toDriverRel(r)
+		}
+		return p
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = toDriver(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = toDriver(e)
+		}
+		return out
+	}
+	return v
+}
+
+func toDriverNode(n *node) neo4j.Node {
+	return neo4j.Node{
+		ElementId: elementID(n.id),
+		Labels:    slices.Clone(n.labels),
+		Props:     toDriver(n.props).(map[string]any),
+	}
+}
+
+func toDriverRel(r *relationship) neo4j.Relationship {
+	return neo4j.Relationship{
+		ElementId:      elementID(r.id),
+		StartElementId: elementID(r.start),
+		EndElementId:   elementID(r.end),
+		Type:           r.typ,
+		Props:          toDriver(r.props).(map[string]any),
+	}
+}
@@ -4,36 +4,66 @@ import (
 	"context"
 	"errors"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/goccy/go-json"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"golang.org/x/sync/semaphore"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
 
 	"github.com/rlch/neogo/internal"
 )
 
-// NewMock creates a mock neogo [Driver] for testing.
-func NewMock() mockDriver {
+// mockMaxRetries is the number of times ExecuteRead and ExecuteWrite retry
+// work which fails with a retriable error.
+const mockMaxRetries = 10
+
+var errMockTxClosed = errors.New("transaction is closed")
+
+// NewMock creates a mock neogo [Driver] for testing. If the driver can't be
+// configured, such as when the types it's configured with are ambiguous,
+// queries run with it return the error.
+func NewMock(configurers ...Configurer) mockDriver {
+	cfg := &Config{}
+	for _, c := range configurers {
+		c(cfg)
+	}
 	m := &mockBindings{}
+	d := &driver{
+		db: &mockNeo4jDriver{
+			mockBindings:    m,
+			bookmarkManager: neo4j.NewBookmarkManager(neo4j.BookmarkManagerConfig{}),
+		},
+		sessionPool: newSessionPool(100, 0),
+		idGenerator: cfg.IDGenerator,
+	}
+	m.err = d.configure(cfg)
+	m.registry = &d.registry
 	return &mockDriverImpl{
 		mockBindings: m,
-		driver: &driver{
-			db: &mockNeo4jDriver{
-				mockBindings: m,
-			},
-			sessionSemaphore: semaphore.NewWeighted(100), // Default semaphore for testing
-		},
+		driver:       d,
 	}
 }
 
 type (
 	mockBindings struct {
 		Current *mockBindingsNode
+
+		// registry encodes bound nodes and relationships.
+		registry *registry
+		// err is the error configuring the mock, returned by every query.
+		err error
+
+		mu           sync.Mutex
+		expectations expectations
+		transactions []*MockTransaction
 	}
 	mockBindingsNode struct {
 		Single  map[string]any
 		Records []map[string]any
 		Next    *mockBindingsNode
+
+		summary *MockSummary
 	}
 	mockDriver interface {
 		Driver
@@ -41,6 +71,33 @@ type (
 		Bind(record map[string]any)
 		BindRecords(records []map[string]any)
 		Clear()
+
+		// ExpectQuery expects a query matching query to be run. query may be a
+		// regular expression as a string or [*regexp.Regexp], a [QueryMatcher]
+		// or a func(cypher string) bool.
+		//
+		// Queries are matched against expectations in any order, and only when
+		// no expectation matches are the responses given by Bind and
+		// BindRecords used. Expectations should be configured before queries
+		// that may match them are run.
+		ExpectQuery(query any) *Expectation
+		// ExpectationsWereMet returns an error describing the expectations
+		// that queries haven't met.
+		ExpectationsWereMet() error
+
+		// Transactions returns the transactions run with the mock, in the order
+		// they began. Each attempt of work retried by ExecuteRead and
+		// ExecuteWrite is a separate transaction, as is each query run outside
+		// of a transaction.
+		Transactions() []MockTransaction
+	}
+
+	// MockTransaction is a transaction run with a mock [Driver].
+	MockTransaction struct {
+		// Queries are the Cypher queries run in the transaction, in order.
+		Queries    []string
+		Committed  bool
+		RolledBack bool
 	}
 	mockDriverImpl struct {
 		*mockBindings
@@ -49,6 +106,7 @@ type (
 
 	mockNeo4jDriver struct {
 		*mockBindings
+		bookmarkManager neo4j.BookmarkManager
 	}
 	mockNeo4jSession struct {
 		*mockBindings
@@ -57,24 +115,54 @@ type (
 	mockNeo4jTx struct {
 		*mockBindings
 		neo4j.ManagedTransaction
+		tx *MockTransaction
+	}
+	mockNeo4jExplicitTx struct {
+		*mockBindings
+		neo4j.ExplicitTransaction
+		tx *MockTransaction
 	}
 	mockNeo4jResult struct {
 		neo4j.ResultWithContext
 		records []*neo4j.Record
 		cursor  int
 		started bool
+		summary neo4j.ResultSummary
+	}
+
+	mockSummary struct {
+		query         mockQuery
+		statementType neo4j.StatementType
+		counters      mockCounters
+		notifications []neo4j.Notification
+		plan          *MockPlan
+	}
+	mockQuery struct {
+		text   string
+		params map[string]any
 	}
+	mockCounters     struct{ c MockCounters }
+	mockServerInfo   struct{}
+	mockDatabase     struct{}
+	mockNotification struct{ n MockNotification }
+	mockPosition     struct{ n MockNotification }
+	mockPlan         struct{ p MockPlan }
+	mockProfiledPlan struct{ p MockPlan }
 )
 
 var (
-	_ mockDriver               = (*mockDriverImpl)(nil)
-	_ neo4j.DriverWithContext  = (*mockNeo4jDriver)(nil)
-	_ neo4j.SessionWithContext = (*mockNeo4jSession)(nil)
-	_ neo4j.ManagedTransaction = (*mockNeo4jTx)(nil)
-	_ neo4j.ResultWithContext  = (*mockNeo4jResult)(nil)
+	_ mockDriver                = (*mockDriverImpl)(nil)
+	_ neo4j.DriverWithContext   = (*mockNeo4jDriver)(nil)
+	_ neo4j.SessionWithContext  = (*mockNeo4jSession)(nil)
+	_ neo4j.ManagedTransaction  = (*mockNeo4jTx)(nil)
+	_ neo4j.ExplicitTransaction = (*mockNeo4jExplicitTx)(nil)
+	_ neo4j.ResultWithContext   = (*mockNeo4jResult)(nil)
+	_ neo4j.ResultSummary       = (*mockSummary)(nil)
 )
 
 func (d *mockBindings) Bind(m map[string]any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.Current == nil {
 		d.Current = &mockBindingsNode{
 			Single: m,
@@ -89,6 +177,8 @@ func (d *mockBindings) Bind(m map[string]any) {
 }
 
 func (d *mockBindings) BindRecords(m []map[string]any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.Current == nil {
 		d.Current = &mockBindingsNode{
 			Records: m,
@@ -102,16 +192,57 @@ func (d *mockBindings) BindRecords(m []map[string]any) {
 	node.Next = &mockBindingsNode{Records: m}
 }
 
+// Clear removes all bindings, expectations and recorded transactions.
 func (d *mockBindings) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.Current = nil
+	d.expectations = nil
+	d.transactions = nil
+}
+
+func (d *mockBindings) Transactions() []MockTransaction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	txs := make([]MockTransaction, len(d.transactions))
+	for i, tx := range d.transactions {
+		txs[i] = *tx
+		txs[i].Queries = append([]string(nil), tx.Queries...)
+	}
+	return txs
+}
+
+// begin records the start of a transaction.
+func (d *mockBindings) begin() *MockTransaction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx := &MockTransaction{}
+	d.transactions = append(d.transactions, tx)
+	return tx
+}
+
+// end commits tx, or rolls it back if rollback is true. It returns an error if
+// tx has already ended.
+func (d *mockBindings) end(tx *MockTransaction, rollback bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if tx.Committed || tx.RolledBack {
+		return errMockTxClosed
+	}
+	if rollback {
+		tx.RolledBack = true
+	} else {
+		tx.Committed = true
+	}
+	return nil
 }
 
 func (d *mockNeo4jDriver) ExecuteQueryBookmarkManager() neo4j.BookmarkManager {
-	panic(errors.New("not implemented"))
+	return d.bookmarkManager
 }
 
 func (d *mockNeo4jDriver) Target() url.URL {
-	panic(errors.New("not implemented"))
+	return url.URL{Scheme: "mock"}
 }
 
 func (d *mockNeo4jDriver) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
@@ -131,11 +262,11 @@ func (d *mockNeo4jDriver) Close(ctx context.Context) error {
 }
 
 func (d *mockNeo4jDriver) IsEncrypted() bool {
-	panic(errors.New("not implemented"))
+	return false
 }
 
 func (d *mockNeo4jDriver) GetServerInfo(ctx context.Context) (neo4j.ServerInfo, error) {
-	panic(errors.New("not implemented"))
+	return mockServerInfo{}, nil
 }
 
 func (s *mockNeo4jSession) LastBookmarks() neo4j.Bookmarks {
@@ -143,21 +274,51 @@ func (s *mockNeo4jSession) LastBookmarks() neo4j.Bookmarks {
 }
 
 func (s *mockNeo4jSession) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
-	panic(errors.New("not implemented"))
+	return &mockNeo4jExplicitTx{mockBindings: s.mockBindings, tx: s.begin()}, nil
 }
 
 func (s *mockNeo4jSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
-	_, err := work(&mockNeo4jTx{mockBindings: s.mockBindings})
-	return nil, err
+	return s.execute(ctx, work)
 }
 
 func (s *mockNeo4jSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
-	_, err := work(&mockNeo4jTx{mockBindings: s.mockBindings})
-	return nil, err
+	return s.execute(ctx, work)
+}
+
+// execute runs work in a transaction, which is committed if work succeeds and
+// rolled back otherwise. Like the driver, work failing with a retriable error
+// is retried in a new transaction.
+func (s *mockNeo4jSession) execute(ctx context.Context, work neo4j.ManagedTransactionWork) (any, error) {
+	for attempt := 1; ; attempt++ {
+		tx := s.begin()
+		out, err := work(&mockNeo4jTx{mockBindings: s.mockBindings, tx: tx})
+		if err == nil {
+			return out, s.end(tx, false)
+		}
+		_ = s.end(tx, true)
+		if !isRetriable(err) || attempt == mockMaxRetries {
+			return nil, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Join(err, ctxErr)
+		}
+	}
+}
+
+// isRetriable reports whether the driver would retry work failing with err.
+func isRetriable(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return neo4jErr.IsRetriable()
+	}
+	var connErr *neo4j.ConnectivityError
+	return errors.As(err, &connErr)
 }
 
 func (s *mockNeo4jSession) Run(ctx context.Context, cypher string, params map[string]any, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
-	panic(errors.New("not implemented"))
+	tx := s.begin()
+	r, err := s.run(tx, cypher, params)
+	return r, errors.Join(err, s.end(tx, err != nil))
 }
 
 func (s *mockNeo4jSession) Close(ctx context.Context) error {
@@ -165,60 +326,49 @@ func (s *mockNeo4jSession) Close(ctx context.Context) error {
 }
 
 func (t *mockNeo4jTx) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
-	r := &mockNeo4jResult{}
-	toRecord := func(m map[string]any) (*neo4j.Record, error) {
-		n := len(m)
-		rec := &neo4j.Record{
-			Keys:   make([]string, n),
-			Values: make([]any, n),
+	return t.run(t.tx, cypher, params)
+}
+
+func (t *mockNeo4jExplicitTx) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	return t.run(t.tx, cypher, params)
+}
+
+func (t *mockNeo4jExplicitTx) Commit(ctx context.Context) error {
+	return t.end(t.tx, false)
+}
+
+func (t *mockNeo4jExplicitTx) Rollback(ctx context.Context) error {
+	return t.end(t.tx, true)
+}
+
+// Close rolls back the transaction if it hasn't been committed or rolled back.
+func (t *mockNeo4jExplicitTx) Close(ctx context.Context) error {
+	_ = t.end(t.tx, true)
+	return nil
+}
+
+// run runs the query cypher in tx, if it's tracked, responding with the
+// bindings of the expectation matching the query or otherwise the next
+// bindings.
+func (d *mockBindings) run(tx *MockTransaction, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	if tx != nil {
+		d.mu.Lock()
+		ended := tx.Committed || tx.RolledBack
+		if !ended {
+			tx.Queries = append(tx.Queries, cypher)
 		}
-		var i int
-		for k, v := range m {
-			rec.Keys[i] = k
-			if _, ok := v.(INode); ok {
-				labels := internal.ExtractNodeLabels(v)
-				var props map[string]any
-				bytes, err := json.Marshal(v)
-				if err != nil {
-					return nil, err
-				}
-				err = json.Unmarshal(bytes, &props)
-				if err != nil {
-					return nil, err
-				}
-				rec.Values[i] = neo4j.Node{
-					Labels: labels,
-					Props:  props,
-				}
-			} else if _, ok := v.(IRelationship); ok {
-				typ := internal.ExtractRelationshipType(v)
-				var props map[string]any
-				bytes, err := json.Marshal(v)
-				if err != nil {
-					return nil, err
-				}
-				err = json.Unmarshal(bytes, &props)
-				if err != nil {
-					return nil, err
-				}
-				rec.Values[i] = neo4j.Relationship{
-					Type:  typ,
-					Props: props,
-				}
-			} else {
-				rec.Values[i] = v
-			}
-			i++
+		d.mu.Unlock()
+		if ended {
+			return nil, errMockTxClosed
 		}
-		return rec, nil
 	}
-	if t.Current == nil {
-		panic(errors.New("mock client used without bindings for all transactions"))
+	bindings, err := d.respond(cypher, params)
+	if err != nil {
+		return nil, err
 	}
-	bindings := *t.Current
-	t.Current = t.Current.Next
+	r := &mockNeo4jResult{summary: newMockSummary(cypher, params, bindings.summary)}
 	if bindings.Single != nil {
-		rec, err := toRecord(bindings.Single)
+		rec, err := d.toRecord(bindings.Single)
 		if err != nil {
 			return nil, err
 		}
@@ -226,7 +376,7 @@ func (t *mockNeo4jTx) Run(ctx context.Context, cypher string, params map[string]
 	} else if bindings.Records != nil {
 		r.records = make([]*neo4j.Record, len(bindings.Records))
 		for i, recMap := range bindings.Records {
-			rec, err := toRecord(recMap)
+			rec, err := d.toRecord(recMap)
 			if err != nil {
 				return nil, err
 			}
@@ -236,6 +386,87 @@ func (t *mockNeo4jTx) Run(ctx context.Context, cypher string, params map[string]
 	return r, nil
 }
 
+// respond returns the bindings of the expectation matching the query, or
+// otherwise the next bindings.
+func (d *mockBindings) respond(cypher string, params map[string]any) (mockBindingsNode, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return mockBindingsNode{}, d.err
+	}
+	if e, err := d.expectations.match(d.registry, cypher, params); e != nil || err != nil {
+		if err != nil {
+			return mockBindingsNode{}, err
+		}
+		return mockBindingsNode{Records: e.records, summary: e.summary}, e.err
+	}
+	if d.Current == nil {
+		if len(d.expectations) > 0 {
+			return mockBindingsNode{}, unexpectedQuery(cypher, params)
+		}
+		panic(errors.New("mock client used without bindings for all transactions"))
+	}
+	bindings := *d.Current
+	d.Current = d.Current.Next
+	return bindings, nil
+}
+
+// toRecord converts a record of bound values into a [neo4j.Record], converting
+// nodes and relationships as they'd be returned by Neo4j.
+func (d *mockBindings) toRecord(m map[string]any) (*neo4j.Record, error) {
+	n := len(m)
+	rec := &neo4j.Record{
+		Keys:   make([]string, n),
+		Values: make([]any, n),
+	}
+	var i int
+	for k, v := range m {
+		rec.Keys[i] = k
+		if _, ok := v.(INode); ok {
+			props, err := encodeProps(d.registry, v)
+			if err != nil {
+				return nil, err
+			}
+			rec.Values[i] = neo4j.Node{
+				ElementId: encodeMetadata(v)[internal.MetadataElementID],
+				Labels:    internal.ExtractNodeLabels(v),
+				Props:     props,
+			}
+		} else if _, ok := v.(IRelationship); ok {
+			props, err := encodeProps(d.registry, v)
+			if err != nil {
+				return nil, err
+			}
+			meta := encodeMetadata(v)
+			rec.Values[i] = neo4j.Relationship{
+				ElementId:      meta[internal.MetadataElementID],
+				StartElementId: meta[internal.MetadataStartElementID],
+				EndElementId:   meta[internal.MetadataEndElementID],
+				Type:           internal.ExtractRelationshipType(v),
+				Props:          props,
+			}
+		} else {
+			rec.Values[i] = v
+		}
+		i++
+	}
+	return rec, nil
+}
+
+// encodeProps encodes the properties of a node or relationship as they would be
+// stored by Neo4j.
+func encodeProps(r *registry, v any) (map[string]any, error) {
+	if r == nil {
+		r = &registry{}
+	}
+	encoded, err := r.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	props, _ := encoded.(map[string]any)
+	return props, nil
+}
+
 func (r *mockNeo4jResult) Keys() ([]string, error) {
 	return r.records[r.cursor].Keys, nil
 }
@@ -290,9 +521,130 @@ func (r *mockNeo4jResult) Single(ctx context.Context) (*neo4j.Record, error) {
 }
 
 func (r *mockNeo4jResult) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
-	panic(errors.New("not implemented"))
+	r.cursor, r.started = len(r.records), true
+	return r.summary, nil
 }
 
 func (r *mockNeo4jResult) IsOpen() bool {
 	return true
 }
+
+func newMockSummary(cypher string, params map[string]any, s *MockSummary) *mockSummary {
+	if s == nil {
+		s = &MockSummary{}
+	}
+	summary := &mockSummary{
+		query:         mockQuery{text: cypher, params: params},
+		statementType: s.StatementType,
+		counters:      mockCounters{s.Counters},
+		plan:          s.Plan,
+	}
+	if summary.statementType == neo4j.StatementTypeUnknown {
+		if isWrite, _ := params["__isWrite"].(bool); isWrite {
+			summary.statementType = neo4j.StatementTypeReadWrite
+		} else {
+			summary.statementType = neo4j.StatementTypeReadOnly
+		}
+	}
+	for _, n := range s.Notifications {
+		summary.notifications = append(summary.notifications, mockNotification{n})
+	}
+	return summary
+}
+
+func (s *mockSummary) Server() neo4j.ServerInfo                  { return mockServerInfo{} }
+func (s *mockSummary) Query() neo4j.Query                        { return s.query }
+func (s *mockSummary) StatementType() neo4j.StatementType        { return s.statementType }
+func (s *mockSummary) Counters() neo4j.Counters                  { return s.counters }
+func (s *mockSummary) Notifications() []neo4j.Notification       { return s.notifications }
+func (s *mockSummary) GqlStatusObjects() []neo4j.GqlStatusObject { return nil }
+func (s *mockSummary) ResultAvailableAfter() time.Duration       { return 0 }
+func (s *mockSummary) ResultConsumedAfter() time.Duration        { return 0 }
+func (s *mockSummary) Database() neo4j.DatabaseInfo              { return mockDatabase{} }
+
+func (s *mockSummary) Plan() neo4j.Plan {
+	if s.plan == nil {
+		return nil
+	}
+	return mockPlan{*s.plan}
+}
+
+func (s *mockSummary) Profile() neo4j.ProfiledPlan {
+	if s.plan == nil || !strings.HasPrefix(s.query.text, "PROFILE ") {
+		return nil
+	}
+	return mockProfiledPlan{*s.plan}
+}
+
+func (p mockPlan) Operator() string          { return p.p.Operator }
+func (p mockPlan) Arguments() map[string]any { return p.p.Arguments }
+func (p mockPlan) Identifiers() []string     { return p.p.Identifiers }
+func (p mockPlan) Children() []neo4j.Plan {
+	var children []neo4j.Plan
+	for _, child := range p.p.Children {
+		children = append(children, mockPlan{child})
+	}
+	return children
+}
+
+func (p mockProfiledPlan) Operator() string           { return p.p.Operator }
+func (p mockProfiledPlan) Arguments() map[string]any  { return p.p.Arguments }
+func (p mockProfiledPlan) Identifiers() []string      { return p.p.Identifiers }
+func (p mockProfiledPlan) DbHits() int64              { return p.p.DbHits }
+func (p mockProfiledPlan) Records() int64             { return p.p.Rows }
+func (p mockProfiledPlan) PageCacheMisses() int64     { return p.p.PageCacheMisses }
+func (p mockProfiledPlan) PageCacheHits() int64       { return p.p.PageCacheHits }
+func (p mockProfiledPlan) PageCacheHitRatio() float64 { return p.p.PageCacheHitRatio }
+func (p mockProfiledPlan) Time() int64                { return p.p.Time }
+func (p mockProfiledPlan) Children() []neo4j.ProfiledPlan {
+	var children []neo4j.ProfiledPlan
+	for _, child := range p.p.Children {
+		children = append(children, mockProfiledPlan{child})
+	}
+	return children
+}
+
+func (q mockQuery) Text() string                           { return q.text }
+func (q mockQuery) Parameters() map[string]any             { return q.params }
+func (mockServerInfo) Address() string                     { return "mock:7687" }
+func (mockServerInfo) Agent() string                       { return "neogo/mock" }
+func (mockServerInfo) ProtocolVersion() db.ProtocolVersion { return db.ProtocolVersion{Major: 5} }
+func (mockDatabase) Name() string                          { return "neo4j" }
+
+func (c mockCounters) ContainsUpdates() bool {
+	u := c.c
+	u.SystemUpdates = 0
+	return u != MockCounters{}
+}
+func (c mockCounters) NodesCreated() int           { return c.c.NodesCreated }
+func (c mockCounters) NodesDeleted() int           { return c.c.NodesDeleted }
+func (c mockCounters) RelationshipsCreated() int   { return c.c.RelationshipsCreated }
+func (c mockCounters) RelationshipsDeleted() int   { return c.c.RelationshipsDeleted }
+func (c mockCounters) PropertiesSet() int          { return c.c.PropertiesSet }
+func (c mockCounters) LabelsAdded() int            { return c.c.LabelsAdded }
+func (c mockCounters) LabelsRemoved() int          { return c.c.LabelsRemoved }
+func (c mockCounters) IndexesAdded() int           { return c.c.IndexesAdded }
+func (c mockCounters) IndexesRemoved() int         { return c.c.IndexesRemoved }
+func (c mockCounters) ConstraintsAdded() int       { return c.c.ConstraintsAdded }
+func (c mockCounters) ConstraintsRemoved() int     { return c.c.ConstraintsRemoved }
+func (c mockCounters) SystemUpdates() int          { return c.c.SystemUpdates }
+func (c mockCounters) ContainsSystemUpdates() bool { return c.c.SystemUpdates > 0 }
+
+func (n mockNotification) Code() string        { return n.n.Code }
+func (n mockNotification) Title() string       { return n.n.Title }
+func (n mockNotification) Description() string { return n.n.Description }
+func (n mockNotification) Position() neo4j.InputPosition {
+	if n.n.Line == 0 {
+		return nil
+	}
+	return mockPosition(n)
+}
+func (n mockNotification) Severity() string                          { return string(n.n.Severity) }
+func (n mockNotification) RawSeverityLevel() string                  { return string(n.n.Severity) }
+func (n mockNotification) RawCategory() string                       { return string(n.n.Category) }
+func (n mockNotification) SeverityLevel() neo4j.NotificationSeverity { return n.n.Severity }
+func (n mockNotification) Category() neo4j.NotificationCategory      { return n.n.Category }
+
+func (p mockPosition) Offset() int { return p.n.Offset }
+func (p mockPosition) Line() int   { return p.n.Line }
+func (p mockPosition) Column() int { return p.n.Column }
@@ -2,10 +2,13 @@ package neogo
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,4 +59,141 @@ func TestMockDriver(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "value", result)
 	})
+
+	t.Run("mock with heterogeneous nodes", func(t *testing.T) {
+		d := NewMock(WithTypes(&tests.Person{}, &tests.BaseOrganism{}))
+		d.BindRecords([]map[string]any{
+			{"n": &tests.Person{Name: "Jessie"}},
+			{"n": &tests.Dog{Borfs: true}},
+		})
+
+		var nodes []INode
+		err := d.Exec().
+			Match(db.Node("n")).
+			Return(db.Qual(&nodes, "n")).
+			Run(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []INode{
+			&tests.Person{Name: "Jessie"},
+			&tests.Dog{Borfs: true},
+		}, nodes)
+	})
+
+	createPerson := func(d Driver, name string) error {
+		p := tests.Person{Name: name}
+		return d.Exec().Create(db.Node(db.Qual(&p, "p"))).Run(ctx)
+	}
+
+	t.Run("tracks explicit transactions", func(t *testing.T) {
+		d := NewMock()
+		d.Bind(nil)
+		d.Bind(nil)
+		sess, err := d.WriteSession(ctx)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, sess.Close(ctx)) }()
+
+		tx, err := sess.BeginTransaction(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Run(func(begin func() Query) error {
+			return begin().Cypher("CREATE (:A)").Run(ctx)
+		}))
+		require.NoError(t, tx.Commit(ctx))
+		assert.Error(t, tx.Rollback(ctx), "already committed")
+		require.NoError(t, tx.Close(ctx))
+
+		tx, err = sess.BeginTransaction(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Run(func(begin func() Query) error {
+			return begin().Cypher("CREATE (:B)").Run(ctx)
+		}))
+		require.NoError(t, tx.Close(ctx))
+
+		assert.Equal(t, []MockTransaction{
+			{Queries: []string{"CREATE (:A)"}, Committed: true},
+			{Queries: []string{"CREATE (:B)"}, RolledBack: true},
+		}, d.Transactions())
+	})
+
+	t.Run("returns summaries", func(t *testing.T) {
+		d := NewMock()
+		d.ExpectQuery(`CREATE`).WillReturnSummary(MockSummary{
+			Counters: MockCounters{NodesCreated: 1, PropertiesSet: 1},
+			Notifications: []MockNotification{{
+				Code:     "Neo.ClientNotification.Statement.CartesianProduct",
+				Severity: neo4j.Warning,
+				Line:     1,
+				Column:   8,
+			}},
+		})
+		d.Bind(nil)
+
+		p := tests.Person{Name: "Jessie"}
+		summary, err := d.Exec().Create(db.Node(db.Qual(&p, "p"))).RunSummary(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.StatementTypeReadWrite, summary.StatementType())
+		assert.True(t, summary.Counters().ContainsUpdates())
+		assert.Equal(t, 1, summary.Counters().NodesCreated())
+		require.Len(t, summary.Notifications(), 1)
+		n := summary.Notifications()[0]
+		assert.Equal(t, neo4j.Warning, n.SeverityLevel())
+		assert.Equal(t, 8, n.Position().Column())
+
+		summary, err = d.Exec().Cypher("RETURN 1").RunSummary(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.StatementTypeReadOnly, summary.StatementType())
+		assert.False(t, summary.Counters().ContainsUpdates())
+		assert.Empty(t, summary.Notifications())
+	})
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		d := NewMock()
+		d.ExpectQuery(`CREATE`).WillReturnError(MockTransientError("deadlock")).Times(1)
+		d.ExpectQuery(`CREATE`).WillReturnError(MockNetworkError("connection reset")).Times(1)
+		d.ExpectQuery(`CREATE`)
+
+		require.NoError(t, createPerson(d, "Jessie"))
+		assert.NoError(t, d.ExpectationsWereMet())
+		txs := d.Transactions()
+		require.Len(t, txs, 3)
+		assert.True(t, txs[0].RolledBack)
+		assert.True(t, txs[1].RolledBack)
+		assert.True(t, txs[2].Committed)
+	})
+
+	t.Run("gives up retrying", func(t *testing.T) {
+		d := NewMock()
+		d.ExpectQuery(`CREATE`).WillReturnError(MockTransientError("deadlock")).Times(mockMaxRetries)
+
+		var neo4jErr *neo4j.Neo4jError
+		require.ErrorAs(t, createPerson(d, "Jessie"), &neo4jErr)
+		assert.True(t, neo4jErr.IsRetriableTransient())
+		assert.Len(t, d.Transactions(), mockMaxRetries)
+	})
+
+	t.Run("doesn't retry constraint errors", func(t *testing.T) {
+		d := NewMock()
+		d.ExpectQuery(`CREATE`).WillReturnError(MockConstraintError("already exists"))
+
+		var neo4jErr *neo4j.Neo4jError
+		require.ErrorAs(t, createPerson(d, "Jessie"), &neo4jErr)
+		assert.Equal(t, "Neo.ClientError.Schema.ConstraintValidationFailed", neo4jErr.Code)
+		assert.Len(t, d.Transactions(), 1)
+
+		d.ExpectQuery(`CREATE`).WillReturnError(errors.New("boom"))
+		assert.Error(t, createPerson(d, "Walter"))
+		assert.Len(t, d.Transactions(), 2)
+	})
+
+	t.Run("runs auto-commit queries", func(t *testing.T) {
+		d := NewMock()
+		d.Bind(map[string]any{"n": 1})
+		s := d.DB().NewSession(ctx, neo4j.SessionConfig{})
+		res, err := s.Run(ctx, "RETURN 1 AS n", nil)
+		require.NoError(t, err)
+		record, err := res.Single(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []any{1}, record.Values)
+		require.NoError(t, s.Close(ctx))
+		assert.Equal(t, []MockTransaction{{Queries: []string{"RETURN 1 AS n"}, Committed: true}}, d.Transactions())
+	})
 }
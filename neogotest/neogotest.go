@@ -0,0 +1,178 @@
+// Package neogotest provides snapshot testing for queries built with neogo.
+//
+// [Snapshot] compiles a query without running it, and compares its Cypher,
+// parameters and bindings with a golden file in testdata, named after the
+// test:
+//
+//	func TestPeople(t *testing.T) {
+//		var p Person
+//		neogotest.Snapshot(t, neogotest.Query().
+//			Match(db.Node(db.Qual(&p, "p"))).
+//			Return(&p))
+//	}
+//
+// Golden files are created or updated by running the tests with the
+// NEOGOTEST_UPDATE environment variable set:
+//
+//	NEOGOTEST_UPDATE=1 go test ./...
+//
+// neogotest doesn't register a flag of its own, as it would conflict with
+// packages declaring their own -update flag. Where a package does declare a
+// boolean -update flag, golden files are also updated when it's set.
+package neogotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/internal"
+	"github.com/rlch/neogo/query"
+)
+
+type compiler interface {
+	Compile() (*internal.CompiledCypher, error)
+}
+
+// Query returns a [neogo.Query] which isn't attached to a Neo4j server, for
+// building queries to snapshot. configurers register the types used by the
+// query, as with [neogo.New].
+func Query(configurers ...neogo.Configurer) neogo.Query {
+	return neogo.NewMock(configurers...).Exec()
+}
+
+// Snapshot compares the compiled query q with the golden file
+// testdata/<test name>.golden, failing t with a diff if they differ.
+//
+// If NEOGOTEST_UPDATE or the test binary's -update flag is set, the golden
+// file is written instead.
+func Snapshot(t testing.TB, q query.Runner) {
+	t.Helper()
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name())+".golden")
+	snapshot(t, path, q, updating(os.Getenv("NEOGOTEST_UPDATE"), flag.Lookup("update")))
+}
+
+// updating reports whether golden files should be written, given the value of
+// NEOGOTEST_UPDATE and the -update flag, if the test binary declares one.
+func updating(env string, update *flag.Flag) bool {
+	if env != "" {
+		return true
+	}
+	if update == nil {
+		return false
+	}
+	getter, ok := update.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	set, _ := getter.Get().(bool)
+	return set
+}
+
+func snapshot(t testing.TB, path string, q query.Runner, update bool) {
+	t.Helper()
+	c, ok := q.(compiler)
+	if !ok {
+		t.Fatalf("neogotest: cannot compile %T", q)
+		return
+	}
+	cy, err := c.Compile()
+	if err != nil {
+		t.Fatalf("neogotest: %v", err)
+		return
+	}
+	got, err := format(cy)
+	if err != nil {
+		t.Fatalf("neogotest: %v", err)
+		return
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("neogotest: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("neogotest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.Fatalf("neogotest: golden file %s doesn't exist, run the test with NEOGOTEST_UPDATE=1 to create it", path)
+			return
+		}
+		t.Fatalf("neogotest: %v", err)
+		return
+	}
+	if string(want) != got {
+		t.Errorf("neogotest: query doesn't match %s, run the test with NEOGOTEST_UPDATE=1 to update it:\n%s", path, diff(string(want), got))
+	}
+}
+
+// format renders a compiled query as it's stored in golden files: its Cypher,
+// then its parameters sorted by name with JSON values, then the names and
+// types of its bindings sorted by name.
+func format(cy *internal.CompiledCypher) (string, error) {
+	var b strings.Builder
+	b.WriteString("-- cypher --\n")
+	b.WriteString(cy.Cypher)
+	b.WriteString("\n")
+
+	if len(cy.Parameters) > 0 {
+		b.WriteString("-- params --\n")
+		for _, name := range sortedKeys(cy.Parameters) {
+			v, err := marshal(cy.Parameters[name])
+			if err != nil {
+				return "", fmt.Errorf("cannot format parameter %q: %w", name, err)
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+
+	if len(cy.Bindings) > 0 {
+		b.WriteString("-- bindings --\n")
+		names := make([]string, 0, len(cy.Bindings))
+		for name := range cy.Bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			binding := cy.Bindings[name]
+			typ := "<nil>"
+			if binding.IsValid() {
+				typ = binding.Type().String()
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, typ)
+		}
+	}
+	return b.String(), nil
+}
+
+// marshal encodes v as JSON on a single line. Map keys are sorted, so values
+// are rendered the same regardless of iteration order.
+func marshal(v any) (string, error) {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
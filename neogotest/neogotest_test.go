@@ -0,0 +1,115 @@
+package neogotest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+// fakeT records the failures reported by snapshot.
+type fakeT struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func person(name string) *tests.Person {
+	p := &tests.Person{Name: name}
+	p.ID = "1"
+	return p
+}
+
+// update is declared as packages using golden files commonly do, which would
+// panic if neogotest registered the flag itself. Snapshot honors it.
+var _ = flag.Bool("update", false, "update golden files")
+
+func TestSnapshot(t *testing.T) {
+	t.Run("matches golden file", func(t *testing.T) {
+		var p tests.Person
+		Snapshot(t, Query().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"name": "$name"}))).
+			Where(db.Cond("p.age", ">", db.NamedParam(18, "age"))).
+			Return(&p))
+	})
+
+	t.Run("writes and compares golden files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "query.golden")
+		p := person("Jessie")
+		snapshot(t, path, Query().Create(db.Node(db.Qual(p, "p"))).Return(p), true)
+		golden, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `-- cypher --
+CREATE (p:Person {id: $p_id, name: $p_name})
+RETURN p
+-- params --
+p_id: "1"
+p_name: "Jessie"
+-- bindings --
+p: *tests.Person
+`, string(golden))
+
+		ft := &fakeT{TB: t}
+		p = person("Jessie")
+		snapshot(ft, path, Query().Create(db.Node(db.Qual(p, "p"))).Return(p), false)
+		assert.Empty(t, ft.errors)
+
+		ft = &fakeT{TB: t}
+		p = person("Walter")
+		snapshot(ft, path, Query().Create(db.Node(db.Qual(p, "p"))).Return(p), false)
+		require.Len(t, ft.errors, 1)
+		assert.False(t, ft.fatal)
+		assert.Contains(t, ft.errors[0], `  p_id: "1"
+- p_name: "Jessie"
++ p_name: "Walter"
+  -- bindings --`)
+	})
+
+	t.Run("fails without golden file", func(t *testing.T) {
+		var p tests.Person
+		ft := &fakeT{TB: t}
+		snapshot(ft, filepath.Join(t.TempDir(), "missing.golden"), Query().
+			Match(db.Node(db.Qual(&p, "p"))).
+			Return(&p), false)
+		require.Len(t, ft.errors, 1)
+		assert.True(t, ft.fatal)
+		assert.Contains(t, ft.errors[0], "NEOGOTEST_UPDATE=1")
+	})
+}
+
+func TestUpdating(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Bool("update", false, "")
+	flags.String("str", "true", "")
+
+	assert.False(t, updating("", nil))
+	assert.True(t, updating("1", nil))
+	assert.False(t, updating("", flags.Lookup("update")))
+	assert.True(t, updating("1", flags.Lookup("update")))
+	require.NoError(t, flags.Parse([]string{"-update"}))
+	assert.True(t, updating("", flags.Lookup("update")))
+	assert.False(t, updating("", flags.Lookup("str")))
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "  a\n- b\n+ c\n  d\n+ e\n", diff("a\nb\nd\n", "a\nc\nd\ne\n"))
+	assert.Equal(t, "  a\n", diff("a\n", "a\n"))
+}
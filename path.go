@@ -0,0 +1,131 @@
+package neogo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Path is a path through the graph, binding the nodes of a [neo4j.Path] to N
+// and its relationships to R.
+//
+// N may be a concrete node, a pointer to one, or an interface implementing
+// [IAbstract], in which case each node is resolved to its registered
+// implementer. Heterogeneous paths can use any to bind the underlying
+// [neo4j.Node] and [neo4j.Relationship] values.
+//
+//	var p neogo.Path[*Person, *Knows]
+//	err := d.Exec().
+//		Match(db.Path(db.Node(db.Qual(Person{}, "a")).To(Knows{}, Person{}), "p")).
+//		Return(db.Qual(&p, "p")).
+//		Run(ctx)
+//
+// When N is a pointer, a node visited more than once is bound to the same
+// pointer.
+type Path[N, R any] struct {
+	// Nodes are the nodes along the path, in order from its start to its end.
+	Nodes []N
+	// Relationships are the relationships along the path, where
+	// Relationships[i] connects Nodes[i] and Nodes[i+1].
+	Relationships []R
+	// Reversed records the direction of each relationship. Reversed[i] is true
+	// if Relationships[i] points from Nodes[i+1] to Nodes[i], against the
+	// direction of the path.
+	Reversed []bool
+}
+
+// Segment is a relationship along a [Path] and the nodes it connects, in the
+// direction of the relationship.
+type Segment[N, R any] struct {
+	Start        N
+	Relationship R
+	End          N
+}
+
+// pathBinder is implemented by pointers to [Path].
+type pathBinder interface {
+	bindPath(r *registry, path neo4j.Path) error
+}
+
+var rPathBinder = reflect.TypeOf((*pathBinder)(nil)).Elem()
+
+// Len returns the number of relationships along the path.
+func (p *Path[N, R]) Len() int {
+	return len(p.Relationships)
+}
+
+// Start returns the first node of the path.
+func (p *Path[N, R]) Start() (n N) {
+	if len(p.Nodes) > 0 {
+		n = p.Nodes[0]
+	}
+	return
+}
+
+// End returns the last node of the path.
+func (p *Path[N, R]) End() (n N) {
+	if len(p.Nodes) > 0 {
+		n = p.Nodes[len(p.Nodes)-1]
+	}
+	return
+}
+
+// Segments returns the relationships along the path with the nodes they
+// connect, oriented by the direction of each relationship.
+func (p *Path[N, R]) Segments() []Segment[N, R] {
+	segments := make([]Segment[N, R], len(p.Relationships))
+	for i, rel := range p.Relationships {
+		start, end := p.Nodes[i], p.Nodes[i+1]
+		if i < len(p.Reversed) && p.Reversed[i] {
+			start, end = end, start
+		}
+		segments[i] = Segment[N, R]{
+			Start:        start,
+			Relationship: rel,
+			End:          end,
+		}
+	}
+	return segments
+}
+
+func (p *Path[N, R]) bindPath(r *registry, path neo4j.Path) error {
+	if len(path.Nodes) != len(path.Relationships)+1 {
+		return fmt.Errorf(
+			"cannot bind path with %d nodes and %d relationships",
+			len(path.Nodes), len(path.Relationships),
+		)
+	}
+	nodes := make([]N, len(path.Nodes))
+	byElementID := make(map[string]int, len(path.Nodes))
+	for i, node := range path.Nodes {
+		if j, ok := byElementID[node.ElementId]; ok && node.ElementId != "" {
+			nodes[i] = nodes[j]
+			continue
+		}
+		if err := r.bindValue(node, reflect.ValueOf(&nodes[i])); err != nil {
+			return fmt.Errorf("cannot bind node %d of path: %w", i, err)
+		}
+		byElementID[node.ElementId] = i
+	}
+	rels := make([]R, len(path.Relationships))
+	reversed := make([]bool, len(path.Relationships))
+	for i, rel := range path.Relationships {
+		start, end := path.Nodes[i].ElementId, path.Nodes[i+1].ElementId
+		switch {
+		case rel.StartElementId == start && rel.EndElementId == end:
+		case rel.StartElementId == end && rel.EndElementId == start:
+			reversed[i] = true
+		default:
+			return fmt.Errorf(
+				"cannot bind path: relationship %d does not connect nodes %s and %s",
+				i, start, end,
+			)
+		}
+		if err := r.bindValue(rel, reflect.ValueOf(&rels[i])); err != nil {
+			return fmt.Errorf("cannot bind relationship %d of path: %w", i, err)
+		}
+	}
+	p.Nodes, p.Relationships, p.Reversed = nodes, rels, reversed
+	return nil
+}
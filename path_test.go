@@ -0,0 +1,140 @@
+package neogo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+)
+
+func personNode(elementID, name string) neo4j.Node {
+	return neo4j.Node{
+		ElementId: elementID,
+		Labels:    []string{"Person"},
+		Props:     map[string]any{"id": elementID, "name": name},
+	}
+}
+
+func knowsRel(start, end string, since int64) neo4j.Relationship {
+	return neo4j.Relationship{
+		ElementId:      start + "-" + end,
+		StartElementId: start,
+		EndElementId:   end,
+		Type:           "KNOWS",
+		Props:          map[string]any{"since": since},
+	}
+}
+
+func TestPath(t *testing.T) {
+	r := &registry{}
+	alice, bob, carol := personNode("a", "Alice"), personNode("b", "Bob"), personNode("c", "Carol")
+
+	t.Run("binds nodes and relationships in order", func(t *testing.T) {
+		var p Path[tests.Person, tests.Knows]
+		err := r.bindValue(neo4j.Path{
+			Nodes:         []neo4j.Node{alice, bob, carol},
+			Relationships: []neo4j.Relationship{knowsRel("a", "b", 2001), knowsRel("c", "b", 2002)},
+		}, reflect.ValueOf(&p))
+		require.NoError(t, err)
+
+		aliceP := tests.Person{Node: Node{ID: "a"}, Name: "Alice"}
+		bobP := tests.Person{Node: Node{ID: "b"}, Name: "Bob"}
+		carolP := tests.Person{Node: Node{ID: "c"}, Name: "Carol"}
+		assert.Equal(t, []tests.Person{aliceP, bobP, carolP}, p.Nodes)
+		assert.Equal(t, []tests.Knows{{Since: 2001}, {Since: 2002}}, p.Relationships)
+		assert.Equal(t, []bool{false, true}, p.Reversed)
+		assert.Equal(t, 2, p.Len())
+		assert.Equal(t, aliceP, p.Start())
+		assert.Equal(t, carolP, p.End())
+		assert.Equal(t, []Segment[tests.Person, tests.Knows]{
+			{Start: aliceP, Relationship: tests.Knows{Since: 2001}, End: bobP},
+			{Start: carolP, Relationship: tests.Knows{Since: 2002}, End: bobP},
+		}, p.Segments())
+	})
+
+	t.Run("shares pointers to nodes visited more than once", func(t *testing.T) {
+		var p Path[*tests.Person, *tests.Knows]
+		err := r.bindValue(neo4j.Path{
+			Nodes:         []neo4j.Node{alice, bob, alice},
+			Relationships: []neo4j.Relationship{knowsRel("a", "b", 2001), knowsRel("b", "a", 2002)},
+		}, reflect.ValueOf(&p))
+		require.NoError(t, err)
+		require.Len(t, p.Nodes, 3)
+		assert.Same(t, p.Nodes[0], p.Nodes[2])
+		assert.NotSame(t, p.Nodes[0], p.Nodes[1])
+		assert.Equal(t, []bool{false, false}, p.Reversed)
+	})
+
+	t.Run("resolves abstract nodes", func(t *testing.T) {
+		r := &registry{}
+		require.NoError(t, r.registerTypes(&tests.Human{}, &tests.Dog{}))
+		var p Path[tests.Organism, any]
+		rel := neo4j.Relationship{StartElementId: "h", EndElementId: "d", Type: "OWNS"}
+		err := r.bindValue(neo4j.Path{
+			Nodes: []neo4j.Node{
+				{ElementId: "h", Labels: []string{"Organism", "Human"}, Props: map[string]any{"name": "Raqeeb"}},
+				{ElementId: "d", Labels: []string{"Organism", "Pet", "Dog"}, Props: map[string]any{"borfs": true}},
+			},
+			Relationships: []neo4j.Relationship{rel},
+		}, reflect.ValueOf(&p))
+		require.NoError(t, err)
+		require.Len(t, p.Nodes, 2)
+		assert.Equal(t, &tests.Human{Name: "Raqeeb"}, p.Nodes[0])
+		assert.IsType(t, &tests.Dog{}, p.Nodes[1])
+		assert.Equal(t, []any{rel}, p.Relationships)
+	})
+
+	t.Run("binds paths within structs and slices", func(t *testing.T) {
+		var out struct {
+			Paths []Path[tests.Person, tests.Knows] `json:"paths"`
+		}
+		err := r.bindValue(map[string]any{
+			"paths": []any{
+				neo4j.Path{Nodes: []neo4j.Node{alice}},
+				neo4j.Path{
+					Nodes:         []neo4j.Node{alice, bob},
+					Relationships: []neo4j.Relationship{knowsRel("a", "b", 2001)},
+				},
+			},
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		require.Len(t, out.Paths, 2)
+		assert.Equal(t, 0, out.Paths[0].Len())
+		assert.Equal(t, "Alice", out.Paths[0].Start().Name)
+		assert.Equal(t, "Bob", out.Paths[1].End().Name)
+	})
+
+	t.Run("errors when relationships do not connect adjacent nodes", func(t *testing.T) {
+		var p Path[tests.Person, tests.Knows]
+		err := r.bindValue(neo4j.Path{
+			Nodes:         []neo4j.Node{alice, bob},
+			Relationships: []neo4j.Relationship{knowsRel("a", "c", 2001)},
+		}, reflect.ValueOf(&p))
+		assert.ErrorContains(t, err, "does not connect nodes a and b")
+	})
+
+	t.Run("binds returned paths", func(t *testing.T) {
+		m := NewMock()
+		m.Bind(map[string]any{
+			"p": neo4j.Path{
+				Nodes:         []neo4j.Node{alice, bob},
+				Relationships: []neo4j.Relationship{knowsRel("a", "b", 2001)},
+			},
+		})
+		var p Path[*tests.Person, *tests.Knows]
+		err := m.Exec().
+			Match(db.Path(db.Node(db.Qual(tests.Person{}, "a")).To(tests.Knows{}, tests.Person{}), "p")).
+			Return(db.Qual(&p, "p")).
+			Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", p.Start().Name)
+		assert.Equal(t, "Bob", p.End().Name)
+		assert.Equal(t, 2001, p.Relationships[0].Since)
+	})
+}
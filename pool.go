@@ -0,0 +1,122 @@
+package neogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrSessionAcquisitionTimeout is returned when a session could not be
+// acquired within [Config.SessionAcquisitionTimeout].
+var ErrSessionAcquisitionTimeout = errors.New("timed out acquiring session")
+
+// PoolStats is a snapshot of the sessions acquired from a [Driver].
+type PoolStats struct {
+	// MaxSessions is the maximum number of sessions that may be open at once.
+	MaxSessions int
+	// InUse is the number of sessions that are currently open.
+	InUse int
+	// Waiters is the number of callers waiting to acquire a session.
+	Waiters int
+	// Acquired is the total number of sessions acquired.
+	Acquired int64
+	// Failed is the total number of acquisitions that timed out or were
+	// cancelled.
+	Failed int64
+	// TotalAcquireWait is the total time spent waiting to acquire sessions.
+	TotalAcquireWait time.Duration
+	// MaxAcquireWait is the longest time spent waiting to acquire a session.
+	MaxAcquireWait time.Duration
+}
+
+// AverageAcquireWait returns the average time spent waiting to acquire a
+// session.
+func (s PoolStats) AverageAcquireWait() time.Duration {
+	if s.Acquired == 0 {
+		return 0
+	}
+	return s.TotalAcquireWait / time.Duration(s.Acquired)
+}
+
+// sessionPool limits the number of sessions open at once, queueing callers
+// until a session becomes available.
+type sessionPool struct {
+	sem     *semaphore.Weighted
+	size    int
+	timeout time.Duration
+
+	inUse     atomic.Int64
+	waiters   atomic.Int64
+	acquired  atomic.Int64
+	failed    atomic.Int64
+	totalWait atomic.Int64
+	maxWait   atomic.Int64
+}
+
+func newSessionPool(size int, timeout time.Duration) *sessionPool {
+	return &sessionPool{
+		sem:     semaphore.NewWeighted(int64(size)),
+		size:    size,
+		timeout: timeout,
+	}
+}
+
+// acquire blocks until a session is available, ctx is done or the
+// acquisition timeout elapses.
+func (p *sessionPool) acquire(ctx context.Context) error {
+	start := time.Now()
+	if !p.sem.TryAcquire(1) {
+		p.waiters.Add(1)
+		err := p.wait(ctx)
+		p.waiters.Add(-1)
+		if err != nil {
+			p.failed.Add(1)
+			return fmt.Errorf("cannot acquire session: %w", err)
+		}
+	}
+	p.inUse.Add(1)
+	p.acquired.Add(1)
+	wait := int64(time.Since(start))
+	p.totalWait.Add(wait)
+	for {
+		cur := p.maxWait.Load()
+		if wait <= cur || p.maxWait.CompareAndSwap(cur, wait) {
+			break
+		}
+	}
+	return nil
+}
+
+func (p *sessionPool) wait(ctx context.Context) error {
+	if p.timeout <= 0 {
+		return p.sem.Acquire(ctx, 1)
+	}
+	acquireCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.sem.Acquire(acquireCtx, 1)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%w after %s", ErrSessionAcquisitionTimeout, p.timeout)
+	}
+	return err
+}
+
+func (p *sessionPool) release() {
+	p.inUse.Add(-1)
+	p.sem.Release(1)
+}
+
+func (p *sessionPool) stats() PoolStats {
+	return PoolStats{
+		MaxSessions:      p.size,
+		InUse:            int(p.inUse.Load()),
+		Waiters:          int(p.waiters.Load()),
+		Acquired:         p.acquired.Load(),
+		Failed:           p.failed.Load(),
+		TotalAcquireWait: time.Duration(p.totalWait.Load()),
+		MaxAcquireWait:   time.Duration(p.maxWait.Load()),
+	}
+}
@@ -0,0 +1,97 @@
+package neogo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionPool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("tracks sessions in use", func(t *testing.T) {
+		p := newSessionPool(2, 0)
+		require.NoError(t, p.acquire(ctx))
+		require.NoError(t, p.acquire(ctx))
+		stats := p.stats()
+		assert.Equal(t, 2, stats.MaxSessions)
+		assert.Equal(t, 2, stats.InUse)
+		assert.Equal(t, int64(2), stats.Acquired)
+
+		p.release()
+		assert.Equal(t, 1, p.stats().InUse)
+	})
+
+	t.Run("queues waiters until a session is released", func(t *testing.T) {
+		p := newSessionPool(1, 0)
+		require.NoError(t, p.acquire(ctx))
+
+		acquired := make(chan error)
+		go func() { acquired <- p.acquire(ctx) }()
+		require.Eventually(t, func() bool {
+			return p.stats().Waiters == 1
+		}, time.Second, time.Millisecond)
+
+		time.Sleep(5 * time.Millisecond)
+		p.release()
+		require.NoError(t, <-acquired)
+
+		stats := p.stats()
+		assert.Equal(t, 0, stats.Waiters)
+		assert.Equal(t, 1, stats.InUse)
+		assert.GreaterOrEqual(t, stats.MaxAcquireWait, 5*time.Millisecond)
+		assert.Equal(t, stats.TotalAcquireWait/2, stats.AverageAcquireWait())
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		p := newSessionPool(1, 10*time.Millisecond)
+		require.NoError(t, p.acquire(ctx))
+		err := p.acquire(ctx)
+		assert.ErrorIs(t, err, ErrSessionAcquisitionTimeout)
+
+		stats := p.stats()
+		assert.Equal(t, int64(1), stats.Failed)
+		assert.Equal(t, 0, stats.Waiters)
+		assert.Equal(t, 1, stats.InUse)
+	})
+
+	t.Run("returns context errors", func(t *testing.T) {
+		p := newSessionPool(1, time.Minute)
+		require.NoError(t, p.acquire(ctx))
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := p.acquire(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, errors.Is(err, ErrSessionAcquisitionTimeout))
+	})
+}
+
+func TestDriverSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns an error when sessions cannot be acquired", func(t *testing.T) {
+		m := NewMock()
+		m.(*mockDriverImpl).sessionPool = newSessionPool(1, 0)
+
+		sess, err := m.WriteSession(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, m.PoolStats().InUse)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = m.ReadSession(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+		err = m.Exec().Cypher("RETURN 1").Run(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		require.NoError(t, sess.Close(ctx))
+		stats := m.PoolStats()
+		assert.Equal(t, 0, stats.InUse)
+		assert.Equal(t, int64(1), stats.Acquired)
+		assert.Equal(t, int64(2), stats.Failed)
+	})
+}
@@ -0,0 +1,149 @@
+package neogo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rlch/neogo/internal"
+	"github.com/rlch/neogo/query"
+)
+
+var _ query.Prepared = (*preparedImpl)(nil)
+
+// preparedImpl is a compiled query whose Cypher, canonicalized parameters,
+// columns and bindings are computed once by [runnerImpl.Prepare]. Records are
+// still bound by Exec as they are by Run, using the decoders the codec caches
+// for each type.
+type preparedImpl struct {
+	session *session
+	cypher  string
+	isWrite bool
+	// params holds the canonicalized parameters injected whilst building the
+	// query. It must not be mutated after construction.
+	params map[string]any
+	// columns are the names of bound columns, in the order they're returned.
+	columns []string
+	// bindings are the identifiers bound when building the query, whose types
+	// targets must have.
+	bindings map[string]reflect.Value
+	// opts configure each execution of the query.
+	opts []query.RunOption
+}
+
+func (c *runnerImpl) Prepare() (query.Prepared, error) {
+	cy, err := c.Compile()
+	if err != nil {
+		return nil, err
+	}
+	p := &preparedImpl{
+		session:  c.session,
+		cypher:   cy.Cypher,
+		isWrite:  cy.IsWrite,
+		params:   cy.Parameters,
+		bindings: make(map[string]reflect.Value, len(cy.Bindings)),
+	}
+	for name, binding := range cy.Bindings {
+		p.bindings[name] = binding
+	}
+	for _, column := range cy.Columns {
+		if _, ok := p.bindings[column]; ok {
+			p.columns = append(p.columns, column)
+		}
+	}
+	// Bindings which aren't projected by name (i.e. wildcards) are appended in
+	// a deterministic order.
+	if len(p.columns) < len(p.bindings) {
+		projected := make(map[string]struct{}, len(p.columns))
+		for _, column := range p.columns {
+			projected[column] = struct{}{}
+		}
+		var rest []string
+		for name := range p.bindings {
+			if _, ok := projected[name]; !ok {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		p.columns = append(p.columns, rest...)
+	}
+	return p, nil
+}
+
+func (p *preparedImpl) Cypher() string { return p.cypher }
+
+func (p *preparedImpl) Params() []string {
+	names := make([]string, 0, len(p.params))
+	for name := range p.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *preparedImpl) Columns() []string {
+	return append([]string(nil), p.columns...)
+}
+
+func (p *preparedImpl) WithOptions(opts ...query.RunOption) query.Prepared {
+	q := *p
+	q.opts = append(append([]query.RunOption(nil), p.opts...), opts...)
+	return &q
+}
+
+func (p *preparedImpl) Exec(ctx context.Context, params map[string]any, targets ...any) error {
+	bindings, err := p.bind(targets)
+	if err != nil {
+		return err
+	}
+	overrides, err := p.session.canonicalizeParams(params)
+	if err != nil {
+		return fmt.Errorf("cannot serialize parameters: %w", err)
+	}
+	merged := make(map[string]any, len(p.params)+len(overrides)+1)
+	for k, v := range p.params {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	cy := &internal.CompiledCypher{
+		Cypher:     p.cypher,
+		Parameters: merged,
+		Bindings:   bindings,
+		Columns:    p.columns,
+		IsWrite:    p.isWrite,
+	}
+	r := &runnerImpl{session: p.session}
+	_, err = r.execute(ctx, cy, merged, p.opts, nil)
+	return err
+}
+
+// bind resolves the bindings for a single execution of the query. The
+// identifiers used to build the query are never bound, as concurrent
+// executions would race on them.
+func (p *preparedImpl) bind(targets []any) (map[string]reflect.Value, error) {
+	if len(targets) != len(p.columns) {
+		return nil, fmt.Errorf(
+			"expected %d targets for columns %v, got %d",
+			len(p.columns), p.columns, len(targets),
+		)
+	}
+	bindings := make(map[string]reflect.Value, len(targets))
+	for i, target := range targets {
+		column := p.columns[i]
+		if target == nil {
+			return nil, fmt.Errorf("target for column %q is nil", column)
+		}
+		v := reflect.ValueOf(target)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil, fmt.Errorf("target for column %q must be a non-nil pointer, got %T", column, target)
+		}
+		if want := p.bindings[column].Type(); v.Type() != want {
+			return nil, fmt.Errorf("target for column %q must be of type %s, got %T", column, want, target)
+		}
+		bindings[column] = v
+	}
+	return bindings, nil
+}
@@ -0,0 +1,161 @@
+package neogo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+)
+
+func TestPrepared(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("compiles once", func(t *testing.T) {
+		m := NewMock()
+		var (
+			p     Person
+			count int
+		)
+		prepared, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p", db.Props{"name": "$name"}))).
+			Return(db.Qual(&count, "count(p)", db.Name("count")), &p).
+			Prepare()
+		require.NoError(t, err)
+		assert.Equal(t, "MATCH (p:Person {name: $name})\nRETURN count(p) AS count, p", prepared.Cypher())
+		assert.Equal(t, []string{"count", "p"}, prepared.Columns())
+		assert.Empty(t, prepared.Params())
+	})
+
+	t.Run("requires targets", func(t *testing.T) {
+		m := NewMock()
+		var n int
+		prepared, err := m.Exec().
+			Return(db.Qual(&n, "$n")).
+			Prepare()
+		require.NoError(t, err)
+		assert.ErrorContains(t, prepared.Exec(ctx, map[string]any{"n": 1}), "expected 1 targets")
+
+		written, err := m.Exec().
+			Create(db.Node(db.Qual(&Person{}, "p"))).
+			Prepare()
+		require.NoError(t, err)
+		m.Bind(nil)
+		assert.NoError(t, written.Exec(ctx, nil))
+	})
+
+	t.Run("executes concurrently", func(t *testing.T) {
+		const n = 10
+		m := NewMock()
+		var v int
+		prepared, err := m.Exec().
+			Return(db.Qual(&v, "$n")).
+			Prepare()
+		require.NoError(t, err)
+		for range n {
+			m.Bind(map[string]any{"$n": 1})
+		}
+
+		var wg sync.WaitGroup
+		values := make([]int, n)
+		errs := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = prepared.Exec(ctx, map[string]any{"n": 1}, &values[i])
+			}()
+		}
+		wg.Wait()
+		for i := range n {
+			require.NoError(t, errs[i])
+			assert.Equal(t, 1, values[i])
+		}
+		assert.Zero(t, v)
+	})
+
+	t.Run("binds to targets", func(t *testing.T) {
+		m := NewMock()
+		var (
+			p    Person
+			tags []string
+		)
+		prepared, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p"))).
+			Return(&p, db.Qual(&tags, "p.tags", db.Name("tags"))).
+			Prepare()
+		require.NoError(t, err)
+
+		for _, name := range []string{"Spongebob", "Patrick"} {
+			var (
+				person Person
+				ts     []string
+			)
+			m.Bind(map[string]any{
+				"p":    &Person{Name: name},
+				"tags": []any{name},
+			})
+			require.NoError(t, prepared.Exec(ctx, nil, &person, &ts))
+			assert.Equal(t, name, person.Name)
+			assert.Equal(t, []string{name}, ts)
+		}
+		assert.Zero(t, p)
+		assert.Nil(t, tags)
+	})
+
+	t.Run("applies run options", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		prepared, err := m.Exec().
+			Return(db.Qual(1, "n")).
+			Prepare()
+		require.NoError(t, err)
+
+		withOptions := prepared.WithOptions(Database("movies"))
+		require.NoError(t, withOptions.WithOptions(Timeout(time.Second)).Exec(ctx, nil))
+		require.Len(t, rec.sessionConfigs, 1)
+		assert.Equal(t, "movies", rec.sessionConfigs[0].DatabaseName)
+		require.Len(t, rec.txConfigs, 1)
+		assert.Equal(t, time.Second, rec.txConfigs[0].Timeout)
+	})
+
+	t.Run("retains injected parameters", func(t *testing.T) {
+		m := NewMock()
+		var p Person
+		prepared, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p"))).
+			Where(db.Cond(&p.Age, ">", 20)).
+			Return(&p).
+			Prepare()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1"}, prepared.Params())
+	})
+
+	t.Run("errors on invalid targets", func(t *testing.T) {
+		m := NewMock()
+		var p Person
+		prepared, err := m.Exec().
+			Match(db.Node(db.Qual(&p, "p"))).
+			Return(&p).
+			Prepare()
+		require.NoError(t, err)
+
+		var wrongType string
+		assert.ErrorContains(t, prepared.Exec(ctx, nil, &wrongType), "must be of type")
+		assert.ErrorContains(t, prepared.Exec(ctx, nil, p), "non-nil pointer")
+		assert.ErrorContains(t, prepared.Exec(ctx, nil, &p, &p), "expected 1 targets")
+	})
+
+	t.Run("errors on invalid query", func(t *testing.T) {
+		m := NewMock()
+		_, err := m.Exec().
+			Unwind(db.NamedParam([]chan int{}, "nums"), "i").
+			Return("i").
+			Prepare()
+		assert.Error(t, err)
+	})
+}
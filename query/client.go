@@ -3,9 +3,11 @@ package query
 
 import (
 	"context"
+	"io"
 	"strings"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/rlch/neogo/cypherfmt"
 	"github.com/rlch/neogo/internal"
 )
 
@@ -202,29 +204,87 @@ type Updater[To any] interface {
 type Runner interface {
 	Print() Runner
 
+	// Format writes the query to w, formatted by [cypherfmt.Format] with opts.
+	Format(w io.Writer, opts cypherfmt.Options) error
+
+	// Dump writes the query to w so that it can be pasted into Neo4j Browser:
+	// a :param statement for each parameter, with its value written as a
+	// Cypher literal, followed by the query.
+	Dump(w io.Writer, opts ...DumpOption) error
+
 	// Run executes the query, populating all the values bound within the query if
 	// their identifiers exist in the returning scope.
-	Run(ctx context.Context) error
+	//
+	// opts configure the execution of the query, such as its timeout or the
+	// database it runs against.
+	Run(ctx context.Context, opts ...RunOption) error
 
 	// RunWithParams is the same as Run, but injects the provided parameters into the
 	// query.
-	RunWithParams(ctx context.Context, params map[string]any) error
+	RunWithParams(ctx context.Context, params map[string]any, opts ...RunOption) error
 
 	// RunSummary is the same as Run, and returns a summary of the result.
-	RunSummary(ctx context.Context) (ResultSummary, error)
+	RunSummary(ctx context.Context, opts ...RunOption) (ResultSummary, error)
 
 	// RunSummaryWithParams is the same as RunWithParams, and returns a summary of the result.
-	RunSummaryWithParams(ctx context.Context, params map[string]any) (ResultSummary, error)
+	RunSummaryWithParams(ctx context.Context, params map[string]any, opts ...RunOption) (ResultSummary, error)
 
 	// Stream executes the query and returns an abstraction over a
 	// [pkg/github.com/neo4j/neo4j-go-driver/v5/neo4j.ResultWithContext], which
 	// allows records to be consumed one-by-one as a linked list, instead of all
 	// at once like Run. This is useful for large or undefined results that may
 	// not necessarily fit in memory.
-	Stream(ctx context.Context, sink func(r Result) error) error
+	Stream(ctx context.Context, sink func(r Result) error, opts ...RunOption) error
 
 	// StreamWithParams is the same as Stream, but injects the provided parameters
-	StreamWithParams(ctx context.Context, params map[string]any, sink func(r Result) error) error
+	StreamWithParams(ctx context.Context, params map[string]any, sink func(r Result) error, opts ...RunOption) error
+
+	// Explain executes the query prefixed with EXPLAIN, returning the plan
+	// chosen by the query planner without running the query.
+	Explain(ctx context.Context, opts ...RunOption) (*Plan, error)
+
+	// Profile executes the query prefixed with PROFILE, returning the plan
+	// annotated with runtime statistics. The query is run, but its records are
+	// discarded rather than bound.
+	Profile(ctx context.Context, opts ...RunOption) (*Plan, error)
+
+	// Prepare compiles the query once, returning a [Prepared] query which can
+	// be executed many times without rebuilding or recompiling the query.
+	Prepare() (Prepared, error)
+}
+
+// Prepared is a query that has been compiled ahead of time. It holds the
+// Cypher text, the parameters injected while building the query and the
+// types bound to each returned column. Results are bound each time it's
+// executed, as they are by [Runner.Run].
+//
+// A Prepared query is immutable and safe for concurrent use, provided that
+// concurrent executions bind their results to distinct targets.
+type Prepared interface {
+	// Cypher returns the compiled Cypher query.
+	Cypher() string
+
+	// Params returns the names of the parameters injected while building the
+	// query, sorted in ascending order.
+	Params() []string
+
+	// Columns returns the names of the bound columns in the order they are
+	// returned by the query.
+	Columns() []string
+
+	// WithOptions returns a copy of the query which is executed with opts, in
+	// addition to those it already has. opts configure each execution, as
+	// they do for [Runner.Run].
+	WithOptions(opts ...RunOption) Prepared
+
+	// Exec executes the query. params are merged with, and take precedence
+	// over, the parameters injected while building the query.
+	//
+	// targets are pointers bound positionally to [Prepared.Columns], and must
+	// have the same types as the identifiers they replace. A target is
+	// required for each column, as the identifiers used to build the query
+	// are never bound.
+	Exec(ctx context.Context, params map[string]any, targets ...any) error
 }
 
 type (
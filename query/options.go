@@ -0,0 +1,63 @@
+package query
+
+import "time"
+
+type (
+	// RunOption configures the execution of a single query.
+	RunOption func(*RunConfig)
+
+	// RunConfig holds the options used to execute a single query.
+	RunConfig struct {
+		// Timeout is the maximum duration of the transaction that runs the
+		// query. Zero uses the server's default.
+		Timeout time.Duration
+		// Metadata is attached to the transaction that runs the query.
+		Metadata map[string]any
+		// FetchSize is the number of records fetched per batch. Zero uses the
+		// driver's default.
+		FetchSize int
+		// Database is the name of the database to run the query against.
+		Database string
+		// ImpersonatedUser is the user the query is run on behalf of.
+		ImpersonatedUser string
+		// StrictDecoding reports unknown properties, missing required
+		// properties, integer overflows and type mismatches when binding
+		// results.
+		StrictDecoding bool
+	}
+
+	// DumpOption configures how a query is written by Runner.Dump.
+	DumpOption func(*DumpConfig)
+
+	// DumpConfig holds the options used to dump a single query.
+	DumpConfig struct {
+		// Redact holds the names of the parameters and properties whose values
+		// are replaced with a placeholder.
+		Redact []string
+		// ParamsMap writes the parameters as a single :params map, replacing
+		// any parameters already set, instead of a :param statement for each.
+		ParamsMap bool
+	}
+)
+
+// NewRunConfig returns a RunConfig with opts applied.
+func NewRunConfig(opts ...RunOption) *RunConfig {
+	config := &RunConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+	return config
+}
+
+// NewDumpConfig returns a DumpConfig with opts applied.
+func NewDumpConfig(opts ...DumpOption) *DumpConfig {
+	config := &DumpConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+	return config
+}
@@ -0,0 +1,221 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Plan is a node in the tree of operators produced by the query planner when
+// a query is prefixed with EXPLAIN or PROFILE.
+//
+// Runtime statistics (Rows, DbHits, PageCacheHits, PageCacheMisses,
+// PageCacheHitRatio and Time) are only populated when Profiled is true.
+type Plan struct {
+	// Operator is the name of the operator, e.g. NodeIndexSeek@neo4j.
+	Operator string
+	// Details describes what the operator does, e.g. the index it uses or the
+	// predicate it filters by.
+	Details string
+	// Identifiers are the variables available to the operator.
+	Identifiers []string
+	// EstimatedRows is the number of rows the planner expects the operator to
+	// produce.
+	EstimatedRows float64
+	// Arguments are the raw arguments reported by the server.
+	Arguments map[string]any
+
+	Profiled          bool
+	Rows              int64
+	DbHits            int64
+	PageCacheHits     int64
+	PageCacheMisses   int64
+	PageCacheHitRatio float64
+	// Time spent in the operator, as reported by the server.
+	Time int64
+
+	Children []*Plan
+}
+
+// NewPlan converts a plan returned by EXPLAIN into a [Plan].
+func NewPlan(p neo4j.Plan) *Plan {
+	if p == nil {
+		return nil
+	}
+	plan := newPlan(p.Operator(), p.Arguments(), p.Identifiers())
+	for _, child := range p.Children() {
+		plan.Children = append(plan.Children, NewPlan(child))
+	}
+	return plan
+}
+
+// NewProfiledPlan converts a plan returned by PROFILE into a [Plan].
+func NewProfiledPlan(p neo4j.ProfiledPlan) *Plan {
+	if p == nil {
+		return nil
+	}
+	plan := newPlan(p.Operator(), p.Arguments(), p.Identifiers())
+	plan.Profiled = true
+	plan.Rows = p.Records()
+	plan.DbHits = p.DbHits()
+	plan.PageCacheHits = p.PageCacheHits()
+	plan.PageCacheMisses = p.PageCacheMisses()
+	plan.PageCacheHitRatio = p.PageCacheHitRatio()
+	plan.Time = p.Time()
+	for _, child := range p.Children() {
+		plan.Children = append(plan.Children, NewProfiledPlan(child))
+	}
+	return plan
+}
+
+func newPlan(operator string, args map[string]any, identifiers []string) *Plan {
+	plan := &Plan{
+		Operator:    operator,
+		Identifiers: identifiers,
+		Arguments:   args,
+	}
+	if details, ok := args["Details"].(string); ok {
+		plan.Details = details
+	}
+	switch rows := args["EstimatedRows"].(type) {
+	case float64:
+		plan.EstimatedRows = rows
+	case int64:
+		plan.EstimatedRows = float64(rows)
+	}
+	return plan
+}
+
+// Walk calls visit for p and each of its descendants in depth-first order,
+// stopping early if visit returns false.
+func (p *Plan) Walk(visit func(*Plan) bool) bool {
+	if p == nil {
+		return true
+	}
+	if !visit(p) {
+		return false
+	}
+	for _, child := range p.Children {
+		if !child.Walk(visit) {
+			return false
+		}
+	}
+	return true
+}
+
+// TotalDbHits returns the sum of the database hits of p and its descendants.
+func (p *Plan) TotalDbHits() (hits int64) {
+	p.Walk(func(p *Plan) bool {
+		hits += p.DbHits
+		return true
+	})
+	return
+}
+
+// Name returns the name of the operator without the database it ran on.
+func (p *Plan) Name() string {
+	name, _, _ := strings.Cut(p.Operator, "@")
+	return name
+}
+
+// UsesIndex reports whether p is an operator that reads from an index.
+func (p *Plan) UsesIndex() bool {
+	return strings.Contains(p.Name(), "Index")
+}
+
+// UsedIndexes returns the details of every operator in the tree that reads
+// from an index, e.g. "RANGE INDEX p:Person(name) WHERE name = $name".
+func (p *Plan) UsedIndexes() []string {
+	var indexes []string
+	p.Walk(func(p *Plan) bool {
+		if !p.UsesIndex() {
+			return true
+		}
+		if p.Details != "" {
+			indexes = append(indexes, p.Details)
+		} else {
+			indexes = append(indexes, p.Name())
+		}
+		return true
+	})
+	return indexes
+}
+
+// UsesIndexOn reports whether any operator in the tree reads from an index on
+// the given label (or relationship type) and properties.
+func (p *Plan) UsesIndexOn(labelOrType string, properties ...string) bool {
+	return !p.Walk(func(p *Plan) bool {
+		if !p.UsesIndex() {
+			return true
+		}
+		_, index, ok := strings.Cut(p.Details, ":"+labelOrType+"(")
+		if !ok {
+			return true
+		}
+		index, _, _ = strings.Cut(index, ")")
+		indexed := map[string]struct{}{}
+		for _, prop := range strings.Split(index, ",") {
+			indexed[strings.TrimSpace(prop)] = struct{}{}
+		}
+		for _, prop := range properties {
+			if _, ok := indexed[prop]; !ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// String renders p as an indented tree of operators.
+func (p *Plan) String() string {
+	var b strings.Builder
+	_ = p.Render(&b)
+	return b.String()
+}
+
+// Render writes p to w as an indented tree of operators, one per line.
+//
+//	ProduceResults@neo4j (est. rows: 1, rows: 1, db hits: 0)
+//	└─ NodeIndexSeek@neo4j: RANGE INDEX p:Person(name) WHERE name = $name (est. rows: 1, rows: 1, db hits: 2)
+func (p *Plan) Render(w io.Writer) error {
+	if p == nil {
+		return nil
+	}
+	return p.render(w, "", "")
+}
+
+func (p *Plan) render(w io.Writer, prefix, childPrefix string) error {
+	line := prefix + p.Operator
+	if p.Details != "" {
+		line += ": " + p.Details
+	}
+	stats := []string{fmt.Sprintf("est. rows: %g", p.EstimatedRows)}
+	if p.Profiled {
+		stats = append(stats,
+			fmt.Sprintf("rows: %d", p.Rows),
+			fmt.Sprintf("db hits: %d", p.DbHits),
+		)
+		if p.PageCacheHits > 0 || p.PageCacheMisses > 0 {
+			stats = append(stats, fmt.Sprintf(
+				"page cache: %d/%d",
+				p.PageCacheHits, p.PageCacheHits+p.PageCacheMisses,
+			))
+		}
+	}
+	line += " (" + strings.Join(stats, ", ") + ")"
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+	for i, child := range p.Children {
+		branch, next := "├─ ", "│  "
+		if i == len(p.Children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		if err := child.render(w, childPrefix+branch, childPrefix+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
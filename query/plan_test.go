@@ -0,0 +1,137 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	fakePlan struct {
+		operator    string
+		arguments   map[string]any
+		identifiers []string
+		children    []neo4j.Plan
+	}
+	fakeProfiledPlan struct {
+		fakePlan
+		dbHits, records       int64
+		pageCacheHits, misses int64
+		pageCacheHitRatio     float64
+		time                  int64
+		profiledPlanChildren  []neo4j.ProfiledPlan
+	}
+)
+
+func (p *fakePlan) Operator() string          { return p.operator }
+func (p *fakePlan) Arguments() map[string]any { return p.arguments }
+func (p *fakePlan) Identifiers() []string     { return p.identifiers }
+func (p *fakePlan) Children() []neo4j.Plan    { return p.children }
+
+func (p *fakeProfiledPlan) DbHits() int64                  { return p.dbHits }
+func (p *fakeProfiledPlan) Records() int64                 { return p.records }
+func (p *fakeProfiledPlan) PageCacheHits() int64           { return p.pageCacheHits }
+func (p *fakeProfiledPlan) PageCacheMisses() int64         { return p.misses }
+func (p *fakeProfiledPlan) PageCacheHitRatio() float64     { return p.pageCacheHitRatio }
+func (p *fakeProfiledPlan) Time() int64                    { return p.time }
+func (p *fakeProfiledPlan) Children() []neo4j.ProfiledPlan { return p.profiledPlanChildren }
+
+func TestPlan(t *testing.T) {
+	explained := &fakePlan{
+		operator:    "ProduceResults@neo4j",
+		arguments:   map[string]any{"EstimatedRows": 1.0, "Details": "p"},
+		identifiers: []string{"p"},
+		children: []neo4j.Plan{
+			&fakePlan{
+				operator: "NodeIndexSeek@neo4j",
+				arguments: map[string]any{
+					"EstimatedRows": 1.0,
+					"Details":       "RANGE INDEX p:Person(name, surname) WHERE name = $name",
+				},
+				identifiers: []string{"p"},
+			},
+		},
+	}
+
+	t.Run("converts explained plans", func(t *testing.T) {
+		plan := NewPlan(explained)
+		require.NotNil(t, plan)
+		assert.Equal(t, "ProduceResults@neo4j", plan.Operator)
+		assert.Equal(t, "ProduceResults", plan.Name())
+		assert.Equal(t, 1.0, plan.EstimatedRows)
+		assert.False(t, plan.Profiled)
+		require.Len(t, plan.Children, 1)
+		assert.Equal(t, []string{"p"}, plan.Children[0].Identifiers)
+	})
+
+	t.Run("converts profiled plans", func(t *testing.T) {
+		plan := NewProfiledPlan(&fakeProfiledPlan{
+			fakePlan: fakePlan{
+				operator:  "ProduceResults@neo4j",
+				arguments: map[string]any{"EstimatedRows": int64(3)},
+			},
+			records: 3,
+			dbHits:  4,
+			profiledPlanChildren: []neo4j.ProfiledPlan{
+				&fakeProfiledPlan{
+					fakePlan:      fakePlan{operator: "AllNodesScan@neo4j"},
+					records:       3,
+					dbHits:        10,
+					pageCacheHits: 5,
+					misses:        1,
+				},
+			},
+		})
+		require.NotNil(t, plan)
+		assert.True(t, plan.Profiled)
+		assert.Equal(t, 3.0, plan.EstimatedRows)
+		assert.Equal(t, int64(3), plan.Rows)
+		assert.Equal(t, int64(14), plan.TotalDbHits())
+		assert.Equal(t, int64(5), plan.Children[0].PageCacheHits)
+		assert.False(t, plan.UsesIndexOn("Person"))
+		assert.Empty(t, plan.UsedIndexes())
+	})
+
+	t.Run("nil plans", func(t *testing.T) {
+		assert.Nil(t, NewPlan(nil))
+		assert.Nil(t, NewProfiledPlan(nil))
+	})
+
+	t.Run("detects used indexes", func(t *testing.T) {
+		plan := NewPlan(explained)
+		assert.Equal(t, []string{"RANGE INDEX p:Person(name, surname) WHERE name = $name"}, plan.UsedIndexes())
+		assert.True(t, plan.UsesIndexOn("Person"))
+		assert.True(t, plan.UsesIndexOn("Person", "name"))
+		assert.True(t, plan.UsesIndexOn("Person", "surname", "name"))
+		assert.False(t, plan.UsesIndexOn("Person", "age"))
+		assert.False(t, plan.UsesIndexOn("Movie"))
+	})
+
+	t.Run("renders a tree", func(t *testing.T) {
+		plan := NewProfiledPlan(&fakeProfiledPlan{
+			fakePlan: fakePlan{operator: "ProduceResults@neo4j"},
+			records:  1,
+			profiledPlanChildren: []neo4j.ProfiledPlan{
+				&fakeProfiledPlan{
+					fakePlan: fakePlan{
+						operator:  "Filter@neo4j",
+						arguments: map[string]any{"Details": "p.age > $v1"},
+					},
+					records: 1,
+					dbHits:  2,
+					profiledPlanChildren: []neo4j.ProfiledPlan{
+						&fakeProfiledPlan{fakePlan: fakePlan{operator: "NodeByLabelScan@neo4j"}, dbHits: 3, pageCacheHits: 1, misses: 1},
+					},
+				},
+				&fakeProfiledPlan{fakePlan: fakePlan{operator: "Argument@neo4j"}},
+			},
+		})
+		assert.Equal(t, `ProduceResults@neo4j (est. rows: 0, rows: 1, db hits: 0)
+├─ Filter@neo4j: p.age > $v1 (est. rows: 0, rows: 1, db hits: 2)
+│  └─ NodeByLabelScan@neo4j (est. rows: 0, rows: 0, db hits: 3, page cache: 1/2)
+└─ Argument@neo4j (est. rows: 0, rows: 0, db hits: 0)
+`, plan.String())
+	})
+}
@@ -7,11 +7,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/goccy/go-json"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/spf13/cast"
-
-	"github.com/rlch/neogo/internal"
 )
 
 // Valuer allows arbitrary types to be marshalled into and unmarshalled from
@@ -45,9 +42,53 @@ type registry struct {
 	abstractNodes []any
 	nodes         []any
 	relationships []any
+	// index resolves nodes to registered types, including the implementers of
+	// abstract nodes, by their labels.
+	index       nodeIndex
+	timeMapping TimeMapping
+	// strict is true if values are decoded strictly.
+	strict bool
+	// codecs are the custom codecs registered for types using [WithCodec].
+	codecs map[reflect.Type]customCodec
+}
+
+// configure applies the registry options of cfg.
+func (r *registry) configure(cfg *Config) error {
+	r.timeMapping = cfg.TimeMapping
+	r.strict = cfg.StrictDecoding
+	if len(cfg.codecs) > 0 {
+		r.codecs = make(map[reflect.Type]customCodec, len(cfg.codecs))
+		for t, c := range cfg.codecs {
+			r.codecs[t] = c
+		}
+	}
+	if len(cfg.Types) > 0 {
+		return r.registerTypes(cfg.Types...)
+	}
+	return nil
 }
 
-func (r *registry) registerTypes(types ...any) {
+// hasCodec reports whether a codec is registered for t, or the type t points
+// to.
+func (r *registry) hasCodec(t reflect.Type) bool {
+	if len(r.codecs) == 0 {
+		return false
+	}
+	for {
+		if _, ok := r.codecs[t]; ok {
+			return true
+		}
+		if t.Kind() != reflect.Ptr {
+			return false
+		}
+		t = t.Elem()
+	}
+}
+
+// registerTypes registers nodes and relationships, indexing the labels of
+// nodes so they can be resolved when binding [IAbstract] and [INode]. It errors
+// if nodes are ambiguous, having the same labels as one another.
+func (r *registry) registerTypes(types ...any) error {
 	if r.abstractNodes == nil {
 		r.abstractNodes = []any{}
 	}
@@ -58,11 +99,17 @@ func (r *registry) registerTypes(types ...any) {
 		r.relationships = []any{}
 	}
 	for _, t := range types {
-		if _, ok := t.(IAbstract); ok {
+		if v, ok := t.(IAbstract); ok {
+			if err := r.index.addAbstract(v); err != nil {
+				return err
+			}
 			r.abstractNodes = append(r.abstractNodes, t)
 			continue
 		}
 		if v, ok := t.(INode); ok {
+			if err := r.index.add(v, true); err != nil {
+				return err
+			}
 			r.nodes = append(r.nodes, v)
 			continue
 		}
@@ -71,6 +118,7 @@ func (r *registry) registerTypes(types ...any) {
 			continue
 		}
 	}
+	return nil
 }
 
 func unwindType(ptrTo reflect.Type) reflect.Type {
@@ -117,13 +165,24 @@ var emptyInterface = reflect.TypeOf((*any)(nil)).Elem()
 func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 	toT := to.Type()
 	if to.Kind() == reflect.Ptr && toT.Elem() == emptyInterface {
-		to.Elem().Set(reflect.ValueOf(from))
-		return nil
-	} else if toT == emptyInterface && to.CanSet() {
-		to.Set(reflect.ValueOf(from))
+		to = to.Elem()
+		toT = emptyInterface
+	}
+	if toT == emptyInterface && to.CanSet() {
+		if from == nil {
+			// reflect.ValueOf(nil) is invalid, so null is bound as the zero
+			// value.
+			to.Set(reflect.Zero(emptyInterface))
+		} else {
+			to.Set(reflect.ValueOf(from))
+		}
 		return nil
 	}
 
+	if from != nil && r.hasCodec(toT) {
+		return r.decode(from, to)
+	}
+
 	var ok bool
 	if from != nil {
 		handleSingleRecordToSlice := func(fromVal any) error {
@@ -159,7 +218,13 @@ func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 				innerT.Kind() == reflect.Interface {
 				return r.bindAbstractNode(fromVal, to)
 			}
-			return r.bindValue(fromVal.Props, to)
+			if innerT.Kind() == reflect.Interface {
+				return r.bindConcreteNode(fromVal, to)
+			}
+			if err := r.bindValue(fromVal.Props, to); err != nil {
+				return err
+			}
+			return bindMetadata(fromVal, to)
 		case neo4j.Relationship:
 			// Handle 1 record of an expected slice of relationships
 			if unwindType(toT).Kind() == reflect.Slice {
@@ -172,45 +237,14 @@ func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 			if ok {
 				return nil
 			}
-			return r.bindValue(fromVal.Props, to)
+			if err := r.bindValue(fromVal.Props, to); err != nil {
+				return err
+			}
+			return bindMetadata(fromVal, to)
 		}
 
 		// Valuer throuh any other RecordValue
-		ok, err = func() (bool, error) {
-			switch fromVal := from.(type) {
-			case bool:
-				return bindValuer(fromVal, to)
-			case int64:
-				return bindValuer(fromVal, to)
-			case float64:
-				return bindValuer(fromVal, to)
-			case string:
-				return bindValuer(fromVal, to)
-			case neo4j.Point2D:
-				return bindValuer(fromVal, to)
-			case neo4j.Point3D:
-				return bindValuer(fromVal, to)
-			case neo4j.Date:
-				return bindValuer(fromVal, to)
-			case neo4j.LocalTime:
-				return bindValuer(fromVal, to)
-			case neo4j.LocalDateTime:
-				return bindValuer(fromVal, to)
-			case neo4j.Time:
-				return bindValuer(fromVal, to)
-			case neo4j.Duration:
-				return bindValuer(fromVal, to)
-			case time.Time:
-				return bindValuer(fromVal, to)
-			case []byte:
-				return bindValuer(fromVal, to)
-			case []any:
-				return bindValuer(fromVal, to)
-			case map[string]any:
-				return bindValuer(fromVal, to)
-			}
-			return false, nil
-		}()
+		ok, err = bindRecordValuer(from, to)
 		if err != nil {
 			return err
 		}
@@ -245,7 +279,7 @@ func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 					}
 					err := r.bindValue(fromI, toI)
 					if err != nil {
-						return fmt.Errorf("error binding slice element %d: %w", i, err)
+						return prefixDecodeError(fmt.Sprintf("[%d]", i), err)
 					}
 				}
 			} else if fromDepth+1 == toDepth {
@@ -261,10 +295,11 @@ func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 			return nil
 		}
 
-		// Primitive coercion.
+		// Primitive coercion. When decoding strictly, values are only converted
+		// losslessly by the codec.
 		value := unwindValue(to)
 		ok, err = func() (bool, error) {
-			if !to.CanSet() || !value.IsValid() || !value.CanInterface() {
+			if r.strict || !to.CanSet() || !value.IsValid() || !value.CanInterface() {
 				return false, nil
 			}
 			i := value.Interface()
@@ -327,105 +362,128 @@ func (r *registry) bindValue(from any, to reflect.Value) (err error) {
 		}
 	}
 
-	// PERF: Obviously huge performance hit here. Consider alternative ways of
-	// coercing between types. Might just need to be imperative and verbose
-	bytes, err := json.Marshal(from)
-	if err != nil {
-		return err
+	return r.decode(from, to)
+}
+
+// bindRecordValuer binds value to bindTo if it implements [Valuer] for the
+// type of value.
+func bindRecordValuer(value any, bindTo reflect.Value) (bool, error) {
+	switch value := value.(type) {
+	case bool:
+		return bindValuer(value, bindTo)
+	case int64:
+		return bindValuer(value, bindTo)
+	case float64:
+		return bindValuer(value, bindTo)
+	case string:
+		return bindValuer(value, bindTo)
+	case neo4j.Point2D:
+		return bindValuer(value, bindTo)
+	case neo4j.Point3D:
+		return bindValuer(value, bindTo)
+	case neo4j.Date:
+		return bindValuer(value, bindTo)
+	case neo4j.LocalTime:
+		return bindValuer(value, bindTo)
+	case neo4j.LocalDateTime:
+		return bindValuer(value, bindTo)
+	case neo4j.Time:
+		return bindValuer(value, bindTo)
+	case neo4j.Duration:
+		return bindValuer(value, bindTo)
+	case time.Time:
+		return bindValuer(value, bindTo)
+	case []byte:
+		return bindValuer(value, bindTo)
+	case []any:
+		return bindValuer(value, bindTo)
+	case map[string]any:
+		return bindValuer(value, bindTo)
+	case neo4j.Node:
+		return bindValuer(value, bindTo)
+	case neo4j.Relationship:
+		return bindValuer(value, bindTo)
+	case neo4j.Path:
+		return bindValuer(value, bindTo)
 	}
-	err = json.Unmarshal(bytes, to.Interface())
-	if err != nil {
+	return false, nil
+}
+
+// bindConcreteNode binds node to the interface pointed to by to. Unless the
+// interface already holds a pointer to a node, it's bound to a new instance of
+// the most specific registered node implementing it whose labels are all
+// labels of node.
+func (r *registry) bindConcreteNode(node neo4j.Node, to reflect.Value) error {
+	for to.Kind() == reflect.Ptr {
+		if to.IsNil() {
+			to.Set(reflect.New(to.Type().Elem()))
+		}
+		to = to.Elem()
+	}
+	// Bind to the existing implementation, if any.
+	if !to.IsNil() && to.Elem().Kind() == reflect.Ptr && !to.Elem().IsNil() {
+		return r.bindValue(node, to.Elem())
+	}
+	if !to.CanSet() {
+		return fmt.Errorf("cannot bind node to unsettable value of type %s", to.Type())
+	}
+	impl := r.index.lookup(node.Labels, to.Type())
+	if impl == nil {
+		return fmt.Errorf(
+			"no node implementing %s found for labels: %s\nDid you forget to register the node using neogo.WithTypes(...)?",
+			to.Type(), strings.Join(node.Labels, ", "),
+		)
+	}
+	toImpl := reflect.New(impl.typ.Elem())
+	if err := r.bindValue(node, toImpl); err != nil {
 		return err
 	}
+	to.Set(toImpl)
 	return nil
 }
 
 func (r *registry) bindAbstractNode(node neo4j.Node, to reflect.Value) error {
-	nodeLabels := node.Labels
-	isNodeLabel := make(map[string]struct{}, len(nodeLabels))
-	for _, label := range nodeLabels {
-		isNodeLabel[label] = struct{}{}
-	}
-
-	var (
-		abs                any
-		inheritanceCounter int
-	)
+	var iface reflect.Type
+	index := &r.index
 	ptrTo := false
 	canBindSubtype := true
 	if to.Type().Implements(rAbstract) {
+		iface = to.Type()
 		if !to.IsNil() {
 			canBindSubtype = false
 		}
 	} else if to.Type().Elem().Implements(rAbstract) {
 		ptrTo = true
+		iface = to.Type().Elem()
 		if !to.Elem().IsNil() {
-			abs = to.Elem().Interface()
-		}
-	} else {
-		return errors.New("cannot bind abstract node to non-abstract type")
-	}
-	// We find the abstract node (or exact implementation if registered) that has
-	// a inheritance chain closest to the database node we're extracting from.
-	// i.e. If we have a concrete-node with inheritance chain A > B > C, we prefer
-	// A > B as a potential subtype over A.
-	if abs == nil {
-	Bases:
-		for _, base := range r.abstractNodes {
-			labels := internal.ExtractConcreteNodeLabels(base)
-			if len(labels) == 0 {
-				continue
-			}
-			currentInheritanceCounter := 0
-			for _, label := range labels {
-				if _, ok := isNodeLabel[label]; !ok {
-					continue Bases
-				}
-				currentInheritanceCounter++
-			}
-			if currentInheritanceCounter > inheritanceCounter {
-				abs = base
-				inheritanceCounter = currentInheritanceCounter
+			// Only the implementers of the existing abstract node are considered.
+			index = &nodeIndex{}
+			if err := index.addAbstract(to.Elem().Interface().(IAbstract)); err != nil {
+				return err
 			}
 		}
-		if abs == nil {
-			return fmt.Errorf(
-				"no abstract node found for labels: %s\nDid you forget to register the base node using neogo.WithTypes(...)?",
-				strings.Join(nodeLabels, ", "),
-			)
-		}
-	}
-
-	// We found our impl
-	var impl any
-	if inheritanceCounter == len(nodeLabels) {
-		impl = abs
 	} else {
-		if !canBindSubtype {
-			return fmt.Errorf(
-				"cannot bind abstract subtype to non-nil abstract type, as value-types cannot be reassigned.\nTry using *%s",
-				to.Type(),
-			)
-		}
-	Impls:
-		for _, nextImpl := range abs.(IAbstract).Implementers() {
-			for _, label := range internal.ExtractConcreteNodeLabels(nextImpl) {
-				if _, ok := isNodeLabel[label]; !ok {
-					continue Impls
-				}
-			}
-			impl = nextImpl
-			break
-		}
+		return errors.New("cannot bind abstract node to non-abstract type")
 	}
+	// We find the registered node with an inheritance chain closest to the
+	// database node we're extracting from. i.e. If we have a concrete-node with
+	// inheritance chain A > B > C, we prefer A > B as a potential subtype over
+	// A.
+	impl := index.lookup(node.Labels, iface)
 	if impl == nil {
 		return fmt.Errorf(
 			"no concrete implementation found for labels: %s\nDid you forget to register the base node using neogo.WithTypes(...)?",
-			strings.Join(nodeLabels, ", "),
+			strings.Join(node.Labels, ", "),
+		)
+	}
+	if !canBindSubtype && !impl.base {
+		return fmt.Errorf(
+			"cannot bind abstract subtype to non-nil abstract type, as value-types cannot be reassigned.\nTry using *%s",
+			to.Type(),
 		)
 	}
-	toImpl := reflect.New(reflect.TypeOf(impl).Elem())
-	err := r.bindValue(node.Props, toImpl)
+	toImpl := reflect.New(impl.typ.Elem())
+	err := r.bindValue(node, toImpl)
 	if err != nil {
 		return err
 	}
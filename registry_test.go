@@ -412,9 +412,9 @@ func TestBindValue(t *testing.T) {
 
 	t.Run("Abstract using registered types", func(t *testing.T) {
 		rWithAbstract := &registry{}
-		rWithAbstract.registerTypes(
+		require.NoError(t, rWithAbstract.registerTypes(
 			&tests.BaseOrganism{},
-		)
+		))
 
 		var to tests.Organism
 		err := rWithAbstract.bindValue(neo4j.Node{
@@ -435,10 +435,10 @@ func TestBindValue(t *testing.T) {
 
 	t.Run("Abstract using registered concrete types", func(t *testing.T) {
 		rWithAbstract := &registry{}
-		rWithAbstract.registerTypes(
+		require.NoError(t, rWithAbstract.registerTypes(
 			&tests.Human{},
 			&tests.Dog{},
-		)
+		))
 		var to tests.Organism
 		err := rWithAbstract.bindValue(neo4j.Node{
 			Labels: []string{"Human", "Organism"},
@@ -456,6 +456,65 @@ func TestBindValue(t *testing.T) {
 		}, to)
 	})
 
+	t.Run("INode using the most specific registered node", func(t *testing.T) {
+		rWithNodes := &registry{}
+		require.NoError(t, rWithNodes.registerTypes(
+			&tests.Person{},
+			&tests.BaseOrganism{},
+		))
+		var to []INode
+		err := rWithNodes.bindValue([]any{
+			neo4j.Node{
+				Labels: []string{"Person"},
+				Props:  map[string]any{"name": "Jessie"},
+			},
+			neo4j.Node{
+				Labels: []string{"Organism"},
+				Props:  map[string]any{"alive": true},
+			},
+			neo4j.Node{
+				Labels: []string{"Organism", "Pet", "Dog"},
+				Props:  map[string]any{"borfs": true},
+			},
+		}, reflect.ValueOf(&to))
+		require.NoError(t, err)
+		require.Equal(t, []INode{
+			&tests.Person{Name: "Jessie"},
+			&tests.BaseOrganism{Alive: true},
+			&tests.Dog{Borfs: true},
+		}, to)
+
+		var out struct {
+			Node INode `json:"node"`
+		}
+		err = rWithNodes.bindValue(map[string]any{
+			"node": neo4j.Node{
+				Labels: []string{"Human", "Organism"},
+				Props:  map[string]any{"name": "Raqeeb"},
+			},
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		require.Equal(t, &tests.Human{Name: "Raqeeb"}, out.Node)
+	})
+
+	t.Run("INode binds to existing implementation", func(t *testing.T) {
+		var to INode = &tests.Person{}
+		err := r.bindValue(neo4j.Node{
+			Labels: []string{"Person"},
+			Props:  map[string]any{"name": "Jessie"},
+		}, reflect.ValueOf(&to))
+		require.NoError(t, err)
+		require.Equal(t, &tests.Person{Name: "Jessie"}, to)
+	})
+
+	t.Run("INode errors without registered node", func(t *testing.T) {
+		var to INode
+		err := r.bindValue(neo4j.Node{
+			Labels: []string{"Person"},
+		}, reflect.ValueOf(&to))
+		require.ErrorContains(t, err, "found for labels: Person")
+	})
+
 	t.Run("Any", func(t *testing.T) {
 		to := new(any)
 		err := r.bindValue(neo4j.Node{
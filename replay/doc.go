@@ -0,0 +1,23 @@
+// Package replay records the traffic between neogo and Neo4j to golden files,
+// and replays it so that integration tests can run without a server.
+//
+// Record by wrapping a driver connected to Neo4j with a [Recorder]:
+//
+//	r := replay.NewRecorder(driver, "testdata/people.json")
+//	d, err := neogo.NewWithDriver(r)
+//	// ... run the test ...
+//	err = r.Close(ctx)
+//
+// Then replay the golden file with a [Replayer]:
+//
+//	r, err := replay.Load("testdata/people.json")
+//	d, err := neogo.NewWithDriver(r)
+//	// ... run the test ...
+//	err = r.ExpectationsWereMet()
+//
+// Golden files are indented JSON, recording each query's Cypher, parameters,
+// records and summary, so that changes to them can be reviewed. Nodes,
+// relationships and paths are recorded with their element IDs, and values of
+// the driver's types are tagged so that they're replayed as they were
+// received.
+package replay
@@ -0,0 +1,296 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type (
+	// Recorder is a [neo4j.DriverWithContext] which records the queries run
+	// with the driver it wraps, and their responses, to a golden file which can
+	// be replayed with [Load].
+	//
+	// Results are buffered, so records are fetched from the wrapped driver
+	// before queries return.
+	//
+	// It's safe for concurrent use.
+	Recorder struct {
+		neo4j.DriverWithContext
+		path string
+
+		mu        sync.Mutex
+		recording recording
+	}
+
+	recordingSession struct {
+		neo4j.SessionWithContext
+		recorder *Recorder
+	}
+
+	recordingExplicitTransaction struct {
+		neo4j.ExplicitTransaction
+		recorder *Recorder
+	}
+
+	recordingManagedTransaction struct {
+		neo4j.ManagedTransaction
+		recorder *Recorder
+	}
+)
+
+var (
+	_ neo4j.DriverWithContext   = (*Recorder)(nil)
+	_ neo4j.SessionWithContext  = (*recordingSession)(nil)
+	_ neo4j.ExplicitTransaction = (*recordingExplicitTransaction)(nil)
+	_ neo4j.ManagedTransaction  = (*recordingManagedTransaction)(nil)
+)
+
+// NewRecorder creates a [Recorder] wrapping driver, which saves its recording
+// to path when it's closed.
+//
+//	d, err := neo4j.NewDriverWithContext(uri, auth)
+//	...
+//	r := replay.NewRecorder(d, "testdata/people.json")
+//	defer r.Close(ctx)
+//	n, err := neogo.NewWithDriver(r)
+func NewRecorder(driver neo4j.DriverWithContext, path string) *Recorder {
+	return &Recorder{
+		DriverWithContext: driver,
+		path:              path,
+		recording:         recording{Version: Version, Interactions: []*interaction{}},
+	}
+}
+
+func (r *Recorder) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &recordingSession{
+		SessionWithContext: r.DriverWithContext.NewSession(ctx, config),
+		recorder:           r,
+	}
+}
+
+// Save writes the queries recorded so far to the golden file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording.save(r.path)
+}
+
+// Close saves the recording and closes the wrapped driver.
+func (r *Recorder) Close(ctx context.Context) error {
+	return errors.Join(r.Save(), r.DriverWithContext.Close(ctx))
+}
+
+// record runs the query cypher with run, recording its response.
+func (r *Recorder) record(
+	ctx context.Context,
+	cypher string,
+	params map[string]any,
+	run func() (neo4j.ResultWithContext, error),
+) (neo4j.ResultWithContext, error) {
+	encodedParams, err := encodeMap(params)
+	if err != nil {
+		return nil, fmt.Errorf("replay: cannot record parameters: %w", err)
+	}
+	i := &interaction{
+		Fingerprint: fingerprint(cypher, encodedParams, nil),
+		Query:       cypher,
+		Params:      encodedParams,
+	}
+	keys, records, summary, err := collect(ctx, run)
+	if err != nil {
+		i.Error = encodeError(err)
+		r.append(i)
+		return nil, err
+	}
+	i.Keys = keys
+	i.Records = make([][]any, len(records))
+	for j, record := range records {
+		if i.Records[j], err = encodeList(record.Values); err != nil {
+			return nil, fmt.Errorf("replay: cannot record result: %w", err)
+		}
+	}
+	if i.Summary, err = encodeSummary(summary); err != nil {
+		return nil, fmt.Errorf("replay: cannot record summary: %w", err)
+	}
+	r.append(i)
+	return newResult(keys, records, summary), nil
+}
+
+// collect runs a query with run, fetching all of its records.
+func collect(ctx context.Context, run func() (neo4j.ResultWithContext, error)) (
+	[]string,
+	[]*neo4j.Record,
+	neo4j.ResultSummary,
+	error,
+) {
+	res, err := run()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keys, err := res.Keys()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	records, err := res.Collect(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	summary, err := res.Consume(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return keys, records, summary, nil
+}
+
+func (r *Recorder) append(i *interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording.Interactions = append(r.recording.Interactions, i)
+}
+
+func encodeSummary(s neo4j.ResultSummary) (*summaryData, error) {
+	if s == nil {
+		return nil, nil
+	}
+	data := &summaryData{}
+	switch s.StatementType() {
+	case neo4j.StatementTypeReadOnly:
+		data.StatementType = "r"
+	case neo4j.StatementTypeReadWrite:
+		data.StatementType = "rw"
+	case neo4j.StatementTypeWriteOnly:
+		data.StatementType = "w"
+	case neo4j.StatementTypeSchemaWrite:
+		data.StatementType = "s"
+	}
+	if c := s.Counters(); c != nil && (c.ContainsUpdates() || c.ContainsSystemUpdates()) {
+		data.Counters = &countersData{
+			NodesCreated:         c.NodesCreated(),
+			NodesDeleted:         c.NodesDeleted(),
+			RelationshipsCreated: c.RelationshipsCreated(),
+			RelationshipsDeleted: c.RelationshipsDeleted(),
+			PropertiesSet:        c.PropertiesSet(),
+			LabelsAdded:          c.LabelsAdded(),
+			LabelsRemoved:        c.LabelsRemoved(),
+			IndexesAdded:         c.IndexesAdded(),
+			IndexesRemoved:       c.IndexesRemoved(),
+			ConstraintsAdded:     c.ConstraintsAdded(),
+			ConstraintsRemoved:   c.ConstraintsRemoved(),
+			SystemUpdates:        c.SystemUpdates(),
+		}
+	}
+	if d := s.Database(); d != nil {
+		data.Database = d.Name()
+	}
+	if server := s.Server(); server != nil {
+		v := server.ProtocolVersion()
+		data.Server = &serverData{
+			Address:  server.Address(),
+			Agent:    server.Agent(),
+			Protocol: fmt.Sprintf("%d.%d", v.Major, v.Minor),
+		}
+	}
+	var err error
+	if p := s.Plan(); p != nil {
+		if data.Plan, err = encodePlan(p); err != nil {
+			return nil, err
+		}
+	}
+	if p := s.Profile(); p != nil {
+		if data.Profile, err = encodeProfile(p); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func encodePlan(p neo4j.Plan) (*planData, error) {
+	args, err := encodeMap(p.Arguments())
+	if err != nil {
+		return nil, err
+	}
+	data := &planData{Operator: p.Operator(), Arguments: args, Identifiers: p.Identifiers()}
+	for _, child := range p.Children() {
+		c, err := encodePlan(child)
+		if err != nil {
+			return nil, err
+		}
+		data.Children = append(data.Children, c)
+	}
+	return data, nil
+}
+
+func encodeProfile(p neo4j.ProfiledPlan) (*planData, error) {
+	args, err := encodeMap(p.Arguments())
+	if err != nil {
+		return nil, err
+	}
+	data := &planData{
+		Operator:          p.Operator(),
+		Arguments:         args,
+		Identifiers:       p.Identifiers(),
+		DbHits:            p.DbHits(),
+		Records:           p.Records(),
+		PageCacheHits:     p.PageCacheHits(),
+		PageCacheMisses:   p.PageCacheMisses(),
+		PageCacheHitRatio: p.PageCacheHitRatio(),
+		Time:              p.Time(),
+	}
+	for _, child := range p.Children() {
+		c, err := encodeProfile(child)
+		if err != nil {
+			return nil, err
+		}
+		data.Children = append(data.Children, c)
+	}
+	return data, nil
+}
+
+func (s *recordingSession) Run(
+	ctx context.Context,
+	cypher string,
+	params map[string]any,
+	configurers ...func(*neo4j.TransactionConfig),
+) (neo4j.ResultWithContext, error) {
+	return s.recorder.record(ctx, cypher, params, func() (neo4j.ResultWithContext, error) {
+		return s.SessionWithContext.Run(ctx, cypher, params, configurers...)
+	})
+}
+
+func (s *recordingSession) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	tx, err := s.SessionWithContext.BeginTransaction(ctx, configurers...)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingExplicitTransaction{ExplicitTransaction: tx, recorder: s.recorder}, nil
+}
+
+func (s *recordingSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.SessionWithContext.ExecuteRead(ctx, s.wrap(work), configurers...)
+}
+
+func (s *recordingSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.SessionWithContext.ExecuteWrite(ctx, s.wrap(work), configurers...)
+}
+
+func (s *recordingSession) wrap(work neo4j.ManagedTransactionWork) neo4j.ManagedTransactionWork {
+	return func(tx neo4j.ManagedTransaction) (any, error) {
+		return work(&recordingManagedTransaction{ManagedTransaction: tx, recorder: s.recorder})
+	}
+}
+
+func (t *recordingExplicitTransaction) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	return t.recorder.record(ctx, cypher, params, func() (neo4j.ResultWithContext, error) {
+		return t.ExplicitTransaction.Run(ctx, cypher, params)
+	})
+}
+
+func (t *recordingManagedTransaction) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	return t.recorder.record(ctx, cypher, params, func() (neo4j.ResultWithContext, error) {
+		return t.ManagedTransaction.Run(ctx, cypher, params)
+	})
+}
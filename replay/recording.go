@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Version is the version of the golden file format written by a [Recorder].
+// Files written with other versions can't be replayed.
+const Version = 1
+
+type (
+	// recording is the contents of a golden file.
+	recording struct {
+		Version      int            `json:"version"`
+		Interactions []*interaction `json:"interactions"`
+	}
+
+	// interaction is a query run against Neo4j, and its response.
+	interaction struct {
+		Fingerprint string         `json:"fingerprint"`
+		Query       string         `json:"query"`
+		Params      map[string]any `json:"params,omitempty"`
+		Keys        []string       `json:"keys,omitempty"`
+		Records     [][]any        `json:"records,omitempty"`
+		Summary     *summaryData   `json:"summary,omitempty"`
+		Error       *errorData     `json:"error,omitempty"`
+	}
+
+	summaryData struct {
+		StatementType string        `json:"statementType,omitempty"`
+		Counters      *countersData `json:"counters,omitempty"`
+		Database      string        `json:"database,omitempty"`
+		Server        *serverData   `json:"server,omitempty"`
+		Plan          *planData     `json:"plan,omitempty"`
+		Profile       *planData     `json:"profile,omitempty"`
+	}
+
+	countersData struct {
+		NodesCreated         int `json:"nodesCreated,omitempty"`
+		NodesDeleted         int `json:"nodesDeleted,omitempty"`
+		RelationshipsCreated int `json:"relationshipsCreated,omitempty"`
+		RelationshipsDeleted int `json:"relationshipsDeleted,omitempty"`
+		PropertiesSet        int `json:"propertiesSet,omitempty"`
+		LabelsAdded          int `json:"labelsAdded,omitempty"`
+		LabelsRemoved        int `json:"labelsRemoved,omitempty"`
+		IndexesAdded         int `json:"indexesAdded,omitempty"`
+		IndexesRemoved       int `json:"indexesRemoved,omitempty"`
+		ConstraintsAdded     int `json:"constraintsAdded,omitempty"`
+		ConstraintsRemoved   int `json:"constraintsRemoved,omitempty"`
+		SystemUpdates        int `json:"systemUpdates,omitempty"`
+	}
+
+	serverData struct {
+		Address  string `json:"address,omitempty"`
+		Agent    string `json:"agent,omitempty"`
+		Protocol string `json:"protocol,omitempty"`
+	}
+
+	planData struct {
+		Operator          string         `json:"operator"`
+		Arguments         map[string]any `json:"arguments,omitempty"`
+		Identifiers       []string       `json:"identifiers,omitempty"`
+		Children          []*planData    `json:"children,omitempty"`
+		DbHits            int64          `json:"dbHits,omitempty"`
+		Records           int64          `json:"records,omitempty"`
+		PageCacheHits     int64          `json:"pageCacheHits,omitempty"`
+		PageCacheMisses   int64          `json:"pageCacheMisses,omitempty"`
+		PageCacheHitRatio float64        `json:"pageCacheHitRatio,omitempty"`
+		Time              int64          `json:"time,omitempty"`
+	}
+
+	errorData struct {
+		// Code is the code of a [neo4j.Neo4jError], or empty for other errors.
+		Code    string `json:"code,omitempty"`
+		Message string `json:"message"`
+	}
+)
+
+// fingerprint identifies a query by its Cypher, ignoring differences in
+// whitespace, and its encoded parameters, excluding those named in ignore.
+func fingerprint(cypher string, params map[string]any, ignore map[string]bool) string {
+	h := sha256.New()
+	h.Write([]byte(strings.Join(strings.Fields(cypher), " ")))
+	for _, k := range sortedKeys(params) {
+		if ignore[k] {
+			continue
+		}
+		fmt.Fprintf(h, "\x00%s=%s", k, canonicalJSON(params[k]))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// canonicalJSON marshals the encoded value v with objects' keys in order,
+// whether it was encoded by a Recorder or loaded from a golden file.
+func canonicalJSON(v any) []byte {
+	// Encoded values are always marshalable.
+	b, _ := json.Marshal(v)
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var generic any
+	if err := d.Decode(&generic); err != nil {
+		return b
+	}
+	b, _ = json.Marshal(generic)
+	return b
+}
+
+func newError(e *errorData) error {
+	if e.Code != "" {
+		return &neo4j.Neo4jError{Code: e.Code, Msg: e.Message}
+	}
+	return errors.New(e.Message)
+}
+
+func encodeError(err error) *errorData {
+	var neoErr *neo4j.Neo4jError
+	if errors.As(err, &neoErr) {
+		return &errorData{Code: neoErr.Code, Message: neoErr.Msg}
+	}
+	return &errorData{Message: err.Error()}
+}
+
+func (r *recording) save(path string) error {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(r); err != nil {
+		return fmt.Errorf("replay: cannot encode recording: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("replay: cannot write recording: %w", err)
+	}
+	return nil
+}
+
+func loadRecording(path string) (*recording, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: cannot read recording: %w", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var r recording
+	if err := d.Decode(&r); err != nil {
+		return nil, fmt.Errorf("replay: cannot decode recording %s: %w", path, err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("replay: recording %s has version %d, but version %d is required", path, r.Version, Version)
+	}
+	return &r, nil
+}
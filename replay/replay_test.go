@@ -0,0 +1,163 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo"
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/internal/tests"
+	"github.com/rlch/neogo/memdb"
+)
+
+// people runs queries with d, returning the people it reads.
+func people(d neogo.Driver) ([]*tests.Person, error) {
+	ctx := context.Background()
+	for _, name := range []string{"Jessie", "Walter"} {
+		p := tests.Person{Name: name, Age: len(name)}
+		p.ID = name
+		if err := d.Exec().Create(db.Node(db.Qual(&p, "p"))).Run(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s, err := d.ReadSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var people []*tests.Person
+	err = s.ReadTransaction(ctx, func(begin func() neogo.Query) error {
+		return begin().
+			Match(db.Node(db.Qual(&people, "p"))).
+			Return(&people, db.Return(nil, db.OrderBy("p.name", true))).
+			Run(ctx)
+	})
+	return people, s.Close(ctx, err)
+}
+
+func record(t *testing.T, run func(d neogo.Driver)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recording.json")
+	r := NewRecorder(memdb.New(), path)
+	d, err := neogo.NewWithDriver(r)
+	require.NoError(t, err)
+	run(d)
+	require.NoError(t, r.Close(context.Background()))
+	return path
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replays recorded queries", func(t *testing.T) {
+		var recorded []*tests.Person
+		path := record(t, func(d neogo.Driver) {
+			var err error
+			recorded, err = people(d)
+			require.NoError(t, err)
+		})
+		require.Len(t, recorded, 2)
+
+		r, err := Load(path)
+		require.NoError(t, err)
+		d, err := neogo.NewWithDriver(r)
+		require.NoError(t, err)
+		replayed, err := people(d)
+		require.NoError(t, err)
+		assert.Equal(t, recorded, replayed)
+		assert.NoError(t, r.ExpectationsWereMet())
+	})
+
+	t.Run("replays records and summaries", func(t *testing.T) {
+		path := record(t, func(d neogo.Driver) {
+			s := d.DB().NewSession(ctx, neo4j.SessionConfig{})
+			_, err := s.Run(ctx, "CREATE (a:A)-[r:R]->(b:B) RETURN a, r, b", nil)
+			require.NoError(t, err)
+			require.NoError(t, s.Close(ctx))
+		})
+		r, err := Load(path)
+		require.NoError(t, err)
+		s := r.NewSession(ctx, neo4j.SessionConfig{})
+		res, err := s.Run(ctx, "CREATE (a:A)-[r:R]->(b:B)\nRETURN a, r, b", nil)
+		require.NoError(t, err, "whitespace is ignored")
+		record, err := res.Single(ctx)
+		require.NoError(t, err)
+		a := record.Values[0].(neo4j.Node)
+		rel := record.Values[1].(neo4j.Relationship)
+		b := record.Values[2].(neo4j.Node)
+		assert.Equal(t, []string{"A"}, a.Labels)
+		assert.Equal(t, a.ElementId, rel.StartElementId)
+		assert.Equal(t, b.ElementId, rel.EndElementId)
+		summary, err := res.Consume(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, neo4j.StatementTypeReadWrite, summary.StatementType())
+		assert.Equal(t, 2, summary.Counters().NodesCreated())
+		assert.Equal(t, 1, summary.Counters().RelationshipsCreated())
+	})
+
+	t.Run("replays errors", func(t *testing.T) {
+		path := record(t, func(d neogo.Driver) {
+			err := d.Exec().Cypher("RETURN 1 / 0").Run(ctx)
+			require.Error(t, err)
+		})
+		r, err := Load(path)
+		require.NoError(t, err)
+		d, err := neogo.NewWithDriver(r)
+		require.NoError(t, err)
+		err = d.Exec().Cypher("RETURN 1 / 0").Run(ctx)
+		var neoErr *neo4j.Neo4jError
+		require.ErrorAs(t, err, &neoErr)
+		assert.Equal(t, "Neo.ClientError.Statement.ArithmeticError", neoErr.Code)
+	})
+
+	t.Run("fails when queries diverge", func(t *testing.T) {
+		path := record(t, func(d neogo.Driver) {
+			require.NoError(t, d.Exec().Cypher("RETURN $x").RunWithParams(ctx, map[string]any{"x": 1}))
+			require.NoError(t, d.Exec().Cypher("RETURN 2").Run(ctx))
+		})
+		r, err := Load(path)
+		require.NoError(t, err)
+		d, err := neogo.NewWithDriver(r)
+		require.NoError(t, err)
+
+		err = d.Exec().Cypher("RETURN $x").RunWithParams(ctx, map[string]any{"x": 2})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "x: recorded 1, got 2")
+
+		err = d.Exec().Cypher("RETURN 3").Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the query was not recorded")
+
+		require.NoError(t, d.Exec().Cypher("RETURN 2").Run(ctx))
+		err = d.Exec().Cypher("RETURN 2").Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already been replayed")
+
+		err = r.ExpectationsWereMet()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "RETURN $x")
+	})
+
+	t.Run("ignores params", func(t *testing.T) {
+		path := record(t, func(d neogo.Driver) {
+			require.NoError(t, d.Exec().Cypher("RETURN $now").RunWithParams(ctx, map[string]any{"now": 1}))
+		})
+		r, err := Load(path, IgnoreParams("now"))
+		require.NoError(t, err)
+		d, err := neogo.NewWithDriver(r)
+		require.NoError(t, err)
+		require.NoError(t, d.Exec().Cypher("RETURN $now").RunWithParams(ctx, map[string]any{"now": 2}))
+	})
+
+	t.Run("rejects other versions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "recording.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 0, "interactions": []}`), 0o644))
+		_, err := Load(path)
+		assert.ErrorContains(t, err, "version 0")
+	})
+}
@@ -0,0 +1,352 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxRetries is the number of times ExecuteRead and ExecuteWrite retry work
+// which fails with a retriable error, as recorded.
+const maxRetries = 100
+
+var (
+	errSessionClosed     = errors.New("replay: session is closed")
+	errTransactionClosed = errors.New("replay: transaction is closed")
+)
+
+type (
+	// Replayer is a [neo4j.DriverWithContext] which serves the responses
+	// recorded by a [Recorder] in place of a Neo4j server.
+	//
+	// Queries are matched to recorded ones by their fingerprint: their Cypher,
+	// ignoring whitespace, and parameters. Queries recorded more than once are
+	// served in the order they were recorded. Running a query which wasn't
+	// recorded, or more times than it was recorded, fails with an error
+	// describing how it diverges from the recording.
+	//
+	// Transactions aren't replayed, so committing and rolling back always
+	// succeed.
+	//
+	// It's safe for concurrent use.
+	Replayer struct {
+		path   string
+		ignore map[string]bool
+
+		mu           sync.Mutex
+		interactions []*response
+		pending      map[string][]*response
+	}
+
+	// Option configures a [Replayer].
+	Option func(*Replayer)
+
+	// response is a recorded interaction, decoded for replaying.
+	response struct {
+		interaction *interaction
+		fingerprint string
+		records     []*neo4j.Record
+		replayed    bool
+	}
+
+	replayingSession struct {
+		neo4j.SessionWithContext
+		replayer *Replayer
+		closed   bool
+	}
+
+	replayingTransaction struct {
+		neo4j.ExplicitTransaction
+		replayer *Replayer
+		done     bool
+	}
+)
+
+var (
+	_ neo4j.DriverWithContext   = (*Replayer)(nil)
+	_ neo4j.SessionWithContext  = (*replayingSession)(nil)
+	_ neo4j.ExplicitTransaction = (*replayingTransaction)(nil)
+	_ neo4j.ManagedTransaction  = (*replayingTransaction)(nil)
+)
+
+// IgnoreParams excludes the parameters with the given names from fingerprints,
+// so that queries are matched regardless of their values. This is useful for
+// parameters which change every run, such as timestamps and generated IDs.
+func IgnoreParams(names ...string) Option {
+	return func(r *Replayer) {
+		for _, name := range names {
+			r.ignore[name] = true
+		}
+	}
+}
+
+// Load creates a [Replayer] serving the responses recorded in the golden file
+// at path.
+//
+//	r, err := replay.Load("testdata/people.json")
+//	...
+//	n, err := neogo.NewWithDriver(r)
+//	...
+//	if err := r.ExpectationsWereMet(); err != nil {
+//		t.Error(err)
+//	}
+func Load(path string, opts ...Option) (*Replayer, error) {
+	rec, err := loadRecording(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replayer{
+		path:    path,
+		ignore:  map[string]bool{},
+		pending: map[string][]*response{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	for n, i := range rec.Interactions {
+		res := &response{interaction: i, fingerprint: fingerprint(i.Query, i.Params, r.ignore)}
+		for _, values := range i.Records {
+			decoded, err := decode(values)
+			if err != nil {
+				return nil, fmt.Errorf("replay: query %d in %s: %w", n, path, err)
+			}
+			l, ok := decoded.([]any)
+			if !ok || len(l) != len(i.Keys) {
+				return nil, fmt.Errorf("replay: query %d in %s: records must be lists of %d values", n, path, len(i.Keys))
+			}
+			res.records = append(res.records, &neo4j.Record{Keys: i.Keys, Values: l})
+		}
+		if _, err := newSummary(queryInfo{}, i.Summary); err != nil {
+			return nil, fmt.Errorf("replay: query %d in %s: %w", n, path, err)
+		}
+		r.interactions = append(r.interactions, res)
+		r.pending[res.fingerprint] = append(r.pending[res.fingerprint], res)
+	}
+	return r, nil
+}
+
+// ExpectationsWereMet returns an error listing the recorded queries which
+// haven't been replayed.
+func (r *Replayer) ExpectationsWereMet() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, res := range r.interactions {
+		if !res.replayed {
+			errs = append(errs, fmt.Errorf("recorded query was not run:\n%s", res.interaction.Query))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("replay: %d of %d queries in %s were not run: %w", len(errs), len(r.interactions), r.path, errors.Join(errs...))
+}
+
+// replay serves the response recorded for the query cypher.
+func (r *Replayer) replay(cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	encodedParams, err := encodeMap(params)
+	if err != nil {
+		return nil, fmt.Errorf("replay: cannot encode parameters: %w", err)
+	}
+	fp := fingerprint(cypher, encodedParams, r.ignore)
+
+	r.mu.Lock()
+	pending := r.pending[fp]
+	if len(pending) == 0 {
+		r.mu.Unlock()
+		return nil, r.diverged(cypher, encodedParams, fp)
+	}
+	res := pending[0]
+	r.pending[fp] = pending[1:]
+	res.replayed = true
+	r.mu.Unlock()
+
+	i := res.interaction
+	if i.Error != nil {
+		return nil, newError(i.Error)
+	}
+	summary, err := newSummary(queryInfo{text: cypher, params: params}, i.Summary)
+	if err != nil {
+		return nil, err
+	}
+	keys := i.Keys
+	if keys == nil {
+		keys = []string{}
+	}
+	return newResult(keys, res.records, summary), nil
+}
+
+// diverged describes how the query cypher, with fingerprint fp, diverges from
+// the recording.
+func (r *Replayer) diverged(cypher string, params map[string]any, fp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "replay: query diverges from %s:\n%s\nwith params: %s", r.path, cypher, strings.Join(sortedKeys(params), ", "))
+
+	normalized := strings.Join(strings.Fields(cypher), " ")
+	recorded, sameCypher := 0, []*response{}
+	for _, res := range r.interactions {
+		if res.fingerprint == fp {
+			recorded++
+		} else if strings.Join(strings.Fields(res.interaction.Query), " ") == normalized {
+			sameCypher = append(sameCypher, res)
+		}
+	}
+	switch {
+	case recorded > 0:
+		fmt.Fprintf(&b, "\nit was recorded %d times, and has already been replayed", recorded)
+	case len(sameCypher) > 0:
+		var diffs []string
+		for _, name := range diffParams(params, sameCypher[0].interaction.Params, r.ignore) {
+			diffs = append(diffs, fmt.Sprintf("%s: recorded %s, got %s",
+				name, canonicalJSON(sameCypher[0].interaction.Params[name]), canonicalJSON(params[name])))
+		}
+		fmt.Fprintf(&b, "\nthe query was recorded %d times with different params:\n  %s", len(sameCypher), strings.Join(diffs, "\n  "))
+	default:
+		b.WriteString("\nthe query was not recorded")
+	}
+	return errors.New(b.String())
+}
+
+// diffParams returns the names of the parameters which differ between a and b.
+func diffParams(a, b map[string]any, ignore map[string]bool) []string {
+	names := map[string]any{}
+	for k := range a {
+		names[k] = nil
+	}
+	for k := range b {
+		names[k] = nil
+	}
+	var diffs []string
+	for _, k := range sortedKeys(names) {
+		if ignore[k] {
+			continue
+		}
+		_, inA := a[k]
+		_, inB := b[k]
+		if inA != inB || string(canonicalJSON(a[k])) != string(canonicalJSON(b[k])) {
+			diffs = append(diffs, k)
+		}
+	}
+	return diffs
+}
+
+func (r *Replayer) NewSession(ctx context.Context, config neo4j.SessionConfig) neo4j.SessionWithContext {
+	return &replayingSession{replayer: r}
+}
+
+func (r *Replayer) ExecuteQueryBookmarkManager() neo4j.BookmarkManager {
+	return nil
+}
+
+func (r *Replayer) Target() url.URL {
+	return url.URL{Scheme: "replay", Path: r.path}
+}
+
+func (r *Replayer) VerifyConnectivity(ctx context.Context) error {
+	return nil
+}
+
+func (r *Replayer) VerifyAuthentication(ctx context.Context, auth *neo4j.AuthToken) error {
+	return nil
+}
+
+func (r *Replayer) Close(ctx context.Context) error {
+	return nil
+}
+
+func (r *Replayer) IsEncrypted() bool {
+	return false
+}
+
+func (r *Replayer) GetServerInfo(ctx context.Context) (neo4j.ServerInfo, error) {
+	for _, res := range r.interactions {
+		if s := res.interaction.Summary; s != nil && s.Server != nil {
+			return serverInfo{*s.Server}, nil
+		}
+	}
+	return serverInfo{}, nil
+}
+
+func (s *replayingSession) LastBookmarks() neo4j.Bookmarks {
+	return neo4j.Bookmarks{}
+}
+
+func (s *replayingSession) BeginTransaction(ctx context.Context, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ExplicitTransaction, error) {
+	if s.closed {
+		return nil, errSessionClosed
+	}
+	return &replayingTransaction{replayer: s.replayer}, nil
+}
+
+func (s *replayingSession) ExecuteRead(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(ctx, work)
+}
+
+func (s *replayingSession) ExecuteWrite(ctx context.Context, work neo4j.ManagedTransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.execute(ctx, work)
+}
+
+// execute runs work, retrying it if it fails with a retriable error, so that
+// recorded retries are replayed.
+func (s *replayingSession) execute(ctx context.Context, work neo4j.ManagedTransactionWork) (out any, err error) {
+	if s.closed {
+		return nil, errSessionClosed
+	}
+	for attempt := 0; ; attempt++ {
+		out, err = work(&replayingTransaction{replayer: s.replayer})
+		var neoErr *neo4j.Neo4jError
+		if err == nil || !errors.As(err, &neoErr) || !neoErr.IsRetriable() || attempt == maxRetries {
+			return out, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Join(err, ctxErr)
+		}
+	}
+}
+
+func (s *replayingSession) Run(ctx context.Context, cypher string, params map[string]any, configurers ...func(*neo4j.TransactionConfig)) (neo4j.ResultWithContext, error) {
+	if s.closed {
+		return nil, errSessionClosed
+	}
+	return s.replayer.replay(cypher, params)
+}
+
+func (s *replayingSession) Close(ctx context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func (t *replayingTransaction) Run(ctx context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	if t.done {
+		return nil, errTransactionClosed
+	}
+	return t.replayer.replay(cypher, params)
+}
+
+func (t *replayingTransaction) Commit(ctx context.Context) error {
+	if t.done {
+		return errTransactionClosed
+	}
+	t.done = true
+	return nil
+}
+
+func (t *replayingTransaction) Rollback(ctx context.Context) error {
+	if t.done {
+		return errTransactionClosed
+	}
+	t.done = true
+	return nil
+}
+
+func (t *replayingTransaction) Close(ctx context.Context) error {
+	t.done = true
+	return nil
+}
@@ -0,0 +1,308 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+)
+
+type (
+	// result is a buffered [neo4j.ResultWithContext], as served by both
+	// recording and replaying drivers.
+	result struct {
+		neo4j.ResultWithContext
+		keys    []string
+		records []*neo4j.Record
+		// cursor is the index of the current record.
+		cursor  int
+		summary neo4j.ResultSummary
+	}
+
+	summary struct {
+		query   queryInfo
+		data    *summaryData
+		plan    *plan
+		profile *plan
+	}
+
+	queryInfo struct {
+		text   string
+		params map[string]any
+	}
+
+	plan struct {
+		data     *planData
+		args     map[string]any
+		children []*plan
+	}
+
+	// unprofiledPlan is a [plan] as a [neo4j.Plan].
+	unprofiledPlan struct{ *plan }
+
+	counters struct{ d countersData }
+
+	serverInfo struct{ d serverData }
+
+	databaseInfo string
+)
+
+var (
+	_ neo4j.ResultWithContext = (*result)(nil)
+	_ neo4j.ResultSummary     = (*summary)(nil)
+	_ neo4j.ProfiledPlan      = (*plan)(nil)
+	_ neo4j.Plan              = unprofiledPlan{}
+	_ neo4j.Counters          = counters{}
+	_ neo4j.ServerInfo        = serverInfo{}
+)
+
+func newResult(keys []string, records []*neo4j.Record, summary neo4j.ResultSummary) *result {
+	return &result{keys: keys, records: records, cursor: -1, summary: summary}
+}
+
+func (r *result) Keys() ([]string, error) {
+	return r.keys, nil
+}
+
+func (r *result) NextRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !r.Next(ctx) {
+		*record = nil
+		return false
+	}
+	*record = r.Record()
+	return true
+}
+
+func (r *result) Next(ctx context.Context) bool {
+	if r.cursor < len(r.records) {
+		r.cursor++
+	}
+	return r.cursor < len(r.records)
+}
+
+func (r *result) PeekRecord(ctx context.Context, record **neo4j.Record) bool {
+	if !r.Peek(ctx) {
+		*record = nil
+		return false
+	}
+	*record = r.records[r.cursor+1]
+	return true
+}
+
+func (r *result) Peek(ctx context.Context) bool {
+	return r.cursor+1 < len(r.records)
+}
+
+func (r *result) Err() error {
+	return nil
+}
+
+func (r *result) Record() *neo4j.Record {
+	if r.cursor < 0 || r.cursor >= len(r.records) {
+		return nil
+	}
+	return r.records[r.cursor]
+}
+
+func (r *result) Collect(ctx context.Context) ([]*neo4j.Record, error) {
+	remaining := r.records[min(r.cursor+1, len(r.records)):]
+	r.cursor = len(r.records)
+	return remaining, nil
+}
+
+func (r *result) Records(ctx context.Context) func(yield func(*neo4j.Record, error) bool) {
+	return func(yield func(*neo4j.Record, error) bool) {
+		for r.Next(ctx) {
+			if !yield(r.Record(), nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *result) Single(ctx context.Context) (*neo4j.Record, error) {
+	remaining, _ := r.Collect(ctx)
+	switch len(remaining) {
+	case 0:
+		return nil, errors.New("replay: result contains no more records")
+	case 1:
+		return remaining[0], nil
+	}
+	return nil, errors.New("replay: result contains more than one record")
+}
+
+func (r *result) Consume(ctx context.Context) (neo4j.ResultSummary, error) {
+	r.cursor = len(r.records)
+	return r.summary, nil
+}
+
+func (r *result) IsOpen() bool {
+	return r.cursor < len(r.records)
+}
+
+// newSummary creates the summary of query from data, decoding plan arguments.
+func newSummary(query queryInfo, data *summaryData) (*summary, error) {
+	s := &summary{query: query, data: data}
+	if data == nil {
+		s.data = &summaryData{}
+	}
+	var err error
+	if s.plan, err = newPlan(s.data.Plan); err != nil {
+		return nil, err
+	}
+	if s.profile, err = newPlan(s.data.Profile); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *summary) Server() neo4j.ServerInfo {
+	if s.data.Server == nil {
+		return serverInfo{}
+	}
+	return serverInfo{*s.data.Server}
+}
+
+func (s *summary) Query() neo4j.Query {
+	return s.query
+}
+
+func (s *summary) StatementType() neo4j.StatementType {
+	switch s.data.StatementType {
+	case "r":
+		return neo4j.StatementTypeReadOnly
+	case "rw":
+		return neo4j.StatementTypeReadWrite
+	case "w":
+		return neo4j.StatementTypeWriteOnly
+	case "s":
+		return neo4j.StatementTypeSchemaWrite
+	}
+	return neo4j.StatementTypeUnknown
+}
+
+func (s *summary) Counters() neo4j.Counters {
+	if s.data.Counters == nil {
+		return counters{}
+	}
+	return counters{*s.data.Counters}
+}
+
+func (s *summary) Plan() neo4j.Plan {
+	if s.plan == nil {
+		return nil
+	}
+	return unprofiledPlan{s.plan}
+}
+
+func (s *summary) Profile() neo4j.ProfiledPlan {
+	if s.profile == nil {
+		return nil
+	}
+	return s.profile
+}
+
+func (s *summary) Notifications() []neo4j.Notification {
+	return nil
+}
+
+func (s *summary) GqlStatusObjects() []neo4j.GqlStatusObject {
+	return nil
+}
+
+func (s *summary) ResultAvailableAfter() time.Duration {
+	return 0
+}
+
+func (s *summary) ResultConsumedAfter() time.Duration {
+	return 0
+}
+
+func (s *summary) Database() neo4j.DatabaseInfo {
+	return databaseInfo(s.data.Database)
+}
+
+func (q queryInfo) Text() string               { return q.text }
+func (q queryInfo) Parameters() map[string]any { return q.params }
+
+func (d databaseInfo) Name() string { return string(d) }
+
+func (i serverInfo) Address() string { return i.d.Address }
+func (i serverInfo) Agent() string   { return i.d.Agent }
+
+func (i serverInfo) ProtocolVersion() db.ProtocolVersion {
+	var v db.ProtocolVersion
+	major, minor, _ := strings.Cut(i.d.Protocol, ".")
+	v.Major, _ = strconv.Atoi(major)
+	v.Minor, _ = strconv.Atoi(minor)
+	return v
+}
+
+func (c counters) ContainsUpdates() bool {
+	return c.d.NodesCreated+c.d.NodesDeleted+c.d.RelationshipsCreated+c.d.RelationshipsDeleted+
+		c.d.PropertiesSet+c.d.LabelsAdded+c.d.LabelsRemoved+c.d.IndexesAdded+c.d.IndexesRemoved+
+		c.d.ConstraintsAdded+c.d.ConstraintsRemoved > 0
+}
+func (c counters) NodesCreated() int           { return c.d.NodesCreated }
+func (c counters) NodesDeleted() int           { return c.d.NodesDeleted }
+func (c counters) RelationshipsCreated() int   { return c.d.RelationshipsCreated }
+func (c counters) RelationshipsDeleted() int   { return c.d.RelationshipsDeleted }
+func (c counters) PropertiesSet() int          { return c.d.PropertiesSet }
+func (c counters) LabelsAdded() int            { return c.d.LabelsAdded }
+func (c counters) LabelsRemoved() int          { return c.d.LabelsRemoved }
+func (c counters) IndexesAdded() int           { return c.d.IndexesAdded }
+func (c counters) IndexesRemoved() int         { return c.d.IndexesRemoved }
+func (c counters) ConstraintsAdded() int       { return c.d.ConstraintsAdded }
+func (c counters) ConstraintsRemoved() int     { return c.d.ConstraintsRemoved }
+func (c counters) SystemUpdates() int          { return c.d.SystemUpdates }
+func (c counters) ContainsSystemUpdates() bool { return c.d.SystemUpdates > 0 }
+
+func newPlan(data *planData) (*plan, error) {
+	if data == nil {
+		return nil, nil
+	}
+	args, err := decodeMap(data.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("plan arguments: %w", err)
+	}
+	p := &plan{data: data, args: args}
+	for _, child := range data.Children {
+		c, err := newPlan(child)
+		if err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, c)
+	}
+	return p, nil
+}
+
+func (p *plan) Operator() string           { return p.data.Operator }
+func (p *plan) Arguments() map[string]any  { return p.args }
+func (p *plan) Identifiers() []string      { return p.data.Identifiers }
+func (p *plan) DbHits() int64              { return p.data.DbHits }
+func (p *plan) Records() int64             { return p.data.Records }
+func (p *plan) PageCacheMisses() int64     { return p.data.PageCacheMisses }
+func (p *plan) PageCacheHits() int64       { return p.data.PageCacheHits }
+func (p *plan) PageCacheHitRatio() float64 { return p.data.PageCacheHitRatio }
+func (p *plan) Time() int64                { return p.data.Time }
+
+func (p *plan) Children() []neo4j.ProfiledPlan {
+	children := make([]neo4j.ProfiledPlan, len(p.children))
+	for i, c := range p.children {
+		children[i] = c
+	}
+	return children
+}
+
+func (p unprofiledPlan) Children() []neo4j.Plan {
+	children := make([]neo4j.Plan, len(p.children))
+	for i, c := range p.children {
+		children[i] = unprofiledPlan{c}
+	}
+	return children
+}
@@ -0,0 +1,522 @@
+package replay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// Values are encoded as JSON, using the native JSON representation of nulls,
+// booleans, strings, lists and maps. Integers are encoded as JSON numbers, and
+// floats as JSON numbers with a fraction or exponent so they can be told
+// apart. Other values are encoded as objects with a single tag key beginning
+// with "$", and maps with such keys are wrapped with the "$map" tag.
+const (
+	tagFloat         = "$float"
+	tagBytes         = "$bytes"
+	tagMap           = "$map"
+	tagNode          = "$node"
+	tagRelationship  = "$relationship"
+	tagPath          = "$path"
+	tagDate          = "$date"
+	tagTime          = "$time"
+	tagLocalTime     = "$localTime"
+	tagDateTime      = "$dateTime"
+	tagLocalDateTime = "$localDateTime"
+	tagDuration      = "$duration"
+	tagPoint2D       = "$point2d"
+	tagPoint3D       = "$point3d"
+)
+
+const (
+	dateLayout          = "2006-01-02"
+	timeLayout          = "15:04:05.999999999Z07:00"
+	localTimeLayout     = "15:04:05.999999999"
+	dateTimeLayout      = "2006-01-02T15:04:05.999999999Z07:00"
+	localDateTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+type (
+	encodedNode struct {
+		ElementID string         `json:"elementId"`
+		Labels    []string       `json:"labels"`
+		Props     map[string]any `json:"props"`
+	}
+	encodedRelationship struct {
+		ElementID      string         `json:"elementId"`
+		StartElementID string         `json:"startElementId"`
+		EndElementID   string         `json:"endElementId"`
+		Type           string         `json:"type"`
+		Props          map[string]any `json:"props"`
+	}
+	encodedPath struct {
+		Nodes         []any `json:"nodes"`
+		Relationships []any `json:"relationships"`
+	}
+	encodedDuration struct {
+		Months  int64 `json:"months"`
+		Days    int64 `json:"days"`
+		Seconds int64 `json:"seconds"`
+		Nanos   int   `json:"nanos"`
+	}
+	encodedPoint struct {
+		SRID uint32  `json:"srid"`
+		X    float64 `json:"x"`
+		Y    float64 `json:"y"`
+		Z    float64 `json:"z,omitempty"`
+	}
+)
+
+// encode converts v, a value sent to or received from Neo4j, to its JSON
+// representation.
+func encode(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		return encodeFloat(v), nil
+	case []byte:
+		return map[string]any{tagBytes: base64.StdEncoding.EncodeToString(v)}, nil
+	case []any:
+		return encodeList(v)
+	case map[string]any:
+		m, err := encodeMap(v)
+		if err != nil {
+			return nil, err
+		}
+		for k := range v {
+			if strings.HasPrefix(k, "$") {
+				return map[string]any{tagMap: m}, nil
+			}
+		}
+		return m, nil
+	case neo4j.Node:
+		n, err := encodeNode(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{tagNode: n}, nil
+	case neo4j.Relationship:
+		r, err := encodeRelationship(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{tagRelationship: r}, nil
+	case neo4j.Path:
+		p := encodedPath{Nodes: make([]any, len(v.Nodes)), Relationships: make([]any, len(v.Relationships))}
+		for i, n := range v.Nodes {
+			var err error
+			if p.Nodes[i], err = encodeNode(n); err != nil {
+				return nil, err
+			}
+		}
+		for i, r := range v.Relationships {
+			var err error
+			if p.Relationships[i], err = encodeRelationship(r); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]any{tagPath: p}, nil
+	case neo4j.Date:
+		return map[string]any{tagDate: v.Time().Format(dateLayout)}, nil
+	case neo4j.Time:
+		return map[string]any{tagTime: v.Time().Format(timeLayout)}, nil
+	case neo4j.LocalTime:
+		return map[string]any{tagLocalTime: v.Time().Format(localTimeLayout)}, nil
+	case neo4j.LocalDateTime:
+		return map[string]any{tagLocalDateTime: v.Time().Format(localDateTimeLayout)}, nil
+	case time.Time:
+		s := v.Format(dateTimeLayout)
+		// Named zones are kept so that they can be restored, as the driver
+		// does for DateTimes with a zone ID.
+		if zone := v.Location().String(); zone != "Local" {
+			if _, err := time.LoadLocation(zone); err == nil {
+				s += "[" + zone + "]"
+			}
+		}
+		return map[string]any{tagDateTime: s}, nil
+	case neo4j.Duration:
+		return map[string]any{tagDuration: encodedDuration{v.Months, v.Days, v.Seconds, v.Nanos}}, nil
+	case neo4j.Point2D:
+		return map[string]any{tagPoint2D: encodedPoint{SRID: v.SpatialRefId, X: v.X, Y: v.Y}}, nil
+	case neo4j.Point3D:
+		return map[string]any{tagPoint3D: encodedPoint{SRID: v.SpatialRefId, X: v.X, Y: v.Y, Z: v.Z}}, nil
+	}
+	n, err := normalize(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return encode(n)
+}
+
+func encodeFloat(f float64) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return map[string]any{tagFloat: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return json.Number(s)
+}
+
+func encodeList(l []any) ([]any, error) {
+	out := make([]any, len(l))
+	for i, v := range l {
+		var err error
+		if out[i], err = encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func encodeMap(m map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		var err error
+		if out[k], err = encode(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return out, nil
+}
+
+func encodeNode(n neo4j.Node) (encodedNode, error) {
+	props, err := encodeMap(n.Props)
+	return encodedNode{ElementID: n.ElementId, Labels: n.Labels, Props: props}, err
+}
+
+func encodeRelationship(r neo4j.Relationship) (encodedRelationship, error) {
+	props, err := encodeMap(r.Props)
+	return encodedRelationship{
+		ElementID:      r.ElementId,
+		StartElementID: r.StartElementId,
+		EndElementID:   r.EndElementId,
+		Type:           r.Type,
+		Props:          props,
+	}, err
+}
+
+// normalize converts v to the representation of the value the driver sends
+// to Neo4j, or returns an error if it can't be sent.
+func normalize(v reflect.Value) (any, error) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return normalizeElem(v.Elem())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+		l := make([]any, v.Len())
+		for i := range l {
+			var err error
+			if l[i], err = normalizeElem(v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var err error
+			if m[iter.Key().String()], err = normalizeElem(iter.Value()); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// normalizeElem normalizes v, leaving values of the driver's types as they are.
+func normalizeElem(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch i := v.Interface().(type) {
+	case neo4j.Node, neo4j.Relationship, neo4j.Path,
+		neo4j.Date, neo4j.Time, neo4j.LocalTime, neo4j.LocalDateTime, time.Time,
+		neo4j.Duration, neo4j.Point2D, neo4j.Point3D:
+		return i, nil
+	}
+	return normalize(v)
+}
+
+// decode converts v, decoded from JSON with [json.Decoder.UseNumber], to the
+// value it encodes.
+func decode(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case json.Number:
+		s := v.String()
+		if strings.ContainsAny(s, ".eE") {
+			return strconv.ParseFloat(s, 64)
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			var err error
+			if l[i], err = decode(e); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case map[string]any:
+		if len(v) == 1 {
+			for tag, tagged := range v {
+				if strings.HasPrefix(tag, "$") {
+					return decodeTagged(tag, tagged)
+				}
+			}
+		}
+		return decodeMap(v)
+	}
+	return nil, fmt.Errorf("unexpected JSON value %T", v)
+}
+
+func decodeMap(v any) (map[string]any, error) {
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object but got %T", v)
+	}
+	out := make(map[string]any, len(m))
+	for k, e := range m {
+		var err error
+		if out[k], err = decode(e); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return out, nil
+}
+
+func decodeTagged(tag string, v any) (any, error) {
+	str := func() (string, error) {
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("%s: expected a string but got %T", tag, v)
+		}
+		return s, nil
+	}
+	switch tag {
+	case tagMap:
+		return decodeMap(v)
+	case tagFloat:
+		s, err := str()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(s, 64)
+	case tagBytes:
+		s, err := str()
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	case tagNode:
+		return decodeNode(v)
+	case tagRelationship:
+		return decodeRelationship(v)
+	case tagPath:
+		var p struct {
+			Nodes         []any `json:"nodes"`
+			Relationships []any `json:"relationships"`
+		}
+		if err := remarshal(v, &p); err != nil {
+			return nil, err
+		}
+		path := neo4j.Path{
+			Nodes:         make([]neo4j.Node, len(p.Nodes)),
+			Relationships: make([]neo4j.Relationship, len(p.Relationships)),
+		}
+		for i, n := range p.Nodes {
+			var err error
+			if path.Nodes[i], err = decodeNode(n); err != nil {
+				return nil, err
+			}
+		}
+		for i, r := range p.Relationships {
+			var err error
+			if path.Relationships[i], err = decodeRelationship(r); err != nil {
+				return nil, err
+			}
+		}
+		return path, nil
+	case tagDuration:
+		var d encodedDuration
+		if err := remarshal(v, &d); err != nil {
+			return nil, err
+		}
+		return neo4j.Duration{Months: d.Months, Days: d.Days, Seconds: d.Seconds, Nanos: d.Nanos}, nil
+	case tagPoint2D, tagPoint3D:
+		var p encodedPoint
+		if err := remarshal(v, &p); err != nil {
+			return nil, err
+		}
+		if tag == tagPoint2D {
+			return neo4j.Point2D{X: p.X, Y: p.Y, SpatialRefId: p.SRID}, nil
+		}
+		return neo4j.Point3D{X: p.X, Y: p.Y, Z: p.Z, SpatialRefId: p.SRID}, nil
+	}
+	s, err := str()
+	if err != nil {
+		return nil, err
+	}
+	// Temporal values are constructed as the driver hydrates them, so that
+	// replayed values are identical to recorded ones.
+	switch tag {
+	case tagDate:
+		t, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		return dbtype.Date(t.UTC()), nil
+	case tagTime:
+		t, err := time.Parse(timeLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		_, offset := t.Zone()
+		return dbtype.Time(time.Date(0, 0, 0, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone("Offset", offset))), nil
+	case tagLocalTime:
+		t, err := time.Parse(localTimeLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		return dbtype.LocalTime(time.Date(0, 0, 0, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)), nil
+	case tagLocalDateTime:
+		t, err := time.ParseInLocation(localDateTimeLayout, s, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		return dbtype.LocalDateTime(t), nil
+	case tagDateTime:
+		var zone string
+		if i := strings.IndexByte(s, '['); i >= 0 && strings.HasSuffix(s, "]") {
+			s, zone = s[:i], s[i+1:len(s)-1]
+		}
+		t, err := time.Parse(dateTimeLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		if zone != "" {
+			loc, err := time.LoadLocation(zone)
+			if err != nil {
+				return nil, err
+			}
+			return t.In(loc), nil
+		}
+		_, offset := t.Zone()
+		return t.In(time.FixedZone("Offset", offset)), nil
+	}
+	return nil, fmt.Errorf("unknown tag %q", tag)
+}
+
+func decodeNode(v any) (neo4j.Node, error) {
+	var n struct {
+		ElementID string   `json:"elementId"`
+		Labels    []string `json:"labels"`
+		Props     any      `json:"props"`
+	}
+	if err := remarshal(v, &n); err != nil {
+		return neo4j.Node{}, err
+	}
+	props, err := decodeMap(n.Props)
+	if err != nil {
+		return neo4j.Node{}, err
+	}
+	if n.Labels == nil {
+		n.Labels = []string{}
+	}
+	return neo4j.Node{ElementId: n.ElementID, Labels: n.Labels, Props: props}, nil
+}
+
+func decodeRelationship(v any) (neo4j.Relationship, error) {
+	var r struct {
+		ElementID      string `json:"elementId"`
+		StartElementID string `json:"startElementId"`
+		EndElementID   string `json:"endElementId"`
+		Type           string `json:"type"`
+		Props          any    `json:"props"`
+	}
+	if err := remarshal(v, &r); err != nil {
+		return neo4j.Relationship{}, err
+	}
+	props, err := decodeMap(r.Props)
+	if err != nil {
+		return neo4j.Relationship{}, err
+	}
+	return neo4j.Relationship{
+		ElementId:      r.ElementID,
+		StartElementId: r.StartElementID,
+		EndElementId:   r.EndElementID,
+		Type:           r.Type,
+		Props:          props,
+	}, nil
+}
+
+// remarshal decodes the JSON value v, decoded with [json.Decoder.UseNumber],
+// into out.
+func remarshal(v any, out any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.UseNumber()
+	return d.Decode(out)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func roundTrip(t *testing.T, v any) (any, string) {
+	t.Helper()
+	encoded, err := encode(v)
+	require.NoError(t, err)
+	b, err := json.Marshal(encoded)
+	require.NoError(t, err)
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var generic any
+	require.NoError(t, d.Decode(&generic))
+	decoded, err := decode(generic)
+	require.NoError(t, err)
+	return decoded, string(b)
+}
+
+func TestValues(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	require.NoError(t, err)
+	node := neo4j.Node{
+		ElementId: "4:db:1",
+		Labels:    []string{"Person"},
+		Props:     map[string]any{"name": "Jessie", "$weird": int64(1)},
+	}
+	rel := neo4j.Relationship{
+		ElementId:      "5:db:1",
+		StartElementId: "4:db:1",
+		EndElementId:   "4:db:2",
+		Type:           "KNOWS",
+		Props:          map[string]any{},
+	}
+
+	t.Run("round trips", func(t *testing.T) {
+		for name, v := range map[string]any{
+			"null":           nil,
+			"bool":           true,
+			"int":            int64(math.MaxInt64),
+			"integral float": 1.0,
+			"float":          1.5e-300,
+			"infinite float": math.Inf(-1),
+			"string":         "neogo",
+			"bytes":          []byte("neogo"),
+			"list":           []any{int64(1), "a", nil},
+			"map":            map[string]any{"a": int64(1), "b": []any{2.0}},
+			"tagged map":     map[string]any{"$node": "not a node"},
+			"node":           node,
+			"relationship":   rel,
+			"path":           neo4j.Path{Nodes: []neo4j.Node{node, node}, Relationships: []neo4j.Relationship{rel}},
+			"date":           dbtype.Date(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)),
+			"time":           dbtype.Time(time.Date(0, 0, 0, 13, 30, 0, 5, time.FixedZone("Offset", 3600))),
+			"local time":     dbtype.LocalTime(time.Date(0, 0, 0, 13, 30, 0, 5, time.Local)),
+			"local datetime": dbtype.LocalDateTime(time.Date(2024, 2, 29, 13, 30, 0, 5, time.Local)),
+			"datetime":       time.Date(2024, 2, 29, 13, 30, 0, 5, time.FixedZone("Offset", -7200)),
+			"zoned datetime": time.Date(2024, 2, 29, 13, 30, 0, 5, stockholm),
+			"duration":       neo4j.Duration{Months: 1, Days: 2, Seconds: 3, Nanos: 4},
+			"point2d":        neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203},
+			"point3d":        neo4j.Point3D{X: 1, Y: 2, Z: 3, SpatialRefId: 9157},
+		} {
+			t.Run(name, func(t *testing.T) {
+				decoded, b := roundTrip(t, v)
+				assert.Equal(t, v, decoded, b)
+			})
+		}
+	})
+
+	t.Run("normalizes parameters", func(t *testing.T) {
+		type name string
+		s := "s"
+		decoded, _ := roundTrip(t, map[string]any{
+			"int":     1,
+			"uint":    uint8(2),
+			"float32": float32(0.5),
+			"named":   name("neogo"),
+			"ptr":     &s,
+			"nil":     (*string)(nil),
+			"strings": []string{"a"},
+			"map":     map[string]int{"a": 1},
+			"array":   [2]bool{true, false},
+		})
+		assert.Equal(t, map[string]any{
+			"int":     int64(1),
+			"uint":    int64(2),
+			"float32": 0.5,
+			"named":   "neogo",
+			"ptr":     "s",
+			"nil":     nil,
+			"strings": []any{"a"},
+			"map":     map[string]any{"a": int64(1)},
+			"array":   []any{true, false},
+		}, decoded)
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		_, b := roundTrip(t, []any{int64(1), 1.0, "1"})
+		assert.Equal(t, `[1,1.0,"1"]`, b)
+
+		_, err := encode(struct{}{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,164 @@
+package neogo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+	"github.com/rlch/neogo/query"
+)
+
+type (
+	strictAddress struct {
+		City     string  `json:"city"`
+		Postcode *string `json:"postcode"`
+	}
+	strictPerson struct {
+		Node `neo4j:"Person"`
+
+		Name     string          `json:"name"`
+		Age      int             `json:"age"`
+		Score    float64         `json:"score"`
+		Nickname string          `json:"nickname,omitempty"`
+		Address  strictAddress   `json:"address"`
+		Previous []strictAddress `json:"previous"`
+	}
+)
+
+func strictProps() map[string]any {
+	return map[string]any{
+		"id":      "p1",
+		"name":    "Jessie",
+		"age":     int64(24),
+		"score":   int64(9),
+		"address": map[string]any{"city": "Albuquerque"},
+		"previous": []any{
+			map[string]any{"city": "Phoenix", "postcode": "85001"},
+		},
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	strict := &registry{strict: true}
+	lenient := &registry{}
+
+	t.Run("decodes complete values", func(t *testing.T) {
+		var p strictPerson
+		require.NoError(t, strict.bindValue(strictProps(), reflect.ValueOf(&p)))
+		assert.Equal(t, "Albuquerque", p.Address.City)
+		assert.Equal(t, float64(9), p.Score)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		modify func(props map[string]any)
+		path   string
+		err    error
+	}{
+		{
+			name:   "unknown properties",
+			modify: func(props map[string]any) { props["address"].(map[string]any)["country"] = "US" },
+			path:   "address.country",
+			err:    ErrUnknownProperty,
+		},
+		{
+			name:   "missing required properties",
+			modify: func(props map[string]any) { delete(props["address"].(map[string]any), "city") },
+			path:   "address.city",
+			err:    ErrMissingProperty,
+		},
+		{
+			name:   "null required properties",
+			modify: func(props map[string]any) { props["name"] = nil },
+			path:   "name",
+			err:    ErrMissingProperty,
+		},
+		{
+			name:   "lossy numeric conversions",
+			modify: func(props map[string]any) { props["score"] = int64(1<<53 + 1) },
+			path:   "score",
+			err:    ErrLossyConversion,
+		},
+		{
+			name: "type mismatches",
+			modify: func(props map[string]any) {
+				props["previous"] = []any{map[string]any{"city": int64(1)}}
+			},
+			path: "previous[0].city",
+			err:  ErrTypeMismatch,
+		},
+	} {
+		t.Run("reports "+tc.name, func(t *testing.T) {
+			props := strictProps()
+			tc.modify(props)
+
+			var p strictPerson
+			err := strict.bindValue(props, reflect.ValueOf(&p))
+			var decodeErr *DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tc.path, decodeErr.Path)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("ignores drift when lenient", func(t *testing.T) {
+		props := strictProps()
+		props["unknown"] = true
+		delete(props, "name")
+		var p strictPerson
+		require.NoError(t, lenient.bindValue(props, reflect.ValueOf(&p)))
+	})
+
+	t.Run("does not coerce primitives", func(t *testing.T) {
+		var n int
+		require.NoError(t, lenient.bindValue(1.5, reflect.ValueOf(&n).Elem()))
+		assert.Equal(t, 1, n)
+		assert.ErrorIs(t, strict.bindValue(1.5, reflect.ValueOf(&n).Elem()), ErrLossyConversion)
+
+		var s string
+		require.NoError(t, lenient.bindValue(int64(1), reflect.ValueOf(&s).Elem()))
+		assert.Equal(t, "1", s)
+		assert.ErrorIs(t, strict.bindValue(int64(1), reflect.ValueOf(&s).Elem()), ErrTypeMismatch)
+	})
+
+	t.Run("is enabled per query", func(t *testing.T) {
+		props := strictProps()
+		props["unknown"] = true
+		m := NewMock()
+		m.BindRecords([]map[string]any{{"person": props}, {"person": props}})
+		m.BindRecords([]map[string]any{{"person": props}, {"person": props}})
+
+		var people []strictPerson
+		q := func() query.Runner {
+			return m.Exec().
+				Match(db.Node(db.Qual(&people, "person"))).
+				Return(&people)
+		}
+		require.NoError(t, q().Run(context.Background()))
+		require.Len(t, people, 2)
+
+		err := q().Run(context.Background(), StrictDecoding())
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, "person[0].unknown", decodeErr.Path)
+		assert.ErrorContains(t, err, "cannot decode person[0].unknown: unknown property")
+	})
+
+	t.Run("is enabled globally", func(t *testing.T) {
+		m := NewMock()
+		m.(*mockDriverImpl).strict = true
+		m.Bind(map[string]any{"person": map[string]any{"name": "Jessie"}})
+		var p strictPerson
+		err := m.Exec().
+			Match(db.Node(db.Qual(&p, "person"))).
+			Return(&p).
+			Run(context.Background())
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.ErrorIs(t, err, ErrMissingProperty)
+	})
+}
@@ -0,0 +1,148 @@
+package neogo
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// TimeMapping determines the Cypher type that [time.Time] values are sent to
+// Neo4j as when used in parameters.
+type TimeMapping int
+
+const (
+	// TimeAsDateTime sends [time.Time] as a zoned DATETIME. This is the default.
+	TimeAsDateTime TimeMapping = iota
+	// TimeAsLocalDateTime sends [time.Time] as a LOCAL DATETIME, using the wall
+	// clock of its location.
+	TimeAsLocalDateTime
+	// TimeAsDate sends [time.Time] as a DATE, using the calendar day of its
+	// location.
+	TimeAsDate
+)
+
+func (m TimeMapping) String() string {
+	switch m {
+	case TimeAsDateTime:
+		return "DateTime"
+	case TimeAsLocalDateTime:
+		return "LocalDateTime"
+	case TimeAsDate:
+		return "Date"
+	}
+	return fmt.Sprintf("TimeMapping(%d)", int(m))
+}
+
+func (m TimeMapping) convert(t time.Time) any {
+	switch m {
+	case TimeAsLocalDateTime:
+		return neo4j.LocalDateTime(t)
+	case TimeAsDate:
+		return neo4j.Date(t)
+	}
+	return t
+}
+
+var (
+	rTime     = reflect.TypeOf(time.Time{})
+	rDuration = reflect.TypeOf(time.Duration(0))
+
+	rNeo4jDuration = reflect.TypeOf(neo4j.Duration{})
+
+	// temporalTypes are the driver's temporal types, all of which are defined
+	// as time.Time and so can be converted between one another.
+	temporalTypes = map[reflect.Type]struct{}{
+		rTime:                                 {},
+		reflect.TypeOf(neo4j.Date{}):          {},
+		reflect.TypeOf(neo4j.LocalDateTime{}): {},
+		reflect.TypeOf(neo4j.LocalTime{}):     {},
+		reflect.TypeOf(neo4j.Time{}):          {},
+	}
+	// nativeTypes are sent to the driver as-is.
+	nativeTypes = map[reflect.Type]struct{}{
+		rNeo4jDuration:                  {},
+		reflect.TypeOf(neo4j.Point2D{}): {},
+		reflect.TypeOf(neo4j.Point3D{}): {},
+	}
+)
+
+// newTemporalEncoder returns an encoder for temporal and spatial types, or nil
+// if t is neither.
+func newTemporalEncoder(t reflect.Type) encoderFunc {
+	switch t {
+	case rTime:
+		return func(r *registry, v reflect.Value) (any, error) {
+			return r.timeMapping.convert(v.Interface().(time.Time)), nil
+		}
+	case rDuration:
+		return func(r *registry, v reflect.Value) (any, error) {
+			return durationToNeo4j(time.Duration(v.Int())), nil
+		}
+	}
+	_, temporal := temporalTypes[t]
+	_, native := nativeTypes[t]
+	if temporal || native {
+		return func(r *registry, v reflect.Value) (any, error) {
+			return v.Interface(), nil
+		}
+	}
+	return nil
+}
+
+// newTemporalDecoder returns a decoder converting the driver's temporal values
+// into temporal types, falling back to next for any other value. It returns
+// nil if t isn't a temporal type.
+func newTemporalDecoder(t reflect.Type, next decoderFunc) decoderFunc {
+	if t == rDuration {
+		return func(r *registry, from any, v reflect.Value) error {
+			d, ok := from.(neo4j.Duration)
+			if !ok {
+				return next(r, from, v)
+			}
+			duration, err := durationFromNeo4j(d)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(duration))
+			return nil
+		}
+	}
+	if _, ok := temporalTypes[t]; !ok {
+		return nil
+	}
+	return func(r *registry, from any, v reflect.Value) error {
+		fv := reflect.ValueOf(from)
+		if _, ok := temporalTypes[fv.Type()]; !ok {
+			return next(r, from, v)
+		}
+		v.Set(fv.Convert(t))
+		return nil
+	}
+}
+
+func durationToNeo4j(d time.Duration) neo4j.Duration {
+	secs, nanos := int64(d/time.Second), int(d%time.Second)
+	if nanos < 0 {
+		secs--
+		nanos += int(time.Second)
+	}
+	return neo4j.Duration{Seconds: secs, Nanos: nanos}
+}
+
+// durationFromNeo4j converts d to a time.Duration. Days are treated as 24
+// hours, whereas months have no fixed length and cannot be converted.
+func durationFromNeo4j(d neo4j.Duration) (time.Duration, error) {
+	if d.Months != 0 {
+		return 0, fmt.Errorf("cannot decode %s into time.Duration: months have no fixed duration", d)
+	}
+	const maxSeconds = math.MaxInt64 / int64(time.Second)
+	secs := d.Days*24*60*60 + d.Seconds
+	if d.Days > maxSeconds/(24*60*60) || d.Days < -maxSeconds/(24*60*60) ||
+		secs > maxSeconds-1 || secs < -maxSeconds+1 {
+		return 0, fmt.Errorf("cannot decode %s into time.Duration: value overflows", d)
+	}
+	return time.Duration(secs)*time.Second + time.Duration(d.Nanos), nil
+}
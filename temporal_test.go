@@ -0,0 +1,163 @@
+package neogo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rlch/neogo/db"
+)
+
+type temporalEvent struct {
+	Name     string                  `json:"name"`
+	At       time.Time               `json:"at"`
+	Day      neo4j.Date              `json:"day"`
+	Duration time.Duration           `json:"duration"`
+	Location neo4j.Point2D           `json:"location"`
+	Path     []*neo4j.Point3D        `json:"path"`
+	Times    []time.Time             `json:"times"`
+	Valuer   simpleValuer[time.Time] `json:"valuer"`
+}
+
+func TestTemporalParams(t *testing.T) {
+	loc := time.FixedZone("AEST", 10*60*60)
+	at := time.Date(2024, 3, 4, 5, 6, 7, 8, loc)
+	event := temporalEvent{
+		Name:     "launch",
+		At:       at,
+		Day:      neo4j.Date(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)),
+		Duration: 90*time.Minute + 5*time.Nanosecond,
+		Location: neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203},
+		Path:     []*neo4j.Point3D{{X: 1, Y: 2, Z: 3, SpatialRefId: 9157}, nil},
+		Times:    []time.Time{at},
+		Valuer:   simpleValuer[time.Time]{Value: at},
+	}
+
+	t.Run("keeps native types within structs and slices", func(t *testing.T) {
+		r := &registry{}
+		params, err := r.canonicalizeParams(map[string]any{
+			"event":  event,
+			"events": []*temporalEvent{&event},
+			"at":     at,
+			"day":    &event.Day,
+		})
+		require.NoError(t, err)
+		expected := map[string]any{
+			"name": "launch",
+			"at":   at,
+			"day":  event.Day,
+			"duration": neo4j.Duration{
+				Seconds: 90 * 60,
+				Nanos:   5,
+			},
+			"location": event.Location,
+			"path":     []any{*event.Path[0], nil},
+			"times":    []any{at},
+			"valuer":   at,
+		}
+		assert.Equal(t, expected, params["event"])
+		assert.Equal(t, []any{expected}, params["events"])
+		assert.Equal(t, at, params["at"])
+		assert.Equal(t, event.Day, params["day"])
+	})
+
+	t.Run("maps time.Time according to config", func(t *testing.T) {
+		for mapping, expected := range map[TimeMapping]any{
+			TimeAsDateTime:      at,
+			TimeAsLocalDateTime: neo4j.LocalDateTime(at),
+			TimeAsDate:          neo4j.Date(at),
+		} {
+			t.Run(mapping.String(), func(t *testing.T) {
+				r := &registry{timeMapping: mapping}
+				params, err := r.canonicalizeParams(map[string]any{
+					"at":    at,
+					"event": event,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, expected, params["at"])
+				props := params["event"].(map[string]any)
+				assert.Equal(t, expected, props["at"])
+				assert.Equal(t, []any{expected}, props["times"])
+				assert.Equal(t, expected, props["valuer"])
+				// Values which are already driver types are left untouched.
+				assert.Equal(t, event.Day, props["day"])
+			})
+		}
+	})
+
+	t.Run("converts negative durations", func(t *testing.T) {
+		assert.Equal(t, neo4j.Duration{Seconds: -2, Nanos: 500_000_000}, durationToNeo4j(-1500*time.Millisecond))
+	})
+
+	t.Run("sends native types to the driver", func(t *testing.T) {
+		m, rec := newRecordingMock()
+		m.Bind(nil)
+		err := m.Exec().
+			Create(db.Node(db.Qual(&event, "e"))).
+			Run(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rec.params, 1)
+		params := rec.params[0]
+		assert.Equal(t, at, params["e_at"])
+		assert.Equal(t, []any{at}, params["e_times"])
+		assert.Equal(t, neo4j.Duration{Seconds: 90 * 60, Nanos: 5}, params["e_duration"])
+		assert.Equal(t, event.Location, params["e_location"])
+	})
+}
+
+func TestTemporalDecoding(t *testing.T) {
+	r := &registry{}
+	at := time.Date(2024, 3, 4, 5, 6, 7, 8, time.UTC)
+
+	t.Run("decodes driver temporal types", func(t *testing.T) {
+		var out struct {
+			DateTime      time.Time           `json:"dateTime"`
+			LocalDateTime time.Time           `json:"localDateTime"`
+			Date          time.Time           `json:"date"`
+			AsDate        neo4j.Date          `json:"asDate"`
+			AsLocal       neo4j.LocalDateTime `json:"asLocal"`
+			Duration      time.Duration       `json:"duration"`
+			Nanos         time.Duration       `json:"nanos"`
+			Location      *neo4j.Point2D      `json:"location"`
+			Legacy        time.Time           `json:"legacy"`
+		}
+		err := r.bindValue(map[string]any{
+			"dateTime":      at,
+			"localDateTime": neo4j.LocalDateTime(at),
+			"date":          neo4j.Date(at),
+			"asDate":        at,
+			"asLocal":       neo4j.LocalDateTime(at),
+			"duration":      neo4j.Duration{Days: 1, Seconds: 60, Nanos: 1},
+			"nanos":         int64(time.Second),
+			"location":      neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203},
+			"legacy":        at.Format(time.RFC3339Nano),
+		}, reflect.ValueOf(&out))
+		require.NoError(t, err)
+		assert.Equal(t, at, out.DateTime)
+		assert.Equal(t, at, out.LocalDateTime)
+		assert.Equal(t, at, out.Date)
+		assert.Equal(t, neo4j.Date(at), out.AsDate)
+		assert.Equal(t, neo4j.LocalDateTime(at), out.AsLocal)
+		assert.Equal(t, 24*time.Hour+time.Minute+time.Nanosecond, out.Duration)
+		assert.Equal(t, time.Second, out.Nanos)
+		assert.Equal(t, &neo4j.Point2D{X: 1, Y: 2, SpatialRefId: 7203}, out.Location)
+		assert.True(t, at.Equal(out.Legacy))
+	})
+
+	t.Run("errors on durations with months", func(t *testing.T) {
+		var d time.Duration
+		err := r.bindValue(neo4j.Duration{Months: 1}, reflect.ValueOf(&d))
+		assert.ErrorContains(t, err, "months have no fixed duration")
+	})
+
+	t.Run("errors on overflowing durations", func(t *testing.T) {
+		var d time.Duration
+		err := r.bindValue(neo4j.Duration{Days: 1 << 40}, reflect.ValueOf(&d))
+		assert.ErrorContains(t, err, "overflows")
+	})
+}